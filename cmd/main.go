@@ -2,21 +2,25 @@ package main
 
 import (
 	"log"
+	"net/netip"
 	"os"
+	"strconv"
+	"strings"
 	"testovoe/internal/controller"
 	"testovoe/internal/pkg"
-	"testovoe/internal/repository"
+	"testovoe/internal/repositoriy"
 	"testovoe/internal/usecase"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 func main() {
-	logger := pkg.NewConsoleLogger()
+	logger := pkg.NewZapLogger()
 
 	dsn := getDSN()
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		logger.Error("failed to connect to database", "error", err)
 		log.Fatal(err)
@@ -25,12 +29,53 @@ func main() {
 	// ❌ УБИРАЕМ AutoMigrate - теперь миграции через Goose
 	logger.Info("database connected successfully")
 
-	questionRepo := repository.NewGormQuestionRepository(db, logger)
-	answerRepo := repository.NewGormAnswerRepository(db, logger)
-	questionUC := usecase.NewQuestionUseCase(questionRepo)
-	answerUC := usecase.NewAnswerUseCase(answerRepo, questionRepo)
-	handlers := controller.NewHTTPHandler(answerUC, questionUC, logger)
-	server := &controller.HTTPServer{Handlers: *handlers}
+	questionRepo := repositoriy.NewGormQuestionRepository(db, logger)
+	answerRepo := repositoriy.NewGormAnswerRepository(db, logger)
+	searchRepo := repositoriy.NewGormSearchRepository(db, logger)
+	voteRepo := repositoriy.NewGormVoteRepository(db, logger)
+	tagRepo := repositoriy.NewGormTagRepository(db, logger)
+	commentRepo := repositoriy.NewGormCommentRepository(db, logger)
+	userRepo := repositoriy.NewGormUserRepository(db, logger)
+	refreshTokenRepo := repositoriy.NewGormRefreshTokenRepository(db, logger)
+	apiKeyRepo := repositoriy.NewGormAPIKeyRepository(db, logger)
+	identityRepo := repositoriy.NewGormIdentityRepository(db, logger)
+	idempotencyRepo := repositoriy.NewGormIdempotencyRepository(db, logger)
+	heldRepo := repositoriy.NewGormHeldContentRepository(db, logger)
+	flagRepo := repositoriy.NewGormFlagRepository(db, logger)
+	defaults := usecase.DefaultTextLimits()
+	textLimits := usecase.TextLimits{
+		Question: getLengthLimit("QUESTION_TEXT_LENGTH", defaults.Question),
+		Answer:   getLengthLimit("ANSWER_TEXT_LENGTH", defaults.Answer),
+		Comment:  getLengthLimit("COMMENT_TEXT_LENGTH", defaults.Comment),
+	}
+	moderation := usecase.NewContentModeration(heldRepo, getContentPolicies(heldRepo)...)
+	questionUC := usecase.NewQuestionUseCase(questionRepo, answerRepo, commentRepo, userRepo, textLimits, moderation)
+	answerUC := usecase.NewAnswerUseCase(answerRepo, questionRepo, userRepo, textLimits, moderation)
+	moderationUC := usecase.NewModerationUseCase(heldRepo, questionUC, answerUC)
+	flagUC := usecase.NewFlagUseCase(flagRepo, questionRepo, answerRepo, getInt("FLAGS_TO_HIDE", 3))
+	searchUC := usecase.NewSearchUseCase(searchRepo)
+	trashUC := usecase.NewTrashUseCase(questionRepo, answerRepo, getDuration("TRASH_RETENTION", 30*24*time.Hour))
+	voteUC := usecase.NewVoteUseCase(voteRepo, questionRepo, answerRepo)
+	tagUC := usecase.NewTagUseCase(tagRepo)
+	commentUC := usecase.NewCommentUseCase(commentRepo, questionRepo, answerRepo, textLimits)
+	userUC := usecase.NewUserUseCase(userRepo)
+	tokens := pkg.NewHMACTokens([]byte(getRequired("JWT_SECRET")), getDuration("JWT_TTL", 15*time.Minute))
+	authUC := usecase.NewAuthUseCase(tokens, userRepo, refreshTokenRepo, getDuration("REFRESH_TTL", 30*24*time.Hour))
+	apiKeyUC := usecase.NewAPIKeyUseCase(apiKeyRepo, userRepo)
+	var oidcUC *usecase.OIDCUseCase
+	if issuer := os.Getenv("OIDC_ISSUER"); issuer != "" {
+		verifier := pkg.NewOIDCVerifier(getRequired("OIDC_JWKS_URL"), issuer, getRequired("OIDC_AUDIENCE"), getDuration("OIDC_JWKS_CACHE_TTL", time.Hour), nil)
+		oidcUC = usecase.NewOIDCUseCase(verifier, identityRepo, userRepo)
+		logger.Info("OIDC tokens accepted", "issuer", issuer)
+	}
+	handlers := controller.NewHTTPHandler(answerUC, questionUC, searchUC, trashUC, voteUC, tagUC, commentUC, userUC, authUC, apiKeyUC, oidcUC, moderationUC, flagUC, logger)
+	rateLimiter := controller.NewRateLimiter(controller.NewMemoryRateLimitStore(), getRateLimit("RATE_LIMIT_READ", "300/1m"), getRateLimit("RATE_LIMIT_WRITE", "30/1m"),
+		getRateLimit("RATE_LIMIT_FAILED_AUTH", "20/1m"), getTrustedProxies())
+	idempotencyUC := usecase.NewIdempotencyUseCase(idempotencyRepo, getDuration("IDEMPOTENCY_TTL", 24*time.Hour))
+	server := &controller.HTTPServer{Handlers: *handlers, RateLimiter: rateLimiter, Idempotency: idempotencyUC}
+
+	go runTrashPurge(trashUC, getDuration("TRASH_PURGE_INTERVAL", time.Hour), logger)
+	go runIdempotencyPurge(idempotencyUC, time.Hour, logger)
 
 	logger.Info("starting server on :8080")
 	if err := server.Run(); err != nil {
@@ -49,6 +94,108 @@ func getDSN() string {
 		" dbname=" + dbname + " port=" + port + " sslmode=disable"
 }
 
+// runTrashPurge permanently removes items that stayed in the trash longer than the retention window.
+func runTrashPurge(trashUC *usecase.TrashUseCase, interval time.Duration, logger pkg.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		result, err := trashUC.Purge()
+		if err != nil {
+			logger.Error("failed to purge trash", "error", err)
+			continue
+		}
+		logger.Info("trash purged", "questions", result.Questions, "answers", result.Answers)
+	}
+}
+
+// runIdempotencyPurge drops expired idempotency keys, a repeat after that runs the request again.
+func runIdempotencyPurge(idempotencyUC *usecase.IdempotencyUseCase, interval time.Duration, logger pkg.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		count, err := idempotencyUC.Purge()
+		if err != nil {
+			logger.Error("failed to purge idempotency keys", "error", err)
+			continue
+		}
+		logger.Info("idempotency keys purged", "count", count)
+	}
+}
+
+// getContentPolicies builds the content policies from the configuration, a zero limit or an empty
+// list turns a policy off.
+func getContentPolicies(recent usecase.RecentContentRepositoriy) []usecase.ContentPolicy {
+	var policies []usecase.ContentPolicy
+	if words := os.Getenv("BANNED_WORDS"); words != "" {
+		policies = append(policies, usecase.NewBannedWordsPolicy(strings.Split(words, ",")))
+	}
+	if max := getInt("MAX_REPEATED_CHARACTERS", 10); max > 0 {
+		policies = append(policies, usecase.NewRepeatedCharactersPolicy(max))
+	}
+	if window := getDuration("DUPLICATE_WINDOW", 10*time.Minute); window > 0 {
+		policies = append(policies, usecase.NewDuplicatePolicy(recent, window))
+	}
+	if max := getInt("MAX_LINKS", 3); max > 0 {
+		policies = append(policies, usecase.NewLinkLimitPolicy(max))
+	}
+	return policies
+}
+
+func getDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(getEnv(key, defaultValue.String()))
+	if err != nil {
+		log.Fatalf("invalid %s: %v", key, err)
+	}
+	return value
+}
+
+func getRateLimit(key, defaultValue string) controller.RateLimit {
+	limit, err := controller.ParseRateLimit(getEnv(key, defaultValue))
+	if err != nil {
+		log.Fatalf("invalid %s: %v", key, err)
+	}
+	return limit
+}
+
+func getTrustedProxies() []netip.Prefix {
+	proxies, err := controller.ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
+	return proxies
+}
+
+func getLengthLimit(key string, defaultValue usecase.LengthLimit) usecase.LengthLimit {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	limit, err := usecase.ParseLengthLimit(value)
+	if err != nil {
+		log.Fatalf("invalid %s: %v", key, err)
+	}
+	return limit
+}
+
+func getInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(getEnv(key, strconv.Itoa(defaultValue)))
+	if err != nil {
+		log.Fatalf("invalid %s: %v", key, err)
+	}
+	return value
+}
+
+func getRequired(key string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		log.Fatalf("%s is required", key)
+	}
+	return value
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
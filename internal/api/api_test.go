@@ -2,63 +2,142 @@ package api
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
 	"testing"
 	"testovoe/internal/controller"
 	"testovoe/internal/entity"
 	"testovoe/internal/pkg"
 	"testovoe/internal/repositoriy"
 	"testovoe/internal/usecase"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
+var testTokens = pkg.NewHMACTokens([]byte("test-secret"), time.Hour)
+
 func setupTestServer(t *testing.T) (*httptest.Server, *gorm.DB) {
-	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
-	db.AutoMigrate(&repositoriy.Question{}, &repositoriy.Answer{})
+	return setupTestServerWithOIDC(t, nil)
+}
+
+// setupTestServerWithOIDC also accepts SSO tokens checked by the verifier when it is not nil.
+func setupTestServerWithOIDC(t *testing.T, verifier usecase.IdentityVerifier) (*httptest.Server, *gorm.DB) {
+	server, db := newTestHTTPServer(t, verifier)
+	return httptest.NewServer(server.Handler()), db
+}
+
+// newTestHTTPServer wires the application without starting it, so tests can adjust the server first.
+func newTestHTTPServer(t *testing.T, verifier usecase.IdentityVerifier) (*controller.HTTPServer, *gorm.DB) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	db.AutoMigrate(&repositoriy.Question{}, &repositoriy.Answer{}, &repositoriy.QuestionRevision{}, &repositoriy.AnswerRevision{}, &repositoriy.Vote{}, &repositoriy.Tag{}, &repositoriy.QuestionTag{}, &repositoriy.Comment{}, &repositoriy.User{}, &repositoriy.RefreshToken{}, &repositoriy.APIKey{}, &repositoriy.UserIdentity{}, &repositoriy.IdempotencyKey{}, &repositoriy.HeldContent{}, &repositoriy.Flag{}, &repositoriy.ModerationDecision{})
+	if err := repositoriy.SetupSQLiteSearch(db); err != nil {
+		t.Fatal(err)
+	}
 
 	logger := pkg.NewZapLogger()
 	questionRepo := repositoriy.NewGormQuestionRepository(db, logger)
 	answerRepo := repositoriy.NewGormAnswerRepository(db, logger)
-	questionUC := usecase.NewQuestionUseCase(questionRepo)
-	answerUC := usecase.NewAnswerUseCase(answerRepo, questionRepo)
-	handlers := controller.NewHTTPHandler(answerUC, questionUC, logger)
-	server := &controller.HTTPServer{Handlers: *handlers}
-
-	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		router := http.NewServeMux()
-		router.HandleFunc("GET /question", server.Handlers.QuestionGetAll)
-		router.HandleFunc("GET /question/{id}", server.Handlers.QuestionGetById)
-		router.HandleFunc("POST /question", server.Handlers.QuestionCreate)
-		router.HandleFunc("DELETE /question/{id}", server.Handlers.QuestionDelete)
-		router.HandleFunc("GET /answer/{id}", server.Handlers.AnswerGetById)
-		router.HandleFunc("POST /question/{id}/answer", server.Handlers.AnswerCreate)
-		router.HandleFunc("DELETE /answer/{id}", server.Handlers.AnswerDelete)
-		router.ServeHTTP(w, r)
-	}))
+	searchRepo := repositoriy.NewGormSearchRepository(db, logger)
+	voteRepo := repositoriy.NewGormVoteRepository(db, logger)
+	tagRepo := repositoriy.NewGormTagRepository(db, logger)
+	commentRepo := repositoriy.NewGormCommentRepository(db, logger)
+	userRepo := repositoriy.NewGormUserRepository(db, logger)
+	refreshTokenRepo := repositoriy.NewGormRefreshTokenRepository(db, logger)
+	apiKeyRepo := repositoriy.NewGormAPIKeyRepository(db, logger)
+	heldRepo := repositoriy.NewGormHeldContentRepository(db, logger)
+	moderation := usecase.NewContentModeration(heldRepo, usecase.NewBannedWordsPolicy([]string{"scam"}), usecase.NewLinkLimitPolicy(2))
+	questionUC := usecase.NewQuestionUseCase(questionRepo, answerRepo, commentRepo, userRepo, usecase.DefaultTextLimits(), moderation)
+	answerUC := usecase.NewAnswerUseCase(answerRepo, questionRepo, userRepo, usecase.DefaultTextLimits(), moderation)
+	moderationUC := usecase.NewModerationUseCase(heldRepo, questionUC, answerUC)
+	flagUC := usecase.NewFlagUseCase(repositoriy.NewGormFlagRepository(db, logger), questionRepo, answerRepo, 2)
+	searchUC := usecase.NewSearchUseCase(searchRepo)
+	trashUC := usecase.NewTrashUseCase(questionRepo, answerRepo, time.Hour)
+	voteUC := usecase.NewVoteUseCase(voteRepo, questionRepo, answerRepo)
+	tagUC := usecase.NewTagUseCase(tagRepo)
+	commentUC := usecase.NewCommentUseCase(commentRepo, questionRepo, answerRepo, usecase.DefaultTextLimits())
+	userUC := usecase.NewUserUseCase(userRepo)
+	authUC := usecase.NewAuthUseCase(testTokens, userRepo, refreshTokenRepo, 24*time.Hour)
+	apiKeyUC := usecase.NewAPIKeyUseCase(apiKeyRepo, userRepo)
+	var oidcUC *usecase.OIDCUseCase
+	if verifier != nil {
+		oidcUC = usecase.NewOIDCUseCase(verifier, repositoriy.NewGormIdentityRepository(db, logger), userRepo)
+	}
+	handlers := controller.NewHTTPHandler(answerUC, questionUC, searchUC, trashUC, voteUC, tagUC, commentUC, userUC, authUC, apiKeyUC, oidcUC, moderationUC, flagUC, logger)
+	idempotencyUC := usecase.NewIdempotencyUseCase(repositoriy.NewGormIdempotencyRepository(db, logger), time.Hour)
+	server := &controller.HTTPServer{Handlers: *handlers, Idempotency: idempotencyUC}
+
+	return server, db
+}
+
+// createUser registers a user named name with the email name@example.com
+// and returns a client whose requests carry a bearer token of that user.
+func createUser(t *testing.T, server *httptest.Server, name string) (uuid.UUID, *http.Client) {
+	body, _ := json.Marshal(entity.UserDto{DisplayName: name, Email: name + "@example.com"})
+	resp, err := http.Post(server.URL+"/users", "application/json", bytes.NewReader(body))
+	if err != nil || resp.StatusCode != http.StatusCreated {
+		t.Fatalf("failed to create user %s: %v", name, err)
+	}
+
+	var user entity.User
+	json.NewDecoder(resp.Body).Decode(&user)
+
+	token, err := testTokens.Issue(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return user.ID, &http.Client{Transport: bearerTransport{token: token}}
+}
+
+// bearerTransport signs every request as one user.
+type bearerTransport struct {
+	token string
+}
+
+func (b bearerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("Authorization", "Bearer "+b.token)
+	return http.DefaultTransport.RoundTrip(r)
+}
 
-	return testServer, db
+// setRole changes the role directly in the database, the way the first admin is appointed.
+func setRole(t *testing.T, db *gorm.DB, userID uuid.UUID, role string) {
+	t.Helper()
+	if err := db.Model(&repositoriy.User{}).Where("id = ?", userID).Update("role", role).Error; err != nil {
+		t.Fatal(err)
+	}
 }
 
 func TestQuestionAPI(t *testing.T) {
 	server, _ := setupTestServer(t)
 	defer server.Close()
 
-	userID := uuid.New()
+	_, client := createUser(t, server, "alice")
 
 	t.Run("create question", func(t *testing.T) {
 		dto := entity.QuestionDto{
-			UserID: userID,
-			Text:   "Test question",
+			Text: "Test question",
 		}
 		body, _ := json.Marshal(dto)
 
-		resp, err := http.Post(server.URL+"/question", "application/json", bytes.NewReader(body))
+		resp, err := client.Post(server.URL+"/question", "application/json", bytes.NewReader(body))
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusCreated, resp.StatusCode)
 
@@ -72,23 +151,45 @@ func TestQuestionAPI(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-		var questions []entity.Question
+		var questions entity.Page[entity.Question]
 		json.NewDecoder(resp.Body).Decode(&questions)
-		assert.Greater(t, len(questions), 0)
+		assert.Greater(t, len(questions.Items), 0)
 	})
 
 	t.Run("create answer", func(t *testing.T) {
 		answerDTO := entity.AnswerDto{
-			UserID: userID,
-			Text:   "Test answer",
+			Text: "Test answer",
 		}
 		body, _ := json.Marshal(answerDTO)
 
-		resp, err := http.Post(server.URL+"/question/1/answer", "application/json", bytes.NewReader(body))
+		resp, err := client.Post(server.URL+"/question/1/answer", "application/json", bytes.NewReader(body))
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusCreated, resp.StatusCode)
 	})
 
+	t.Run("get question with answers", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/question/1")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var question entity.QuestionWithAnswers
+		json.NewDecoder(resp.Body).Decode(&question)
+		assert.Equal(t, 1, question.ID)
+		assert.Len(t, question.Answers, 1)
+		assert.Equal(t, "Test answer", question.Answers[0].Text)
+	})
+
+	t.Run("list answers of question", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/question/1/answers")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var answers entity.Page[entity.Answer]
+		json.NewDecoder(resp.Body).Decode(&answers)
+		assert.Len(t, answers.Items, 1)
+		assert.Empty(t, answers.NextCursor)
+	})
+
 	t.Run("get answer", func(t *testing.T) {
 		resp, err := http.Get(server.URL + "/answer/1")
 		assert.NoError(t, err)
@@ -97,46 +198,1791 @@ func TestQuestionAPI(t *testing.T) {
 
 	t.Run("delete answer", func(t *testing.T) {
 		req, _ := http.NewRequest("DELETE", server.URL+"/answer/1", nil)
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := client.Do(req)
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
 	})
 
 	t.Run("delete question", func(t *testing.T) {
 		req, _ := http.NewRequest("DELETE", server.URL+"/question/1", nil)
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := client.Do(req)
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
 	})
 }
 
-func TestQuestionAPI_Errors(t *testing.T) {
+func TestQuestionAPI_Pagination(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	_, client := createUser(t, server, "alice")
+	for i := 0; i < 3; i++ {
+		body, _ := json.Marshal(entity.QuestionDto{Text: "Paged question"})
+		resp, err := client.Post(server.URL+"/question", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	resp, err := http.Get(server.URL + "/question?limit=2")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var first entity.Page[entity.Question]
+	json.NewDecoder(resp.Body).Decode(&first)
+	assert.Len(t, first.Items, 2)
+	assert.NotEmpty(t, first.NextCursor)
+	assert.Equal(t, "</question?cursor="+first.NextCursor+`&limit=2>; rel="next"`, resp.Header.Get("Link"))
+
+	resp, err = http.Get(server.URL + "/question?limit=2&cursor=" + first.NextCursor)
+	assert.NoError(t, err)
+
+	var second entity.Page[entity.Question]
+	json.NewDecoder(resp.Body).Decode(&second)
+	assert.Len(t, second.Items, 1)
+	assert.Empty(t, second.NextCursor)
+	assert.Empty(t, resp.Header.Get("Link"))
+	assert.NotEqual(t, first.Items[1].ID, second.Items[0].ID)
+
+	resp, err = http.Get(server.URL + "/question?cursor=garbage")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/question?limit=2&sort=most_answered&cursor=" + first.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var problem controller.Problem
+	json.NewDecoder(resp.Body).Decode(&problem)
+	assert.Equal(t, []controller.FieldError{{Field: "cursor", Rule: "sort"}}, problem.Errors)
+}
+
+func TestQuestionAPI_Filters(t *testing.T) {
 	server, _ := setupTestServer(t)
 	defer server.Close()
 
-	userID := uuid.New()
+	alice, aliceClient := createUser(t, server, "alice")
+	_, bobClient := createUser(t, server, "bob")
+	for _, post := range []struct {
+		client *http.Client
+		text   string
+	}{
+		{aliceClient, "Alice question"},
+		{bobClient, "Bob question"},
+	} {
+		body, _ := json.Marshal(entity.QuestionDto{Text: post.text})
+		resp, err := post.client.Post(server.URL+"/question", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
 
-	t.Run("short question text", func(t *testing.T) {
-		dto := entity.QuestionDto{
-			UserID: userID,
-			Text:   "Hi",
-		}
+	body, _ := json.Marshal(entity.AnswerDto{Text: "Answer to Bob"})
+	resp, err := aliceClient.Post(server.URL+"/question/2/answer", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	list := func(query string) (int, []entity.Question) {
+		resp, err := http.Get(server.URL + "/question?" + query)
+		assert.NoError(t, err)
+
+		var page entity.Page[entity.Question]
+		json.NewDecoder(resp.Body).Decode(&page)
+		return resp.StatusCode, page.Items
+	}
+
+	status, questions := list("user_id=" + alice.String())
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, questions, 1)
+	assert.Equal(t, "Alice question", questions[0].Text)
+
+	status, questions = list("answered=false")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, questions, 1)
+	assert.Equal(t, "Alice question", questions[0].Text)
+
+	status, questions = list("sort=most_answered")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, questions, 2)
+	assert.Equal(t, 1, questions[0].AnswerCount)
+
+	status, _ = list("sort=random")
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+
+	status, _ = list("color=blue")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	status, _ = list("created_after=yesterday")
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestUsersAPI(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	userID, client := createUser(t, server, "alice")
+
+	body, _ := json.Marshal(entity.UserDto{DisplayName: "Other Alice", Email: "ALICE@example.com"})
+	resp, err := client.Post(server.URL+"/users", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	name := "Алиса"
+	body, _ = json.Marshal(entity.UserUpdateDto{DisplayName: &name})
+	req, _ := http.NewRequest(http.MethodPatch, server.URL+"/users/"+userID.String(), bytes.NewReader(body))
+	resp, err = client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = client.Get(server.URL + "/users/" + userID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var user entity.User
+	json.NewDecoder(resp.Body).Decode(&user)
+	assert.Equal(t, name, user.DisplayName)
+	assert.Equal(t, "alice@example.com", user.Email)
+	assert.Equal(t, entity.RoleUser, user.Role)
+
+	_, bob := createUser(t, server, "bob")
+	for _, reader := range []*http.Client{http.DefaultClient, bob} {
+		resp, err = reader.Get(server.URL + "/users/" + userID.String())
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		var profile map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&profile)
+		assert.Equal(t, map[string]interface{}{"id": userID.String(), "display_name": name}, profile)
+	}
+
+	body, _ = json.Marshal(entity.QuestionDto{Text: "Who am I?"})
+	resp, err = http.Post(server.URL+"/question", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	req, _ = http.NewRequest(http.MethodPost, server.URL+"/question", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer forged")
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	expired, _ := pkg.NewHMACTokens([]byte("test-secret"), -time.Minute).Issue(userID)
+	req, _ = http.NewRequest(http.MethodPost, server.URL+"/question", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+expired)
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	stranger, _ := testTokens.Issue(uuid.New())
+	req, _ = http.NewRequest(http.MethodPost, server.URL+"/question", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+stranger)
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	body, _ = json.Marshal(entity.QuestionDto{Text: "Where is the wiki?"})
+	resp, err = client.Post(server.URL+"/question", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	body, _ = json.Marshal(entity.AnswerDto{Text: "On the intranet"})
+	resp, err = client.Post(server.URL+"/question/1/answer", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, _ = http.Get(server.URL + "/question/1")
+	var question entity.QuestionWithAnswers
+	json.NewDecoder(resp.Body).Decode(&question)
+	assert.Equal(t, &entity.UserSummary{ID: userID, DisplayName: name}, question.Author)
+	assert.Equal(t, name, question.Answers[0].Author.DisplayName)
+}
+
+func TestAuthAPI(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	post := func(path string, dto interface{}) *http.Response {
 		body, _ := json.Marshal(dto)
+		resp, err := http.Post(server.URL+path, "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		return resp
+	}
+	decode := func(resp *http.Response) entity.TokenPair {
+		var tokens entity.TokenPair
+		json.NewDecoder(resp.Body).Decode(&tokens)
+		return tokens
+	}
+
+	resp := post("/auth/register", entity.RegisterDto{DisplayName: "Alice", Email: "alice@example.com", Password: "correct horse"})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+	registered := decode(resp)
+	assert.NotEmpty(t, registered.AccessToken)
+	assert.NotEmpty(t, registered.RefreshToken)
+	assert.Equal(t, "Bearer", registered.TokenType)
+	assert.Equal(t, int(time.Hour.Seconds()), registered.ExpiresIn)
+	assert.Equal(t, "alice@example.com", registered.User.Email)
+
+	resp = post("/auth/register", entity.RegisterDto{DisplayName: "Alice", Email: "alice@example.com", Password: "another one"})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	resp = post("/auth/register", entity.RegisterDto{DisplayName: "Bob", Email: "bob@example.com", Password: "short"})
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	resp = post("/auth/login", entity.LoginDto{Email: "alice@example.com", Password: "wrong password"})
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp = post("/auth/login", entity.LoginDto{Email: "nobody@example.com", Password: "correct horse"})
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = post("/auth/login", entity.LoginDto{Email: "Alice@Example.com", Password: "correct horse"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	session := decode(resp)
+
+	client := &http.Client{Transport: bearerTransport{token: session.AccessToken}}
+	body, _ := json.Marshal(entity.QuestionDto{Text: "Posted after login"})
+	resp, err := client.Post(server.URL+"/question", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	t.Run("refresh rotates the token", func(t *testing.T) {
+		resp := post("/auth/refresh", entity.RefreshDto{RefreshToken: session.RefreshToken})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		rotated := decode(resp)
+		assert.NotEqual(t, session.RefreshToken, rotated.RefreshToken)
+
+		resp = post("/auth/refresh", entity.RefreshDto{RefreshToken: session.RefreshToken})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		// reusing an old token ends every session of the user
+		resp = post("/auth/refresh", entity.RefreshDto{RefreshToken: rotated.RefreshToken})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		resp = post("/auth/refresh", entity.RefreshDto{RefreshToken: registered.RefreshToken})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("logout", func(t *testing.T) {
+		session := decode(post("/auth/login", entity.LoginDto{Email: "alice@example.com", Password: "correct horse"}))
+
+		resp := post("/auth/logout", entity.RefreshDto{RefreshToken: session.RefreshToken})
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
 
-		resp, err := http.Post(server.URL+"/question", "application/json", bytes.NewReader(body))
+		resp = post("/auth/refresh", entity.RefreshDto{RefreshToken: session.RefreshToken})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp = post("/auth/logout", entity.RefreshDto{RefreshToken: "made up"})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("expired access token does not block login", func(t *testing.T) {
+		expired, _ := pkg.NewHMACTokens([]byte("test-secret"), -time.Minute).Issue(registered.User.ID)
+		body, _ := json.Marshal(entity.LoginDto{Email: "alice@example.com", Password: "correct horse"})
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/auth/login", bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+expired)
+
+		resp, err := http.DefaultClient.Do(req)
 		assert.NoError(t, err)
-		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
 	})
 
-	t.Run("answer to non-existent question", func(t *testing.T) {
-		answerDTO := entity.AnswerDto{
-			UserID: userID,
-			Text:   "Test answer",
+	t.Run("users without a password can not log in", func(t *testing.T) {
+		createUser(t, server, "carol")
+
+		resp := post("/auth/login", entity.LoginDto{Email: "carol@example.com", Password: ""})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
+
+func TestAPIKeysAPI(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	aliceID, alice := createUser(t, server, "alice")
+	_, bob := createUser(t, server, "bob")
+
+	createKey := func(scopes ...string) entity.CreatedAPIKey {
+		body, _ := json.Marshal(entity.APIKeyDto{Name: "bot", Scopes: scopes})
+		resp, err := alice.Post(server.URL+"/api-keys", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var key entity.CreatedAPIKey
+		json.NewDecoder(resp.Body).Decode(&key)
+		return key
+	}
+	withKey := func(method, path, key string, dto interface{}) *http.Response {
+		body, _ := json.Marshal(dto)
+		req, _ := http.NewRequest(method, server.URL+path, bytes.NewReader(body))
+		req.Header.Set("X-API-Key", key)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	writer := createKey(entity.ScopeQuestionsRead, entity.ScopeQuestionsWrite)
+	assert.NotEmpty(t, writer.Key)
+	assert.Contains(t, writer.Key, writer.Prefix)
+
+	resp := withKey(http.MethodPost, "/question", writer.Key, entity.QuestionDto{Text: "Posted by a bot"})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var question entity.Question
+	json.NewDecoder(resp.Body).Decode(&question)
+	assert.Equal(t, aliceID, question.UserID)
+
+	resp = withKey(http.MethodPost, "/question/1/answer", writer.Key, entity.AnswerDto{Text: "Answered by a bot"})
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = withKey(http.MethodGet, "/api-keys", writer.Key, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = withKey(http.MethodGet, "/question/1", writer.Key, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = withKey(http.MethodGet, "/question/1", "qak_"+writer.Prefix+"_forged", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	answerer := createKey(entity.ScopeAnswersWrite)
+	resp = withKey(http.MethodPost, "/question/1/answer", answerer.Key, entity.AnswerDto{Text: "Answered by a bot"})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp = withKey(http.MethodGet, "/question/1", answerer.Key, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, err := alice.Get(server.URL + "/api-keys")
+	assert.NoError(t, err)
+
+	var keys []entity.APIKey
+	json.NewDecoder(resp.Body).Decode(&keys)
+	assert.Len(t, keys, 2)
+	assert.NotNil(t, keys[1].LastUsedAt)
+
+	req, _ := http.NewRequest(http.MethodDelete, server.URL+"/api-keys/"+strconv.Itoa(writer.ID), nil)
+	resp, err = bob.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, err = alice.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = withKey(http.MethodGet, "/question/1", writer.Key, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestRolesAPI(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer server.Close()
+
+	aliceID, alice := createUser(t, server, "alice")
+	bobID, bob := createUser(t, server, "bob")
+	_, carol := createUser(t, server, "carol")
+	adminID, admin := createUser(t, server, "admin")
+	setRole(t, db, adminID, entity.RoleAdmin)
+
+	body, _ := json.Marshal(entity.QuestionDto{Text: "Alice's question"})
+	resp, err := alice.Post(server.URL+"/question", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	do := func(client *http.Client, method, path string, body string) *http.Response {
+		req, _ := http.NewRequest(method, server.URL+path, bytes.NewReader([]byte(body)))
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	resp = do(carol, http.MethodPatch, "/question/1", `{"text": "Carol was here"}`)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = do(carol, http.MethodDelete, "/question/1", "")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = do(carol, http.MethodPatch, "/users/"+aliceID.String(), `{"display_name": "Mallory"}`)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = do(bob, http.MethodPost, "/users/"+bobID.String()+"/roles/moderator", "")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = do(admin, http.MethodPost, "/users/"+bobID.String()+"/roles/moderator", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var user entity.User
+	json.NewDecoder(resp.Body).Decode(&user)
+	assert.Equal(t, entity.RoleModerator, user.Role)
+
+	resp = do(admin, http.MethodPost, "/users/"+bobID.String()+"/roles/owner", "")
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	resp = do(bob, http.MethodPatch, "/question/1", `{"text": "Edited by a moderator"}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = do(bob, http.MethodDelete, "/question/1", "")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp = do(alice, http.MethodPost, "/question/1/restore", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = do(admin, http.MethodDelete, "/users/"+bobID.String()+"/roles/moderator", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = do(bob, http.MethodDelete, "/question/1", "")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = do(admin, http.MethodDelete, "/users/"+adminID.String()+"/roles/admin", "")
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestTagsAPI(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	_, client := createUser(t, server, "alice")
+	for _, dto := range []entity.QuestionDto{
+		{Text: "Goroutine leak", Tags: []string{"Go", "concurrency"}},
+		{Text: "Slow join query", Tags: []string{"sql"}},
+		{Text: "Channel deadlock", Tags: []string{"go"}},
+	} {
+		body, _ := json.Marshal(dto)
+		resp, err := client.Post(server.URL+"/question", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	body, _ := json.Marshal(entity.QuestionDto{Text: "Bad tags", Tags: []string{"a/b"}})
+	resp, err := client.Post(server.URL+"/question", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/tags")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var tags entity.Page[entity.Tag]
+	json.NewDecoder(resp.Body).Decode(&tags)
+	assert.Len(t, tags.Items, 3)
+	assert.Equal(t, "go", tags.Items[0].Name)
+	assert.Equal(t, 2, tags.Items[0].QuestionCount)
+
+	resp, err = http.Get(server.URL + "/tags/Go/questions?sort=newest")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var questions entity.Page[entity.Question]
+	json.NewDecoder(resp.Body).Decode(&questions)
+	assert.Len(t, questions.Items, 2)
+	assert.Equal(t, "Channel deadlock", questions.Items[0].Text)
+	assert.Equal(t, []string{"go"}, questions.Items[0].Tags)
+
+	resp, err = http.Get(server.URL + "/question?tags=go,concurrency")
+	assert.NoError(t, err)
+	questions = entity.Page[entity.Question]{}
+	json.NewDecoder(resp.Body).Decode(&questions)
+	assert.Len(t, questions.Items, 1)
+	assert.Equal(t, []string{"concurrency", "go"}, questions.Items[0].Tags)
+}
+
+func TestCommentsAPI(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	_, client := createUser(t, server, "alice")
+	body, _ := json.Marshal(entity.QuestionDto{Text: "VPN does not connect"})
+	resp, err := client.Post(server.URL+"/question", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	body, _ = json.Marshal(entity.AnswerDto{Text: "Reinstall the client"})
+	resp, err = client.Post(server.URL+"/question/1/answer", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	comment := func(path, text string) (int, entity.Comment) {
+		body, _ := json.Marshal(entity.CommentDto{Text: text})
+		resp, err := client.Post(server.URL+path, "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+
+		var created entity.Comment
+		json.NewDecoder(resp.Body).Decode(&created)
+		return resp.StatusCode, created
+	}
+
+	status, questionComment := comment("/question/1/comments", "Which office?")
+	assert.Equal(t, http.StatusCreated, status)
+	assert.Equal(t, entity.CommentTargetQuestion, questionComment.TargetType)
+
+	status, _ = comment("/answer/1/comments", "Which version?")
+	assert.Equal(t, http.StatusCreated, status)
+
+	status, _ = comment("/answer/99/comments", "Which version?")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	resp, err = http.Get(server.URL + "/question/1")
+	assert.NoError(t, err)
+	var page entity.QuestionWithAnswers
+	json.NewDecoder(resp.Body).Decode(&page)
+	assert.Len(t, page.Comments, 1)
+	assert.Len(t, page.Answers, 1)
+	assert.Len(t, page.Answers[0].Comments, 1)
+	assert.Equal(t, "Which version?", page.Answers[0].Comments[0].Text)
+
+	resp, err = http.Get(server.URL + "/question/1/answers")
+	assert.NoError(t, err)
+	var answers entity.Page[entity.Answer]
+	json.NewDecoder(resp.Body).Decode(&answers)
+	assert.Len(t, answers.Items, 1)
+
+	req, _ := http.NewRequest(http.MethodDelete, server.URL+"/comment/1", nil)
+	resp, err = client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/question/1/comments")
+	assert.NoError(t, err)
+	var comments entity.Page[entity.Comment]
+	json.NewDecoder(resp.Body).Decode(&comments)
+	assert.Empty(t, comments.Items)
+}
+
+func TestSearchAPI(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer server.Close()
+
+	_, client := createUser(t, server, "alice")
+	post := func(path string, v interface{}) {
+		body, _ := json.Marshal(v)
+		resp, err := client.Post(server.URL+path, "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	post("/question", entity.QuestionDto{Text: "How to configure goose migrations?"})
+	post("/question", entity.QuestionDto{Text: "Where are the logs stored?"})
+	post("/question/2/answer", entity.AnswerDto{Text: "Goose migrations live in the migrations dir, goose reads them"})
+	post("/question", entity.QuestionDto{Text: `Why does <img src=x onerror="alert(1)"> render?`})
+
+	search := func(query string) (int, []entity.SearchResult) {
+		resp, err := http.Get(server.URL + "/search?" + query)
+		assert.NoError(t, err)
+
+		var page entity.Page[entity.SearchResult]
+		json.NewDecoder(resp.Body).Decode(&page)
+		return resp.StatusCode, page.Items
+	}
+
+	status, results := search("q=goose+migrations")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, results, 2)
+	assert.Equal(t, entity.SearchTypeAnswer, results[0].Type)
+	assert.Equal(t, 2, results[0].QuestionID)
+	assert.Contains(t, results[0].Snippet, "<mark>")
+	assert.Equal(t, entity.SearchTypeQuestion, results[1].Type)
+	assert.Equal(t, 1, results[1].ID)
+
+	status, results = search("q=logs")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "Where are the <mark>logs</mark> stored?", results[0].Snippet)
+
+	status, results = search("q=render")
+	assert.Equal(t, http.StatusOK, status)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, "Why does &lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>render</mark>?", results[0].Snippet)
+	}
+
+	status, results = search("q=" + url.QueryEscape(`"nothing OR`))
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, results)
+
+	status, _ = search("q=")
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+
+	t.Run("answers of a hidden question", func(t *testing.T) {
+		db.Model(&repositoriy.Question{}).Where("id = ?", 2).Update("hidden_at", time.Now())
+
+		_, results := search("q=goose+migrations")
+		if assert.Len(t, results, 1) {
+			assert.Equal(t, entity.SearchTypeQuestion, results[0].Type)
+			assert.Equal(t, 1, results[0].ID)
 		}
-		body, _ := json.Marshal(answerDTO)
+	})
+}
+
+func TestRevisionsAPI(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	_, client := createUser(t, server, "alice")
+	body, _ := json.Marshal(entity.QuestionDto{Text: "How to run tests?"})
+	resp, err := client.Post(server.URL+"/question", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
 
-		resp, err := http.Post(server.URL+"/question/999/answer", "application/json", bytes.NewReader(body))
+	patch := func(path, text string) *http.Response {
+		body, _ := json.Marshal(map[string]string{"text": text})
+		req, _ := http.NewRequest(http.MethodPatch, server.URL+path, bytes.NewReader(body))
+		resp, err := client.Do(req)
 		assert.NoError(t, err)
-		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		return resp
+	}
+
+	resp = patch("/question/1", "How to run fast tests?")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var question entity.Question
+	json.NewDecoder(resp.Body).Decode(&question)
+	assert.Equal(t, "How to run fast tests?", question.Text)
+	assert.NotNil(t, question.UpdatedAt)
+
+	resp = patch("/question/1", "Hi")
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/question/1/revisions")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var revisions []entity.Revision
+	json.NewDecoder(resp.Body).Decode(&revisions)
+	assert.Len(t, revisions, 2)
+	assert.Equal(t, "How to run tests?", revisions[0].Text)
+	assert.Equal(t, "How to run fast tests?", revisions[1].Text)
+
+	resp, err = http.Get(server.URL + "/question/1/revisions/diff?from=1&to=2")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var diff entity.RevisionDiff
+	json.NewDecoder(resp.Body).Decode(&diff)
+	assert.Contains(t, diff.Changes, entity.DiffChange{Op: entity.DiffInsert, Text: "fast "})
+
+	resp, err = http.Get(server.URL + "/question/1/revisions/diff?from=1&to=5")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	body, _ = json.Marshal(entity.AnswerDto{Text: "Use go test"})
+	resp, err = client.Post(server.URL+"/question/1/answer", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp = patch("/answer/1", "Use go test -short")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/answer/1/revisions")
+	assert.NoError(t, err)
+	json.NewDecoder(resp.Body).Decode(&revisions)
+	assert.Len(t, revisions, 2)
+
+	resp, err = http.Get(server.URL + "/search?q=short")
+	assert.NoError(t, err)
+
+	var results entity.Page[entity.SearchResult]
+	json.NewDecoder(resp.Body).Decode(&results)
+	assert.Len(t, results.Items, 1)
+}
+
+func TestTrashAPI(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer server.Close()
+
+	_, client := createUser(t, server, "alice")
+	moderatorID, moderator := createUser(t, server, "moderator")
+	setRole(t, db, moderatorID, entity.RoleModerator)
+	body, _ := json.Marshal(entity.QuestionDto{Text: "Deleted by accident"})
+	resp, err := client.Post(server.URL+"/question", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	body, _ = json.Marshal(entity.AnswerDto{Text: "Precious answer"})
+	resp, err = client.Post(server.URL+"/question/1/answer", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	req, _ := http.NewRequest(http.MethodDelete, server.URL+"/question/1", nil)
+	resp, err = client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, _ = http.Get(server.URL + "/question/1")
+	assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+	resp, _ = http.Get(server.URL + "/answer/1")
+	assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+
+	var search entity.Page[entity.SearchResult]
+	resp, _ = http.Get(server.URL + "/search?q=precious")
+	json.NewDecoder(resp.Body).Decode(&search)
+	assert.Empty(t, search.Items)
+
+	resp, err = http.Get(server.URL + "/trash")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, err = client.Get(server.URL + "/trash")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, err = moderator.Get(server.URL + "/trash")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var trash entity.Page[entity.Question]
+	json.NewDecoder(resp.Body).Decode(&trash)
+	assert.Len(t, trash.Items, 1)
+	assert.NotNil(t, trash.Items[0].DeletedAt)
+
+	var trashAnswers entity.Page[entity.Answer]
+	resp, _ = moderator.Get(server.URL + "/trash?type=answer")
+	json.NewDecoder(resp.Body).Decode(&trashAnswers)
+	assert.Len(t, trashAnswers.Items, 1)
+
+	resp, _ = moderator.Get(server.URL + "/trash?type=comment")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = client.Post(server.URL+"/question/1/restore", "application/json", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/question/1")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var question entity.QuestionWithAnswers
+	json.NewDecoder(resp.Body).Decode(&question)
+	assert.Len(t, question.Answers, 1)
+	assert.Nil(t, question.DeletedAt)
+
+	req, _ = http.NewRequest(http.MethodDelete, server.URL+"/answer/1", nil)
+	resp, _ = client.Do(req)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = client.Post(server.URL+"/answer/1/restore", "application/json", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, _ = client.Post(server.URL+"/answer/1/restore", "application/json", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestModerationAPI(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer server.Close()
+
+	_, client := createUser(t, server, "alice")
+	moderatorID, moderator := createUser(t, server, "moderator")
+	setRole(t, db, moderatorID, entity.RoleModerator)
+	post := func(path string, dto interface{}) *http.Response {
+		body, _ := json.Marshal(dto)
+		resp, err := client.Post(server.URL+path, "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		return resp
+	}
+
+	resp := post("/question", entity.QuestionDto{Text: "Is this a sc4m?"})
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	var problem controller.Problem
+	json.NewDecoder(resp.Body).Decode(&problem)
+	assert.Equal(t, []controller.FieldError{{Field: "text", Rule: "policy banned_words"}}, problem.Errors)
+
+	resp = post("/question", entity.QuestionDto{Text: "Compare https://a.io, https://b.io and https://c.io", Tags: []string{"go"}})
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	var held entity.HeldContent
+	json.NewDecoder(resp.Body).Decode(&held)
+	assert.Equal(t, "links", held.Policy)
+	assert.Nil(t, held.PostID)
+
+	resp, _ = http.Get(server.URL + "/question/1")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, _ = client.Get(server.URL + "/moderation/held")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, err := moderator.Get(server.URL + "/moderation/held")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var queue entity.Page[entity.HeldContent]
+	json.NewDecoder(resp.Body).Decode(&queue)
+	assert.Len(t, queue.Items, 1)
+
+	resp, err = moderator.Post(server.URL+"/moderation/held/"+strconv.Itoa(held.ID)+"/approve", "application/json", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	json.NewDecoder(resp.Body).Decode(&held)
+	assert.Equal(t, 1, *held.PostID)
+
+	resp, _ = http.Get(server.URL + "/question/1")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var question entity.QuestionWithAnswers
+	json.NewDecoder(resp.Body).Decode(&question)
+	assert.Equal(t, []string{"go"}, question.Tags)
+
+	resp, _ = moderator.Post(server.URL+"/moderation/held/"+strconv.Itoa(held.ID)+"/approve", "application/json", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	t.Run("edits are held too", func(t *testing.T) {
+		text := "See https://a.io, https://b.io and https://c.io"
+		body, _ := json.Marshal(entity.QuestionUpdateDto{Text: &text})
+		req, _ := http.NewRequest(http.MethodPatch, server.URL+"/question/1", bytes.NewReader(body))
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+		resp, _ = http.Get(server.URL + "/question/1")
+		var question entity.QuestionWithAnswers
+		json.NewDecoder(resp.Body).Decode(&question)
+		assert.Equal(t, "Compare https://a.io, https://b.io and https://c.io", question.Text)
+	})
+
+	t.Run("rejected content is dropped", func(t *testing.T) {
+		resp := post("/question/1/answer", entity.AnswerDto{Text: "Try https://a.io, https://b.io or https://c.io"})
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+		var held entity.HeldContent
+		json.NewDecoder(resp.Body).Decode(&held)
+		assert.Equal(t, 1, *held.QuestionID)
+
+		req, _ := http.NewRequest(http.MethodDelete, server.URL+"/moderation/held/"+strconv.Itoa(held.ID), nil)
+		resp, err := moderator.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		resp, _ = http.Get(server.URL + "/question/1/answers")
+		var answers entity.Page[entity.Answer]
+		json.NewDecoder(resp.Body).Decode(&answers)
+		assert.Empty(t, answers.Items)
+	})
+}
+
+func TestFlagsAPI(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer server.Close()
+
+	_, alice := createUser(t, server, "alice")
+	_, bob := createUser(t, server, "bob")
+	_, carol := createUser(t, server, "carol")
+	moderatorID, moderator := createUser(t, server, "moderator")
+	setRole(t, db, moderatorID, entity.RoleModerator)
+	post := func(client *http.Client, path string, dto interface{}) *http.Response {
+		body, _ := json.Marshal(dto)
+		resp, err := client.Post(server.URL+path, "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		return resp
+	}
+
+	assert.Equal(t, http.StatusCreated, post(alice, "/question", entity.QuestionDto{Text: "Flagged question"}).StatusCode)
+	assert.Equal(t, http.StatusCreated, post(bob, "/question/1/answer", entity.AnswerDto{Text: "Answer to a flagged question"}).StatusCode)
+
+	resp := post(bob, "/question/1/flag", entity.FlagDto{Reason: "boring"})
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	resp = post(alice, "/question/1/flag", entity.FlagDto{Reason: entity.FlagSpam})
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp = post(bob, "/question/1/flag", entity.FlagDto{Reason: entity.FlagSpam})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var flag entity.Flag
+	json.NewDecoder(resp.Body).Decode(&flag)
+	assert.Equal(t, entity.FlagPending, flag.Status)
+	assert.Equal(t, http.StatusConflict, post(bob, "/question/1/flag", entity.FlagDto{Reason: entity.FlagSpam}).StatusCode)
+
+	resp, _ = http.Get(server.URL + "/question/1")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, http.StatusCreated, post(carol, "/question/1/flag", entity.FlagDto{Reason: entity.FlagOffensive}).StatusCode)
+
+	for _, path := range []string{"/question/1", "/question/1/revisions", "/question/1/revisions/diff?from=1&to=1", "/answer/1", "/answer/1/revisions", "/question/1/answers"} {
+		resp, _ = http.Get(server.URL + path)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, path)
+	}
+	var list entity.Page[entity.Question]
+	resp, _ = http.Get(server.URL + "/question")
+	json.NewDecoder(resp.Body).Decode(&list)
+	assert.Empty(t, list.Items)
+
+	resp, _ = bob.Get(server.URL + "/moderation/flags")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp, _ = moderator.Get(server.URL + "/moderation/flags")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var flags entity.Page[entity.Flag]
+	json.NewDecoder(resp.Body).Decode(&flags)
+	assert.Len(t, flags.Items, 2)
+
+	resp = post(moderator, "/moderation/flags/"+strconv.Itoa(flag.ID)+"/decision", entity.DecisionDto{Action: entity.ActionDismiss, Note: "Not spam"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = http.Get(server.URL + "/question/1")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = http.Get(server.URL + "/answer/1/revisions")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = post(moderator, "/moderation/flags/"+strconv.Itoa(flag.ID)+"/decision", entity.DecisionDto{Action: entity.ActionLock})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	t.Run("lock", func(t *testing.T) {
+		assert.Equal(t, http.StatusCreated, post(alice, "/question", entity.QuestionDto{Text: "Heated question"}).StatusCode)
+		resp := post(bob, "/question/2/flag", entity.FlagDto{Reason: entity.FlagOffTopic})
+		var flag entity.Flag
+		json.NewDecoder(resp.Body).Decode(&flag)
+
+		resp = post(moderator, "/moderation/flags/"+strconv.Itoa(flag.ID)+"/decision", entity.DecisionDto{Action: entity.ActionLock})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		assert.Equal(t, http.StatusConflict, post(bob, "/question/2/answer", entity.AnswerDto{Text: "Late answer"}).StatusCode)
+		assert.Equal(t, http.StatusConflict, post(bob, "/question/2/comments", entity.CommentDto{Text: "Late comment"}).StatusCode)
+
+		resp, _ = http.Get(server.URL + "/question/2")
+		var question entity.QuestionWithAnswers
+		json.NewDecoder(resp.Body).Decode(&question)
+		assert.NotNil(t, question.LockedAt)
+	})
+
+	t.Run("decisions are recorded", func(t *testing.T) {
+		resp, err := moderator.Get(server.URL + "/moderation/decisions")
+		assert.NoError(t, err)
+		var decisions entity.Page[entity.ModerationDecision]
+		json.NewDecoder(resp.Body).Decode(&decisions)
+		if assert.Len(t, decisions.Items, 3) {
+			assert.Equal(t, entity.ActionLock, decisions.Items[0].Action)
+			assert.Equal(t, entity.ActionDismiss, decisions.Items[1].Action)
+			assert.Equal(t, moderatorID, *decisions.Items[1].ModeratorID)
+			assert.Equal(t, entity.ActionHide, decisions.Items[2].Action)
+			assert.Nil(t, decisions.Items[2].ModeratorID)
+		}
+	})
+}
+
+func TestVoteAPI(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	_, alice := createUser(t, server, "alice")
+	_, bob := createUser(t, server, "bob")
+	body, _ := json.Marshal(entity.QuestionDto{Text: "Which answer is best?"})
+	resp, err := alice.Post(server.URL+"/question", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	for _, text := range []string{"Mediocre answer", "Great answer"} {
+		body, _ = json.Marshal(entity.AnswerDto{Text: text})
+		resp, err = bob.Post(server.URL+"/question/1/answer", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	vote := func(client *http.Client, method, path string, dto entity.VoteDto) (int, entity.VoteResult) {
+		body, _ := json.Marshal(dto)
+		req, _ := http.NewRequest(method, server.URL+path, bytes.NewReader(body))
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+
+		var result entity.VoteResult
+		json.NewDecoder(resp.Body).Decode(&result)
+		return resp.StatusCode, result
+	}
+
+	status, result := vote(alice, http.MethodPost, "/answer/2/vote", entity.VoteDto{Value: entity.VoteUp})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, entity.VoteResult{Score: 1, Vote: 1}, result)
+
+	status, result = vote(bob, http.MethodPost, "/answer/2/vote", entity.VoteDto{Value: entity.VoteUp})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 2, result.Score)
+
+	req, _ := http.NewRequest(http.MethodDelete, server.URL+"/answer/2/vote", nil)
+	resp, err = bob.Do(req)
+	assert.NoError(t, err)
+	status = resp.StatusCode
+	result = entity.VoteResult{}
+	json.NewDecoder(resp.Body).Decode(&result)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, entity.VoteResult{Score: 1}, result)
+
+	status, _ = vote(alice, http.MethodPost, "/answer/1/vote", entity.VoteDto{Value: 2})
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+
+	status, result = vote(bob, http.MethodPost, "/question/1/vote", entity.VoteDto{Value: entity.VoteDown})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, -1, result.Score)
+
+	resp, err = http.Get(server.URL + "/question/1/answers?sort=score")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var answers entity.Page[entity.Answer]
+	json.NewDecoder(resp.Body).Decode(&answers)
+	assert.Len(t, answers.Items, 2)
+	assert.Equal(t, "Great answer", answers.Items[0].Text)
+	assert.Equal(t, 1, answers.Items[0].Score)
+
+	resp, _ = http.Get(server.URL + "/question/1")
+	var question entity.QuestionWithAnswers
+	json.NewDecoder(resp.Body).Decode(&question)
+	assert.Equal(t, -1, question.Score)
+}
+
+func TestAcceptAPI(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	_, author := createUser(t, server, "author")
+	_, helper := createUser(t, server, "helper")
+	body, _ := json.Marshal(entity.QuestionDto{Text: "Printer is offline"})
+	resp, err := author.Post(server.URL+"/question", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	body, _ = json.Marshal(entity.AnswerDto{Text: "Turn it off and on"})
+	resp, err = helper.Post(server.URL+"/question/1/answer", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	accept := func(client *http.Client, method, path string) (int, entity.Question) {
+		req, _ := http.NewRequest(method, server.URL+path, nil)
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+
+		var question entity.Question
+		json.NewDecoder(resp.Body).Decode(&question)
+		return resp.StatusCode, question
+	}
+
+	status, _ := accept(helper, http.MethodPost, "/question/1/accept/1")
+	assert.Equal(t, http.StatusForbidden, status)
+
+	status, question := accept(author, http.MethodPost, "/question/1/accept/1")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, 1, *question.AcceptedAnswerID)
+
+	resp, _ = http.Get(server.URL + "/question/1/answers")
+	var answers entity.Page[entity.Answer]
+	json.NewDecoder(resp.Body).Decode(&answers)
+	assert.True(t, answers.Items[0].IsAccepted)
+
+	resp, _ = http.Get(server.URL + "/question?accepted=true")
+	var questions entity.Page[entity.Question]
+	json.NewDecoder(resp.Body).Decode(&questions)
+	assert.Len(t, questions.Items, 1)
+
+	status, question = accept(author, http.MethodDelete, "/question/1/accept")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Nil(t, question.AcceptedAnswerID)
+
+	resp, _ = http.Get(server.URL + "/question?accepted=true")
+	questions = entity.Page[entity.Question]{}
+	json.NewDecoder(resp.Body).Decode(&questions)
+	assert.Empty(t, questions.Items)
+}
+
+func TestQuestionAPI_Errors(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer server.Close()
+
+	_, client := createUser(t, server, "alice")
+
+	t.Run("short question text", func(t *testing.T) {
+		dto := entity.QuestionDto{
+			Text: "Hi",
+		}
+		body, _ := json.Marshal(dto)
+
+		resp, err := client.Post(server.URL+"/question", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+
+	t.Run("answer to non-existent question", func(t *testing.T) {
+		answerDTO := entity.AnswerDto{
+			Text: "Test answer",
+		}
+		body, _ := json.Marshal(answerDTO)
+
+		resp, err := client.Post(server.URL+"/question/999/answer", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("problem lists invalid fields", func(t *testing.T) {
+		body, _ := json.Marshal(entity.QuestionDto{Text: "Hi", Tags: []string{"bad tag!"}})
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/question", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Request-ID", "form-42")
+
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+		assert.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
+		assert.Equal(t, "form-42", resp.Header.Get("X-Request-ID"))
+
+		var problem controller.Problem
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+		assert.Equal(t, "/problems/validation", problem.Type)
+		assert.Equal(t, http.StatusUnprocessableEntity, problem.Status)
+		assert.Equal(t, "/question", problem.Instance)
+		assert.Equal(t, "form-42", problem.RequestID)
+		assert.Equal(t, []controller.FieldError{
+			{Field: "text", Rule: "min_length 5"},
+			{Field: "tags", Rule: "pattern"},
+		}, problem.Errors)
+	})
+
+	t.Run("non-existent question", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/question/999")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("database failure is not shown", func(t *testing.T) {
+		sqlDB, err := db.DB()
+		assert.NoError(t, err)
+		assert.NoError(t, sqlDB.Close())
+
+		resp, err := http.Get(server.URL + "/question/1")
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.NotEmpty(t, resp.Header.Get("X-Request-ID"))
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Contains(t, string(body), "Internal server error")
+		assert.NotContains(t, string(body), "sql")
+	})
+}
+
+// jwksServer stands in for the identity provider, keys can be rotated while it runs.
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	keys    []map[string]string
+	fetches int
+	// stall holds the answers back until it is closed.
+	stall chan struct{}
+}
+
+func newJWKSServer() *jwksServer {
+	s := &jwksServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		stall := s.stall
+		s.mu.Unlock()
+		if stall != nil {
+			<-stall
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.fetches++
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": s.keys})
+	}))
+	return s
+}
+
+func (s *jwksServer) publish(keys ...map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA", "kid": kid, "use": "sig",
+		"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "EC", "kid": kid, "crv": "P-256",
+		"x": base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		"y": base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+func TestOIDCAPI(t *testing.T) {
+	const issuer, audience = "https://sso.example.com", "qa-service"
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	rotatedKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	jwks := newJWKSServer()
+	defer jwks.Close()
+	jwks.publish(rsaJWK("rsa-1", &rsaKey.PublicKey), ecJWK("ec-1", &ecKey.PublicKey))
+
+	verifier := pkg.NewOIDCVerifier(jwks.URL, issuer, audience, 300*time.Millisecond, nil)
+	server, _ := setupTestServerWithOIDC(t, verifier)
+	defer server.Close()
+
+	sign := func(method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	claimsOf := func(sub, email string, verified bool) jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss": issuer, "aud": audience, "sub": sub,
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"email": email, "email_verified": verified, "name": "Alice from SSO",
+		}
+	}
+	postQuestion := func(token string) (*http.Response, entity.Question) {
+		body, _ := json.Marshal(entity.QuestionDto{Text: "Asked through SSO"})
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/question", bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+
+		var question entity.Question
+		json.NewDecoder(resp.Body).Decode(&question)
+		return resp, question
+	}
+
+	resp, first := postQuestion(sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, claimsOf("emp-1", "alice@corp.example", true)))
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "Alice from SSO", first.Author.DisplayName)
+
+	resp, second := postQuestion(sign(jwt.SigningMethodES256, "ec-1", ecKey, claimsOf("emp-1", "alice@corp.example", true)))
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, first.UserID, second.UserID)
+	assert.Equal(t, 1, jwks.fetches)
+
+	t.Run("rejected tokens", func(t *testing.T) {
+		wrongAudience := claimsOf("emp-1", "alice@corp.example", true)
+		wrongAudience["aud"] = "another-service"
+		wrongIssuer := claimsOf("emp-1", "alice@corp.example", true)
+		wrongIssuer["iss"] = "https://evil.example.com"
+		expired := claimsOf("emp-1", "alice@corp.example", true)
+		expired["exp"] = time.Now().Add(-time.Minute).Unix()
+		otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+		for name, token := range map[string]string{
+			"audience":  sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, wrongAudience),
+			"issuer":    sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, wrongIssuer),
+			"expired":   sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, expired),
+			"signature": sign(jwt.SigningMethodRS256, "rsa-1", otherKey, claimsOf("emp-1", "alice@corp.example", true)),
+			"algorithm": sign(jwt.SigningMethodHS256, "rsa-1", rsaKey.PublicKey.N.Bytes(), claimsOf("emp-1", "alice@corp.example", true)),
+		} {
+			resp, _ := postQuestion(token)
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, name)
+		}
+	})
+
+	t.Run("email of a local user", func(t *testing.T) {
+		createUser(t, server, "bob")
+
+		for _, verified := range []bool{false, true} {
+			resp, _ := postQuestion(sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, claimsOf("emp-2", "bob@example.com", verified)))
+			assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		}
+	})
+
+	t.Run("another subject with the email of a user of the provider", func(t *testing.T) {
+		resp, question := postQuestion(sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, claimsOf("emp-9", "alice@corp.example", true)))
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, first.UserID, question.UserID)
+	})
+
+	t.Run("key rotation", func(t *testing.T) {
+		jwks.publish(rsaJWK("rsa-2", &rotatedKey.PublicKey))
+		time.Sleep(400 * time.Millisecond)
+
+		resp, question := postQuestion(sign(jwt.SigningMethodRS256, "rsa-2", rotatedKey, claimsOf("emp-1", "alice@corp.example", true)))
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, first.UserID, question.UserID)
+
+		resp, _ = postQuestion(sign(jwt.SigningMethodRS256, "rsa-1", rsaKey, claimsOf("emp-1", "alice@corp.example", true)))
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("slow provider", func(t *testing.T) {
+		stall := make(chan struct{})
+		jwks.mu.Lock()
+		jwks.stall = stall
+		jwks.mu.Unlock()
+		defer close(stall)
+		time.Sleep(400 * time.Millisecond)
+
+		token := sign(jwt.SigningMethodRS256, "rsa-2", rotatedKey, claimsOf("emp-1", "alice@corp.example", true))
+		for i := 0; i < 3; i++ {
+			start := time.Now()
+			resp, _ := postQuestion(token)
+			assert.Equal(t, http.StatusCreated, resp.StatusCode)
+			assert.Less(t, time.Since(start), time.Second, "cached key waits for the refresh")
+		}
+	})
+}
+
+func TestRateLimitAPI(t *testing.T) {
+	app, _ := newTestHTTPServer(t, nil)
+	app.RateLimiter = controller.NewRateLimiter(controller.NewMemoryRateLimitStore(),
+		controller.RateLimit{Requests: 5, Per: time.Minute}, controller.RateLimit{Requests: 3, Per: time.Minute}, controller.RateLimit{}, nil)
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	_, alice := createUser(t, server, "alice")
+	_, bob := createUser(t, server, "bob")
+
+	body, _ := json.Marshal(entity.APIKeyDto{Name: "bot", Scopes: []string{entity.ScopeQuestionsWrite}})
+	resp, err := alice.Post(server.URL+"/api-keys", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	var key entity.CreatedAPIKey
+	json.NewDecoder(resp.Body).Decode(&key)
+
+	ask := func(client *http.Client, apiKey string) *http.Response {
+		body, _ := json.Marshal(entity.QuestionDto{Text: "Is this spam?"})
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/question", bytes.NewReader(body))
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	t.Run("writes of a user", func(t *testing.T) {
+		for _, remaining := range []string{"1", "0"} {
+			resp := ask(alice, "")
+			assert.Equal(t, http.StatusCreated, resp.StatusCode)
+			assert.Equal(t, "3", resp.Header.Get("RateLimit-Limit"))
+			assert.Equal(t, remaining, resp.Header.Get("RateLimit-Remaining"))
+			assert.Equal(t, "3;w=60", resp.Header.Get("RateLimit-Policy"))
+		}
+
+		resp := ask(alice, "")
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		assert.InDelta(t, 20, retryAfter, 1)
+		assert.Equal(t, "0", resp.Header.Get("RateLimit-Remaining"))
+		assert.Equal(t, "60", resp.Header.Get("RateLimit-Reset"))
+
+		assert.Equal(t, http.StatusCreated, ask(bob, "").StatusCode)
+		assert.Equal(t, http.StatusCreated, ask(http.DefaultClient, key.Key).StatusCode)
+	})
+
+	t.Run("anonymous clients by IP", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			resp, err := http.Get(server.URL + "/question")
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+
+		resp, err := http.Get(server.URL + "/question")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+		resp, err = alice.Get(server.URL + "/question")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		body, _ := json.Marshal(entity.UserDto{DisplayName: "carol", Email: "carol@example.com"})
+		resp, err = http.Post(server.URL+"/users", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		body, _ = json.Marshal(entity.UserDto{DisplayName: "dave", Email: "dave@example.com"})
+		resp, err = http.Post(server.URL+"/users", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	})
+}
+
+func TestFailedAuthRateLimitAPI(t *testing.T) {
+	app, _ := newTestHTTPServer(t, nil)
+	proxies, err := controller.ParseTrustedProxies("127.0.0.1, ::1")
+	assert.NoError(t, err)
+	app.RateLimiter = controller.NewRateLimiter(controller.NewMemoryRateLimitStore(),
+		controller.RateLimit{}, controller.RateLimit{}, controller.RateLimit{Requests: 3, Per: time.Minute}, proxies)
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	request := func(forwardedFor, apiKey string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/question", nil)
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusUnauthorized, request("203.0.113.7", "qa_guessed-key").StatusCode)
+	}
+
+	resp := request("203.0.113.7", "qa_guessed-key")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+	assert.Equal(t, http.StatusTooManyRequests, request("203.0.113.7", "").StatusCode)
+
+	// The address written by a client in front of the proxy is not believed.
+	assert.Equal(t, http.StatusTooManyRequests, request("198.51.100.1, 203.0.113.7", "").StatusCode)
+	assert.Equal(t, http.StatusOK, request("203.0.113.8", "").StatusCode)
+	assert.Equal(t, http.StatusUnauthorized, request("203.0.113.8", "qa_guessed-key").StatusCode)
+}
+
+// stalledVerifier rejects every token, but only once it is released.
+type stalledVerifier struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (v stalledVerifier) VerifyIdentity(token string) (entity.ExternalIdentity, error) {
+	v.entered <- struct{}{}
+	<-v.release
+	return entity.ExternalIdentity{}, errors.New("bad signature")
+}
+
+func TestFailedAuthRateLimitConcurrentAPI(t *testing.T) {
+	verifier := stalledVerifier{entered: make(chan struct{}, 10), release: make(chan struct{})}
+	app, _ := newTestHTTPServer(t, verifier)
+	app.RateLimiter = controller.NewRateLimiter(controller.NewMemoryRateLimitStore(),
+		controller.RateLimit{}, controller.RateLimit{}, controller.RateLimit{Requests: 3, Per: time.Minute}, nil)
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	statuses := make(chan int, 10)
+	guess := func() {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/question", nil)
+		req.Header.Set("Authorization", "Bearer guessed")
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		statuses <- resp.StatusCode
+	}
+
+	// three guesses hold all the tokens while their credentials are checked
+	for i := 0; i < 3; i++ {
+		go guess()
+		<-verifier.entered
+	}
+	for i := 0; i < 3; i++ {
+		guess()
+		assert.Equal(t, http.StatusTooManyRequests, <-statuses)
+	}
+
+	close(verifier.release)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusUnauthorized, <-statuses)
+	}
+}
+
+func TestIdempotencyAPI(t *testing.T) {
+	server, db := setupTestServer(t)
+	defer server.Close()
+
+	_, alice := createUser(t, server, "alice")
+	_, bob := createUser(t, server, "bob")
+
+	ask := func(client *http.Client, key, text string) (*http.Response, entity.Question) {
+		body, _ := json.Marshal(entity.QuestionDto{Text: text})
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/question", bytes.NewReader(body))
+		req.Header.Set("Idempotency-Key", key)
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+
+		var question entity.Question
+		json.NewDecoder(resp.Body).Decode(&question)
+		return resp, question
+	}
+	countQuestions := func() int64 {
+		var count int64
+		db.Model(&repositoriy.Question{}).Count(&count)
+		return count
+	}
+
+	resp, first := ask(alice, "retry-1", "Asked once")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Idempotent-Replayed"))
+
+	resp, repeated := ask(alice, "retry-1", "Asked once")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "true", resp.Header.Get("Idempotent-Replayed"))
+	assert.Equal(t, first.ID, repeated.ID)
+	assert.Equal(t, int64(1), countQuestions())
+
+	resp, _ = ask(alice, "retry-1", "Asked something else")
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp, other := ask(bob, "retry-1", "Asked once")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.NotEqual(t, first.ID, other.ID)
+	assert.Equal(t, int64(2), countQuestions())
+
+	t.Run("answers", func(t *testing.T) {
+		answer := func() *http.Response {
+			body, _ := json.Marshal(entity.AnswerDto{Text: "Answered once"})
+			req, _ := http.NewRequest(http.MethodPost, server.URL+"/question/"+strconv.Itoa(first.ID)+"/answer", bytes.NewReader(body))
+			req.Header.Set("Idempotency-Key", "answer-1")
+			resp, err := bob.Do(req)
+			assert.NoError(t, err)
+			return resp
+		}
+
+		assert.Equal(t, http.StatusCreated, answer().StatusCode)
+		assert.Equal(t, "true", answer().Header.Get("Idempotent-Replayed"))
+
+		var count int64
+		db.Model(&repositoriy.Answer{}).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("validation errors are replayed too", func(t *testing.T) {
+		resp, _ := ask(alice, "retry-2", "")
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+		resp, _ = ask(alice, "retry-2", "")
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+		assert.Equal(t, "true", resp.Header.Get("Idempotent-Replayed"))
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		resp, _ := ask(alice, "has space", "Asked once")
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+}
+
+func TestConditionalRequestsAPI(t *testing.T) {
+	server, _ := setupTestServer(t)
+	defer server.Close()
+
+	_, alice := createUser(t, server, "alice")
+	_, bob := createUser(t, server, "bob")
+
+	body, _ := json.Marshal(entity.QuestionDto{Text: "Is caching hard?"})
+	resp, err := alice.Post(server.URL+"/question", "application/json", bytes.NewReader(body))
+	assert.NoError(t, err)
+	var question entity.Question
+	json.NewDecoder(resp.Body).Decode(&question)
+	assert.Equal(t, 1, question.Version)
+
+	get := func(path string, header map[string]string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		for name, value := range header {
+			req.Header.Set(name, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+	send := func(method, path, ifMatch string, dto interface{}) *http.Response {
+		body, _ := json.Marshal(dto)
+		req, _ := http.NewRequest(method, server.URL+path, bytes.NewReader(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		resp, err := alice.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+	questionPath := "/question/" + strconv.Itoa(question.ID)
+
+	resp = get(questionPath, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	etag := resp.Header.Get("ETag")
+	assert.Regexp(t, `^"1-[0-9a-f]{32}"$`, etag)
+	lastModified := resp.Header.Get("Last-Modified")
+	assert.NotEmpty(t, lastModified)
+	assert.Contains(t, resp.Header.Get("Vary"), "Authorization")
+
+	t.Run("not modified", func(t *testing.T) {
+		resp := get(questionPath, map[string]string{"If-None-Match": etag})
+		assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+		assert.Equal(t, etag, resp.Header.Get("ETag"))
+
+		resp = get(questionPath, map[string]string{"If-Modified-Since": lastModified})
+		assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+		resp = get(questionPath, map[string]string{"If-Modified-Since": time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// If-None-Match wins when both are sent
+		resp = get(questionPath, map[string]string{"If-None-Match": `"stale"`, "If-Modified-Since": lastModified})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		list := get("/question", nil)
+		assert.Equal(t, http.StatusOK, list.StatusCode)
+		resp = get("/question", map[string]string{"If-None-Match": list.Header.Get("ETag")})
+		assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	})
+
+	t.Run("vote changes the tag and the time", func(t *testing.T) {
+		// Last-Modified counts whole seconds
+		time.Sleep(1100 * time.Millisecond)
+		body, _ := json.Marshal(entity.VoteDto{Value: entity.VoteUp})
+		resp, err := bob.Post(server.URL+questionPath+"/vote", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = get(questionPath, map[string]string{"If-None-Match": etag})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		etag = resp.Header.Get("ETag")
+
+		resp = get(questionPath, map[string]string{"If-Modified-Since": lastModified})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NotEqual(t, lastModified, resp.Header.Get("Last-Modified"))
+		lastModified = resp.Header.Get("Last-Modified")
+	})
+
+	t.Run("comment on an answer changes the time of the question", func(t *testing.T) {
+		body, _ := json.Marshal(entity.AnswerDto{Text: "Naming things is harder"})
+		resp, err := bob.Post(server.URL+questionPath+"/answer", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		var answer entity.Answer
+		json.NewDecoder(resp.Body).Decode(&answer)
+		lastModified = get(questionPath, nil).Header.Get("Last-Modified")
+
+		time.Sleep(1100 * time.Millisecond)
+		body, _ = json.Marshal(entity.CommentDto{Text: "Off by one too"})
+		resp, err = alice.Post(server.URL+"/answer/"+strconv.Itoa(answer.ID)+"/comments", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp = get(questionPath, map[string]string{"If-Modified-Since": lastModified})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp = get("/answer/"+strconv.Itoa(answer.ID), map[string]string{"If-Modified-Since": lastModified})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		etag = get(questionPath, nil).Header.Get("ETag")
+	})
+
+	t.Run("new answer changes the tag", func(t *testing.T) {
+		body, _ := json.Marshal(entity.AnswerDto{Text: "Only the invalidation part"})
+		resp, err := bob.Post(server.URL+questionPath+"/answer", "application/json", bytes.NewReader(body))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		resp = get(questionPath, map[string]string{"If-None-Match": etag})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NotEqual(t, etag, resp.Header.Get("ETag"))
+	})
+
+	t.Run("optimistic concurrency", func(t *testing.T) {
+		text := "Is cache invalidation hard?"
+		resp := send(http.MethodPatch, questionPath, etag, entity.QuestionUpdateDto{Text: &text})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		updatedTag := resp.Header.Get("ETag")
+		assert.Regexp(t, `^"2-`, updatedTag)
+
+		other := "Somebody else's edit"
+		resp = send(http.MethodPatch, questionPath, etag, entity.QuestionUpdateDto{Text: &other})
+		assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+		resp = send(http.MethodDelete, questionPath, etag, nil)
+		assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+		resp = send(http.MethodDelete, questionPath, `W/`+updatedTag, nil)
+		assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+		resp = send(http.MethodDelete, questionPath, etag+`, W/`+updatedTag+`, "foreign"`, nil)
+		assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+		resp = send(http.MethodDelete, questionPath, etag+", "+updatedTag, nil)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	})
+}
+
+// recordingLogger keeps every line logged through it or the loggers it derives.
+type recordingLogger struct {
+	mu     *sync.Mutex
+	lines  *[]map[string]interface{}
+	fields map[string]interface{}
+}
+
+func newRecordingLogger() recordingLogger {
+	return recordingLogger{mu: &sync.Mutex{}, lines: &[]map[string]interface{}{}, fields: map[string]interface{}{}}
+}
+
+func (l recordingLogger) record(msg string, fields ...interface{}) {
+	line := map[string]interface{}{"msg": msg}
+	for k, v := range l.fields {
+		line[k] = v
+	}
+	for i := 0; i+1 < len(fields); i += 2 {
+		line[fields[i].(string)] = fields[i+1]
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	*l.lines = append(*l.lines, line)
+}
+
+func (l recordingLogger) Debug(msg string, fields ...interface{}) { l.record(msg, fields...) }
+func (l recordingLogger) Info(msg string, fields ...interface{})  { l.record(msg, fields...) }
+func (l recordingLogger) Warn(msg string, fields ...interface{})  { l.record(msg, fields...) }
+func (l recordingLogger) Error(msg string, fields ...interface{}) { l.record(msg, fields...) }
+
+func (l recordingLogger) WithFields(fields map[string]interface{}) pkg.Logger {
+	merged := map[string]interface{}{}
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return recordingLogger{mu: l.mu, lines: l.lines, fields: merged}
+}
+
+// find returns the lines with the message, in the order they were logged.
+func (l recordingLogger) find(msg string) []map[string]interface{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var found []map[string]interface{}
+	for _, line := range *l.lines {
+		if line["msg"] == msg {
+			found = append(found, line)
+		}
+	}
+	return found
+}
+
+func TestRequestScopeAPI(t *testing.T) {
+	logger := newRecordingLogger()
+	// Use cases are left out, so every route that reaches one panics.
+	handlers := controller.NewHTTPHandler(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, logger)
+	server := httptest.NewServer((&controller.HTTPServer{Handlers: *handlers}).Handler())
+	defer server.Close()
+
+	get := func(path, requestID string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		if requestID != "" {
+			req.Header.Set("X-Request-ID", requestID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		return resp
+	}
+
+	t.Run("request id", func(t *testing.T) {
+		resp := get("/question/abc", "client-id-1")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "client-id-1", resp.Header.Get("X-Request-ID"))
+
+		var problem controller.Problem
+		json.NewDecoder(resp.Body).Decode(&problem)
+		assert.Equal(t, "client-id-1", problem.RequestID)
+
+		resp = get("/question/abc", "forged\tid")
+		_, err := uuid.Parse(resp.Header.Get("X-Request-ID"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		resp := get("/question", "panic-id")
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
+
+		var problem controller.Problem
+		json.NewDecoder(resp.Body).Decode(&problem)
+		assert.Equal(t, "Internal server error", problem.Detail)
+		assert.Equal(t, "panic-id", problem.RequestID)
+
+		panics := logger.find("handler panicked")
+		if assert.Len(t, panics, 1) {
+			assert.Equal(t, "panic-id", panics[0]["request_id"])
+			assert.Contains(t, panics[0]["stack"], "QuestionGetAll")
+		}
+
+		resp = get("/question/abc", "")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("access log", func(t *testing.T) {
+		var line map[string]interface{}
+		for _, l := range logger.find("HTTP request") {
+			if l["request_id"] == "panic-id" {
+				line = l
+			}
+		}
+		if assert.NotNil(t, line) {
+			assert.Equal(t, "GET", line["method"])
+			assert.Equal(t, "GET /question", line["route"])
+			assert.Equal(t, http.StatusInternalServerError, line["status"])
+			assert.Greater(t, line["bytes"], 0)
+			assert.NotNil(t, line["duration"])
+		}
 	})
 }
@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testovoe/internal/entity"
+)
+
+// POST accept answer       input - query id, answerID                     output - json question
+func (h *HTTPHandler) QuestionAccept(w http.ResponseWriter, r *http.Request) {
+	h.accept(w, r, func(questionID int, dto entity.AcceptDto) (entity.Question, error) {
+		answerID, err := pathID(r, "answerID")
+		if err != nil {
+			return entity.Question{}, err
+		}
+		return h.answer.Accept(questionID, answerID, dto)
+	})
+}
+
+// DELETE accepted answer   input - query id                               output - json question
+func (h *HTTPHandler) QuestionUnaccept(w http.ResponseWriter, r *http.Request) {
+	h.accept(w, r, h.answer.Unaccept)
+}
+
+func (h *HTTPHandler) accept(w http.ResponseWriter, r *http.Request, accept func(int, entity.AcceptDto) (entity.Question, error)) {
+	id, err := pathID(r, "id")
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	question, err := accept(id, entity.AcceptDto{UserID: currentUserID(r)})
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(question, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("accepted answer changed via HTTP")
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testovoe/internal/entity"
+)
+
+// POST api key             input - json with name and scopes   output - json key, shown only once
+func (h *HTTPHandler) APIKeyCreate(w http.ResponseWriter, r *http.Request) {
+	keyDTO := entity.APIKeyDto{}
+
+	err := json.NewDecoder(r.Body).Decode(&keyDTO)
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, _ := currentUser(r)
+	key, err := h.apiKeys.Create(user, keyDTO)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(key, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("API key created via HTTP", "api_key_id", key.ID)
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
+
+// GET api keys             input - nothing              output - json keys of the user without secrets
+func (h *HTTPHandler) APIKeyList(w http.ResponseWriter, r *http.Request) {
+	user, _ := currentUser(r)
+	keys, err := h.apiKeys.List(user)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(keys, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("API keys listed via HTTP")
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
+
+// DELETE api key           input - query id             output - 204
+func (h *HTTPHandler) APIKeyRevoke(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, _ := currentUser(r)
+	err = h.apiKeys.Revoke(user, id)
+
+	h.log(r).Info("API key revoked via HTTP")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testovoe/internal/entity"
+	"testovoe/internal/usecase"
+
+	"github.com/google/uuid"
+)
+
+type contextKey int
+
+const (
+	userContextKey contextKey = iota
+	apiKeyContextKey
+	requestIDContextKey
+	loggerContextKey
+)
+
+// publicRoutes change data but do not need a user, a token sent to them is ignored so that
+// a client holding an expired access token can still log in or refresh it.
+var publicRoutes = map[string]bool{
+	"POST /users":         true,
+	"POST /auth/register": true,
+	"POST /auth/login":    true,
+	"POST /auth/refresh":  true,
+	"POST /auth/logout":   true,
+}
+
+// routeScopes is the scope an API key needs for a route, other reads need questions:read
+// and other changes need admin.
+var routeScopes = map[string]string{
+	"POST /question":                        entity.ScopeQuestionsWrite,
+	"PATCH /question/{id}":                  entity.ScopeQuestionsWrite,
+	"DELETE /question/{id}":                 entity.ScopeQuestionsWrite,
+	"POST /question/{id}/restore":           entity.ScopeQuestionsWrite,
+	"POST /question/{id}/vote":              entity.ScopeQuestionsWrite,
+	"DELETE /question/{id}/vote":            entity.ScopeQuestionsWrite,
+	"POST /question/{id}/accept/{answerID}": entity.ScopeQuestionsWrite,
+	"DELETE /question/{id}/accept":          entity.ScopeQuestionsWrite,
+	"POST /question/{id}/comments":          entity.ScopeQuestionsWrite,
+
+	"POST /question/{id}/answer": entity.ScopeAnswersWrite,
+	"PATCH /answer/{id}":         entity.ScopeAnswersWrite,
+	"DELETE /answer/{id}":        entity.ScopeAnswersWrite,
+	"POST /answer/{id}/restore":  entity.ScopeAnswersWrite,
+	"POST /answer/{id}/vote":     entity.ScopeAnswersWrite,
+	"DELETE /answer/{id}/vote":   entity.ScopeAnswersWrite,
+	"POST /answer/{id}/comments": entity.ScopeAnswersWrite,
+
+	"GET /api-keys": entity.ScopeAdmin,
+}
+
+// authenticate resolves the bearer token or the X-API-Key header into the user of the request.
+// Bearer tokens are our own JWTs or, when SSO is configured, tokens of the identity provider.
+// Reads may stay anonymous, mutating routes require credentials, and invalid ones are rejected everywhere.
+// The router is only asked for the route pattern, requests go on to next.
+func (s *HTTPServer) authenticate(router *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := router.Handler(r)
+		if publicRoutes[pattern] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := r.Header.Get("Authorization")
+
+		if key := r.Header.Get("X-API-Key"); key != "" {
+			if header != "" {
+				unauthorized(w, r, errors.New("Use either a bearer token or an API key"))
+				return
+			}
+
+			user, apiKey, err := s.Handlers.apiKeys.Authenticate(key)
+			if err != nil {
+				s.Handlers.writeError(w, r, err)
+				return
+			}
+
+			if pattern != "" && !apiKey.HasScope(requiredScope(r.Method, pattern)) {
+				httpError(w, r, errors.New("API key has no "+requiredScope(r.Method, pattern)+" scope"), http.StatusForbidden)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), userContextKey, user)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, apiKeyContextKey, apiKey)))
+			return
+		}
+
+		if header == "" {
+			if isReadOnly(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			unauthorized(w, r, usecase.ErrUnauthenticated)
+			return
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			unauthorized(w, r, errors.New("Authorization must be a bearer token"))
+			return
+		}
+
+		user, err := s.Handlers.auth.Authenticate(token)
+		if err != nil && s.Handlers.oidc != nil {
+			user, err = s.Handlers.oidc.Authenticate(token)
+		}
+		if err != nil {
+			s.Handlers.writeError(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userContextKey, user)))
+	})
+}
+
+// currentUser returns the authenticated user of the request.
+func currentUser(r *http.Request) (entity.User, bool) {
+	user, ok := r.Context().Value(userContextKey).(entity.User)
+	return user, ok
+}
+
+// currentAPIKey returns the API key the request was authenticated with.
+func currentAPIKey(r *http.Request) (entity.APIKey, bool) {
+	key, ok := r.Context().Value(apiKeyContextKey).(entity.APIKey)
+	return key, ok
+}
+
+// currentUserID is the id of the authenticated user, uuid.Nil for anonymous requests.
+func currentUserID(r *http.Request) uuid.UUID {
+	user, _ := currentUser(r)
+	return user.ID
+}
+
+func requiredScope(method, pattern string) string {
+	if scope, ok := routeScopes[pattern]; ok {
+		return scope
+	}
+
+	if isReadOnly(method) {
+		return entity.ScopeQuestionsRead
+	}
+
+	return entity.ScopeAdmin
+}
+
+func isReadOnly(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	httpError(w, r, err, http.StatusUnauthorized)
+}
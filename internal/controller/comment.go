@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testovoe/internal/entity"
+)
+
+// POST comment on question input - query id, json with text   output - json created comment
+func (h *HTTPHandler) QuestionCommentCreate(w http.ResponseWriter, r *http.Request) {
+	h.commentCreate(w, r, h.comments.CommentQuestion)
+}
+
+// POST comment on answer   input - query id, json with text   output - json created comment
+func (h *HTTPHandler) AnswerCommentCreate(w http.ResponseWriter, r *http.Request) {
+	h.commentCreate(w, r, h.comments.CommentAnswer)
+}
+
+// GET comments of question input - query id, cursor, limit   output - json page of comments
+func (h *HTTPHandler) QuestionComments(w http.ResponseWriter, r *http.Request) {
+	h.commentList(w, r, h.comments.ListByQuestion)
+}
+
+// GET comments of answer   input - query id, cursor, limit   output - json page of comments
+func (h *HTTPHandler) AnswerComments(w http.ResponseWriter, r *http.Request) {
+	h.commentList(w, r, h.comments.ListByAnswer)
+}
+
+// DELETE comment           input - query id             output - 204
+func (h *HTTPHandler) CommentDelete(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, _ := currentUser(r)
+	err = h.comments.Delete(user, id)
+
+	h.log(r).Info("comment deleted via HTTP")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *HTTPHandler) commentCreate(w http.ResponseWriter, r *http.Request, create func(int, entity.CommentDto) (entity.Comment, error)) {
+	id, err := pathID(r, "id")
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	commentDTO := entity.CommentDto{}
+
+	err = json.NewDecoder(r.Body).Decode(&commentDTO)
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	commentDTO.UserID = currentUserID(r)
+
+	comment, err := create(id, commentDTO)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(comment, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("comment created via HTTP")
+
+	w.WriteHeader(http.StatusCreated)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
+
+func (h *HTTPHandler) commentList(w http.ResponseWriter, r *http.Request, list func(int, entity.PageRequest) (entity.Page[entity.Comment], error)) {
+	id, err := pathID(r, "id")
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	comments, err := list(id, page)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(comments, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("comments listed via HTTP")
+
+	setNextLink(w, r, comments.NextCursor)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// conditional gives successful reads a strong ETag and, when the handler knows it, Last-Modified,
+// and answers 304 Not Modified when the client already has that body. Bodies depend on who asks,
+// an author sees more of a profile and moderators see hidden posts, so caches must keep them apart.
+func (s *HTTPServer) conditional(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Authorization, X-API-Key")
+
+		buffered := &conditionalWriter{ResponseWriter: w}
+		next.ServeHTTP(buffered, r)
+
+		if buffered.status == 0 {
+			buffered.status = http.StatusOK
+		}
+
+		if buffered.status != http.StatusOK {
+			w.WriteHeader(buffered.status)
+			if _, err := w.Write(buffered.body.Bytes()); err != nil {
+				requestLogger(r).Warn("failed to write response", "error", err)
+			}
+			return
+		}
+
+		header := w.Header()
+		etag := etagOf(buffered.version, buffered.body.Bytes())
+		header.Set("ETag", etag)
+		if !buffered.modified.IsZero() {
+			header.Set("Last-Modified", buffered.modified.UTC().Format(http.TimeFormat))
+		}
+		if header.Get("Cache-Control") == "" {
+			header.Set("Cache-Control", "no-cache")
+		}
+
+		if notModified(r, etag, buffered.modified) {
+			header.Del("Content-Type")
+			header.Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(buffered.body.Bytes()); err != nil {
+			requestLogger(r).Warn("failed to write response", "error", err)
+		}
+	})
+}
+
+// conditionalWriter holds the response back until its ETag is known.
+type conditionalWriter struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	version  *int
+	modified time.Time
+}
+
+func (w *conditionalWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *conditionalWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// setValidators tells the conditional middleware the version of the resource being read
+// and when anything shown with it last changed.
+func setValidators(w http.ResponseWriter, version int, modified time.Time) {
+	if buffered, ok := w.(*conditionalWriter); ok {
+		buffered.version = &version
+		buffered.modified = modified
+	}
+}
+
+// etagOf hashes the body, so a tag changes with any change of the response. Versioned resources
+// carry the version in front of the hash, that part is what If-Match is checked against.
+func etagOf(version *int, body []byte) string {
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:16])
+	if version == nil {
+		return `"` + hash + `"`
+	}
+	return `"` + strconv.Itoa(*version) + "-" + hash + `"`
+}
+
+// notModified evaluates If-None-Match, weak tags match too as the RFC says for GET,
+// and only when it is absent, If-Modified-Since.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		for _, tag := range strings.Split(header, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || modified.IsZero() {
+		return false
+	}
+	return !modified.Truncate(time.Second).After(since)
+}
+
+// ifMatchVersions reads the versions the client accepts from the list in If-Match, nil when the
+// header is absent or "*". Weak tags and tags we did not issue can not match and are left out,
+// so a header with none of ours gives an empty list that no version is in.
+func ifMatchVersions(r *http.Request) []int {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil
+	}
+
+	versions := []int{}
+	for _, tag := range strings.Split(header, ",") {
+		tag, ok := strings.CutPrefix(strings.TrimSpace(tag), `"`)
+		if !ok {
+			continue
+		}
+
+		prefix, _, ok := strings.Cut(tag, "-")
+		version, err := strconv.Atoi(prefix)
+		if ok && err == nil {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testovoe/internal/usecase"
+
+	"github.com/google/uuid"
+)
+
+// Problem is an error response as described by RFC 7807.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestID string       `json:"request_id"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError is an invalid field of the request and the rule it breaks, forms use it to highlight the field.
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+}
+
+// problemTypes identify problems of every status we send, other statuses get about:blank.
+var problemTypes = map[int]string{
+	http.StatusBadRequest:            "/problems/bad-request",
+	http.StatusUnauthorized:          "/problems/unauthenticated",
+	http.StatusForbidden:             "/problems/forbidden",
+	http.StatusNotFound:              "/problems/not-found",
+	http.StatusConflict:              "/problems/conflict",
+	http.StatusPreconditionFailed:    "/problems/precondition-failed",
+	http.StatusRequestEntityTooLarge: "/problems/too-large",
+	http.StatusUnprocessableEntity:   "/problems/validation",
+	http.StatusTooManyRequests:       "/problems/rate-limited",
+	http.StatusInternalServerError:   "/problems/internal",
+}
+
+// statusOf maps the kind of a use case error to the HTTP status, untyped errors are internal.
+func statusOf(err error) int {
+	switch usecase.KindOf(err) {
+	case usecase.KindValidation:
+		return http.StatusUnprocessableEntity
+	case usecase.KindNotFound:
+		return http.StatusNotFound
+	case usecase.KindConflict:
+		return http.StatusConflict
+	case usecase.KindForbidden:
+		return http.StatusForbidden
+	case usecase.KindUnauthenticated:
+		return http.StatusUnauthorized
+	case usecase.KindPrecondition:
+		return http.StatusPreconditionFailed
+	case usecase.KindMalformed:
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// writeError is how handlers report failures of use cases. Internal errors are logged and the client
+// gets a generic message, so database and driver details never leave the server.
+// Content held for moderation is not a failure, it is answered with 202 and the held content.
+func (h *HTTPHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	var held *usecase.HeldError
+	if errors.As(err, &held) {
+		h.writeHeld(w, r, held.Content)
+		return
+	}
+
+	status := statusOf(err)
+	switch status {
+	case http.StatusInternalServerError:
+		h.log(r).Error("request failed", "path", r.URL.Path, "error", err)
+		err = errors.New("Internal server error")
+	case http.StatusUnauthorized:
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+
+	httpError(w, r, err, status)
+}
+
+// httpError writes err as a problem, the message of err becomes the detail and the fields
+// of a validation error are listed in errors.
+func httpError(w http.ResponseWriter, r *http.Request, err error, status int) {
+	problem := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    err.Error(),
+		Instance:  r.URL.RequestURI(),
+		RequestID: requestID(w, r),
+	}
+	if t, ok := problemTypes[status]; ok {
+		problem.Type = t
+	}
+	for _, field := range usecase.FieldsOf(err) {
+		problem.Errors = append(problem.Errors, FieldError{Field: field.Field, Rule: field.Rule})
+	}
+
+	b, err := json.MarshalIndent(problem, "", "    ")
+	if err != nil {
+		w.WriteHeader(status)
+		return
+	}
+
+	header := w.Header()
+	header.Del("Content-Length")
+	header.Set("Content-Type", "application/problem+json")
+	header.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	if _, err := w.Write(b); err != nil {
+		requestLogger(r).Warn("failed to write response", "error", err)
+	}
+}
+
+// requestID is the X-Request-ID of the response, taken from the request when the client sent one.
+// It lets a client quote the failed request and us find it in the logs.
+func requestID(w http.ResponseWriter, r *http.Request) string {
+	if id, ok := r.Context().Value(requestIDContextKey).(string); ok {
+		return id
+	}
+	if id := w.Header().Get("X-Request-ID"); id != "" {
+		return id
+	}
+
+	id := r.Header.Get("X-Request-ID")
+	if !validRequestID(id) {
+		id = uuid.NewString()
+	}
+	w.Header().Set("X-Request-ID", id)
+	return id
+}
+
+// validRequestID accepts short IDs of visible ASCII, anything else is replaced so it can not forge log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testovoe/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// questionListParams are query parameters accepted by GET /question, anything else is rejected.
+var questionListParams = map[string]bool{
+	"cursor":         true,
+	"limit":          true,
+	"user_id":        true,
+	"created_after":  true,
+	"created_before": true,
+	"answered":       true,
+	"accepted":       true,
+	"min_answers":    true,
+	"tags":           true,
+	"sort":           true,
+}
+
+// parseQuestionFilter reads filters, sort and page of the question list from query parameters.
+func parseQuestionFilter(r *http.Request) (entity.QuestionFilter, error) {
+	query := r.URL.Query()
+
+	for name := range query {
+		if !questionListParams[name] {
+			return entity.QuestionFilter{}, errors.New("Unknown query parameter: " + name)
+		}
+	}
+
+	page, err := parsePageRequest(r)
+	if err != nil {
+		return entity.QuestionFilter{}, err
+	}
+
+	filter := entity.QuestionFilter{
+		Sort: entity.QuestionSort(query.Get("sort")),
+		Page: page,
+	}
+
+	if s := query.Get("user_id"); s != "" {
+		userID, err := uuid.Parse(s)
+		if err != nil {
+			return entity.QuestionFilter{}, errors.New("User id must be a UUID")
+		}
+		filter.UserID = &userID
+	}
+
+	if s := query.Get("created_after"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return entity.QuestionFilter{}, errors.New("Created after must be an RFC 3339 time")
+		}
+		filter.CreatedAfter = &t
+	}
+
+	if s := query.Get("created_before"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return entity.QuestionFilter{}, errors.New("Created before must be an RFC 3339 time")
+		}
+		filter.CreatedBefore = &t
+	}
+
+	if s := query.Get("answered"); s != "" {
+		answered, err := strconv.ParseBool(s)
+		if err != nil {
+			return entity.QuestionFilter{}, errors.New("Answered must be true or false")
+		}
+		filter.Answered = &answered
+	}
+
+	if s := query.Get("accepted"); s != "" {
+		accepted, err := strconv.ParseBool(s)
+		if err != nil {
+			return entity.QuestionFilter{}, errors.New("Accepted must be true or false")
+		}
+		filter.Accepted = &accepted
+	}
+
+	if s := query.Get("min_answers"); s != "" {
+		minAnswers, err := strconv.Atoi(s)
+		if err != nil {
+			return entity.QuestionFilter{}, errors.New("Min answers must be a number")
+		}
+		filter.MinAnswers = minAnswers
+	}
+
+	if s := query.Get("tags"); s != "" {
+		filter.Tags = strings.Split(s, ",")
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testovoe/internal/entity"
+)
+
+// POST flag question       input - query id, json with reason   output - json flag
+func (h *HTTPHandler) QuestionFlag(w http.ResponseWriter, r *http.Request) {
+	h.flag(w, r, "question", h.flags.FlagQuestion)
+}
+
+// POST flag answer         input - query id, json with reason   output - json flag
+func (h *HTTPHandler) AnswerFlag(w http.ResponseWriter, r *http.Request) {
+	h.flag(w, r, "answer", h.flags.FlagAnswer)
+}
+
+func (h *HTTPHandler) flag(w http.ResponseWriter, r *http.Request, kind string, flag func(int, entity.FlagDto) (entity.Flag, error)) {
+	id, err := pathID(r, "id")
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	flagDTO := entity.FlagDto{}
+
+	err = json.NewDecoder(r.Body).Decode(&flagDTO)
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	flagDTO.UserID = currentUserID(r)
+
+	result, err := flag(id, flagDTO)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(result, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info(kind+" flagged via HTTP", "flag_id", result.ID)
+
+	w.WriteHeader(http.StatusCreated)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
+
+// GET pending flags (moderator)   input - query cursor, limit   output - json page of flags, oldest first
+func (h *HTTPHandler) ModerationFlagList(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageRequest(r)
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, _ := currentUser(r)
+	flags, err := h.flags.ListPending(user, page)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(flags, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("pending flags listed via HTTP")
+
+	setNextLink(w, r, flags.NextCursor)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
+
+// POST decision on flag (moderator)   input - query id, json with action and note   output - json decision
+func (h *HTTPHandler) ModerationFlagDecide(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	decisionDTO := entity.DecisionDto{}
+
+	err = json.NewDecoder(r.Body).Decode(&decisionDTO)
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, _ := currentUser(r)
+	decision, err := h.flags.Decide(user, id, decisionDTO)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(decision, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("flag resolved via HTTP", "flag_id", id, "action", decision.Action)
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
+
+// GET moderation decisions (moderator)   input - query cursor, limit   output - json page of decisions, newest first
+func (h *HTTPHandler) ModerationDecisionList(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageRequest(r)
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, _ := currentUser(r)
+	decisions, err := h.flags.ListDecisions(user, page)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(decisions, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("moderation decisions listed via HTTP")
+
+	setNextLink(w, r, decisions.NextCursor)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
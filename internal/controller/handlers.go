@@ -3,269 +3,319 @@ package controller
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
 	"strconv"
 	"testovoe/internal/entity"
 	"testovoe/internal/pkg"
 	"testovoe/internal/usecase"
-	"time"
 )
 
 type HTTPHandler struct {
-	answer   *usecase.AnswerUseCase
-	question *usecase.QuestionUseCase
-	logger   pkg.Logger
+	answer     *usecase.AnswerUseCase
+	question   *usecase.QuestionUseCase
+	search     *usecase.SearchUseCase
+	trash      *usecase.TrashUseCase
+	votes      *usecase.VoteUseCase
+	tags       *usecase.TagUseCase
+	comments   *usecase.CommentUseCase
+	users      *usecase.UserUseCase
+	auth       *usecase.AuthUseCase
+	apiKeys    *usecase.APIKeyUseCase
+	oidc       *usecase.OIDCUseCase
+	moderation *usecase.ModerationUseCase
+	flags      *usecase.FlagUseCase
+	logger     pkg.Logger
 }
 
-func NewHTTPHandler(answerUC *usecase.AnswerUseCase, questionUC *usecase.QuestionUseCase, logger pkg.Logger) *HTTPHandler {
+func NewHTTPHandler(answerUC *usecase.AnswerUseCase, questionUC *usecase.QuestionUseCase, searchUC *usecase.SearchUseCase, trashUC *usecase.TrashUseCase, voteUC *usecase.VoteUseCase, tagUC *usecase.TagUseCase, commentUC *usecase.CommentUseCase, userUC *usecase.UserUseCase, authUC *usecase.AuthUseCase, apiKeyUC *usecase.APIKeyUseCase, oidcUC *usecase.OIDCUseCase, moderationUC *usecase.ModerationUseCase, flagUC *usecase.FlagUseCase, logger pkg.Logger) *HTTPHandler {
 	return &HTTPHandler{
-		answer:   answerUC,
-		question: questionUC,
-		logger:   logger.WithFields(map[string]interface{}{"layer": "http"}),
+		answer:     answerUC,
+		question:   questionUC,
+		search:     searchUC,
+		trash:      trashUC,
+		votes:      voteUC,
+		tags:       tagUC,
+		comments:   commentUC,
+		users:      userUC,
+		auth:       authUC,
+		apiKeys:    apiKeyUC,
+		oidc:       oidcUC,
+		moderation: moderationUC,
+		flags:      flagUC,
+		logger:     logger.WithFields(map[string]interface{}{"layer": "http"}),
 	}
 }
 
-type ErrorDTO struct {
-	Message string    `json:"message"`
-	Time    time.Time `json:"time"`
-}
-
-func (er *ErrorDTO) ToString() string {
-	b, err := json.MarshalIndent(er, "", "    ")
+// GET All questions input - query filters, sort, cursor, limit  output - json page of questions
+func (h *HTTPHandler) QuestionGetAll(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseQuestionFilter(r)
 
 	if err != nil {
-		panic(err)
+		httpError(w, r, err, http.StatusBadRequest)
+		return
 	}
 
-	return string(b)
-}
-
-func httpError(w http.ResponseWriter, err error, status int) {
-	errDTO := ErrorDTO{
-		Message: err.Error(),
-		Time:    time.Now(),
-	}
-
-	http.Error(
-		w,
-		errDTO.ToString(),
-		status,
-	)
-}
-
-// GET All questions input - nothing                  output - json all question
-func (h *HTTPHandler) QuestionGetAll(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("HTTP request received",
-		"method", r.Method,
-		"path", r.URL.Path,
-		"user_agent", r.UserAgent())
-
-	start := time.Now()
-
-	questions, err := h.question.GetAll()
+	questions, err := h.question.List(filter)
 
 	if err != nil {
-		httpError(w, err, http.StatusInternalServerError)
+		h.writeError(w, r, err)
 		return
 	}
 
 	b, err := json.MarshalIndent(questions, "", "    ")
 
 	if err != nil {
-		httpError(w, err, http.StatusInternalServerError)
+		h.writeError(w, r, err)
 		return
 	}
 
-	h.logger.Info("questions getet via HTTP",
-		"duration", time.Since(start),
-	)
+	h.log(r).Info("questions getet via HTTP")
 
+	setNextLink(w, r, questions.NextCursor)
 	w.WriteHeader(http.StatusOK)
 	if _, err := w.Write(b); err != nil {
-		fmt.Println("Error to write answer")
+		h.log(r).Warn("failed to write response", "error", err)
 	}
 }
 
 // GET 1 question    input - query id                 output - json one question
 func (h *HTTPHandler) QuestionGetById(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("HTTP request received",
-		"method", r.Method,
-		"path", r.URL.Path,
-		"user_agent", r.UserAgent(),
-	)
-
-	start := time.Now()
-
 	StringID := r.PathValue("id")
 	if StringID == "" {
-		httpError(w, errors.New("This id is empty"), http.StatusBadRequest)
+		httpError(w, r, errors.New("This id is empty"), http.StatusBadRequest)
 		return
 	}
 
 	id, err := strconv.Atoi(StringID)
 
 	if err != nil {
-		httpError(w, err, http.StatusBadRequest)
+		httpError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	question, err := h.question.GetByID(id)
+	question, err := h.question.GetWithAnswers(id)
 
 	if err != nil {
-		httpError(w, err, http.StatusBadRequest)
+		h.writeError(w, r, err)
 		return
 	}
 
 	b, err := json.MarshalIndent(question, "", "    ")
 
 	if err != nil {
-		httpError(w, err, http.StatusInternalServerError)
+		h.writeError(w, r, err)
 		return
 	}
 
-	h.logger.Info("question geted via HTTP",
-		"duration", time.Since(start),
-	)
+	setValidators(w, question.Version, question.ChangedAt)
+
+	h.log(r).Info("question geted via HTTP")
 
 	w.WriteHeader(http.StatusOK)
 	if _, err := w.Write(b); err != nil {
-		fmt.Println("Error to write answer")
+		h.log(r).Warn("failed to write response", "error", err)
 	}
 }
 
 // POST question     input - json with data question  output - json created question
 func (h *HTTPHandler) QuestionCreate(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("HTTP request received",
-		"method", r.Method,
-		"path", r.URL.Path,
-		"user_agent", r.UserAgent(),
-	)
-	start := time.Now()
-
 	questionDTO := entity.QuestionDto{}
 
 	err := json.NewDecoder(r.Body).Decode(&questionDTO)
 
 	if err != nil {
-		httpError(w, err, http.StatusBadRequest)
+		httpError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
+	questionDTO.UserID = currentUserID(r)
+
 	question, err := h.question.Save(questionDTO)
 
 	if err != nil {
-		httpError(w, err, http.StatusBadRequest)
+		h.writeError(w, r, err)
 		return
 	}
 
 	b, err := json.MarshalIndent(question, "", "    ")
 
 	if err != nil {
-		httpError(w, err, http.StatusInternalServerError)
+		h.writeError(w, r, err)
 		return
 	}
 
-	h.logger.Info("question created via HTTP", "duration", time.Since(start))
+	h.log(r).Info("question created via HTTP")
 
 	w.WriteHeader(http.StatusCreated)
 	if _, err := w.Write(b); err != nil {
-		fmt.Println("Error to write answer")
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
+
+// PATCH question    input - query id, json with new text  output - json updated question
+func (h *HTTPHandler) QuestionUpdate(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	updateDTO := entity.QuestionUpdateDto{}
+
+	err = json.NewDecoder(r.Body).Decode(&updateDTO)
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	updateDTO.Versions = ifMatchVersions(r)
+
+	user, _ := currentUser(r)
+	question, err := h.question.Update(user, id, updateDTO)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(question, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etagOf(&question.Version, b))
+
+	h.log(r).Info("question updated via HTTP")
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
 	}
 }
 
 // DELETE question   input - query id                 output - nothing
 func (h *HTTPHandler) QuestionDelete(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("HTTP request received",
-		"method", r.Method,
-		"path", r.URL.Path,
-		"user_agent", r.UserAgent(),
-	)
-	start := time.Now()
-
 	StringID := r.PathValue("id")
 	if StringID != "" {
 		id, err := strconv.Atoi(StringID)
 
 		if err != nil {
-			httpError(w, err, http.StatusBadRequest)
+			httpError(w, r, err, http.StatusBadRequest)
 			return
 		}
 
-		err = h.question.Delete(id)
+		user, _ := currentUser(r)
+		err = h.question.Delete(user, id, ifMatchVersions(r))
 
-		h.logger.Info("question deleted via HTTP", "duration", time.Since(start))
+		h.log(r).Info("question deleted via HTTP")
 
 		if err != nil {
-			httpError(w, err, http.StatusBadRequest)
+			h.writeError(w, r, err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
 	} else {
-		httpError(w, errors.New("This id is empty"), http.StatusBadRequest)
+		httpError(w, r, errors.New("This id is empty"), http.StatusBadRequest)
 	}
 }
 
 func (h *HTTPHandler) AnswerGetById(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("HTTP request received",
-		"method", r.Method,
-		"path", r.URL.Path,
-		"user_agent", r.UserAgent(),
-	)
-	start := time.Now()
-
 	StringID := r.PathValue("id")
 	if StringID == "" {
-		httpError(w, errors.New("This id is empty"), http.StatusBadRequest)
+		httpError(w, r, errors.New("This id is empty"), http.StatusBadRequest)
 		return
 	}
 
 	id, err := strconv.Atoi(StringID)
 
 	if err != nil {
-		httpError(w, err, http.StatusBadRequest)
+		httpError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	answer, err := h.answer.GetByID(id)
 
 	if err != nil {
-		httpError(w, err, http.StatusBadRequest)
+		h.writeError(w, r, err)
 		return
 	}
 
 	b, err := json.MarshalIndent(answer, "", "    ")
 
 	if err != nil {
-		httpError(w, err, http.StatusInternalServerError)
+		h.writeError(w, r, err)
 		return
 	}
 
-	h.logger.Info("answer geted via HTTP", "duration", time.Since(start))
+	setValidators(w, answer.Version, answer.ChangedAt)
+
+	h.log(r).Info("answer geted via HTTP")
 
 	w.WriteHeader(http.StatusOK)
 	if _, err := w.Write(b); err != nil {
-		fmt.Println("Error to write answer")
+		h.log(r).Warn("failed to write response", "error", err)
 	}
 }
 
-func (h *HTTPHandler) AnswerCreate(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("HTTP request received",
-		"method", r.Method,
-		"path", r.URL.Path,
-		"user_agent", r.UserAgent(),
-	)
-	start := time.Now()
+// GET answers of question   input - query id, sort, cursor, limit  output - json page of answers, oldest first or by score
+func (h *HTTPHandler) AnswerListByQuestion(w http.ResponseWriter, r *http.Request) {
+	StringID := r.PathValue("id")
+	if StringID == "" {
+		httpError(w, r, errors.New("This id is empty"), http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.Atoi(StringID)
 
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePageRequest(r)
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	answers, err := h.answer.ListByQuestion(id, entity.AnswerSort(r.URL.Query().Get("sort")), page)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(answers, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("answers listed via HTTP")
+
+	setNextLink(w, r, answers.NextCursor)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
+
+func (h *HTTPHandler) AnswerCreate(w http.ResponseWriter, r *http.Request) {
 	StringID := r.PathValue("id")
 	if StringID == "" {
-		httpError(w, errors.New("This id is empty"), http.StatusBadRequest)
+		httpError(w, r, errors.New("This id is empty"), http.StatusBadRequest)
 		return
 	}
 	id, err := strconv.Atoi(StringID)
 
 	if err != nil {
-		httpError(w, err, http.StatusBadRequest)
+		httpError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
@@ -274,60 +324,101 @@ func (h *HTTPHandler) AnswerCreate(w http.ResponseWriter, r *http.Request) {
 	err = json.NewDecoder(r.Body).Decode(&AnswerDTO)
 
 	if err != nil {
-		httpError(w, err, http.StatusBadRequest)
+		httpError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
+	AnswerDTO.UserID = currentUserID(r)
+
 	answer, err := h.answer.Save(AnswerDTO, id)
 
 	if err != nil {
-		httpError(w, err, http.StatusBadRequest)
+		h.writeError(w, r, err)
 		return
 	}
 
 	b, err := json.MarshalIndent(answer, "", "    ")
 
 	if err != nil {
-		httpError(w, err, http.StatusInternalServerError)
+		h.writeError(w, r, err)
 		return
 	}
 
-	h.logger.Info("answer created via HTTP", "duration", time.Since(start))
+	h.log(r).Info("answer created via HTTP")
 
 	w.WriteHeader(http.StatusCreated)
 	if _, err := w.Write(b); err != nil {
-		fmt.Println("Error to write answer")
+		h.log(r).Warn("failed to write response", "error", err)
 	}
 }
 
-func (h *HTTPHandler) AnswerDelete(w http.ResponseWriter, r *http.Request) {
-	h.logger.Info("HTTP request received",
-		"method", r.Method,
-		"path", r.URL.Path,
-		"user_agent", r.UserAgent(),
-	)
-	start := time.Now()
+// PATCH answer      input - query id, json with new text  output - json updated answer
+func (h *HTTPHandler) AnswerUpdate(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	updateDTO := entity.AnswerUpdateDto{}
+
+	err = json.NewDecoder(r.Body).Decode(&updateDTO)
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	updateDTO.Versions = ifMatchVersions(r)
+
+	user, _ := currentUser(r)
+	answer, err := h.answer.Update(user, id, updateDTO)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
 
+	b, err := json.MarshalIndent(answer, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", etagOf(&answer.Version, b))
+
+	h.log(r).Info("answer updated via HTTP")
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
+
+func (h *HTTPHandler) AnswerDelete(w http.ResponseWriter, r *http.Request) {
 	StringID := r.PathValue("id")
 	if StringID != "" {
 		id, err := strconv.Atoi(StringID)
 
 		if err != nil {
-			httpError(w, err, http.StatusBadRequest)
+			httpError(w, r, err, http.StatusBadRequest)
 			return
 		}
 
-		err = h.answer.Delete(id)
+		user, _ := currentUser(r)
+		err = h.answer.Delete(user, id, ifMatchVersions(r))
 
-		h.logger.Info("answer deleted via HTTP", "duration", time.Since(start))
+		h.log(r).Info("answer deleted via HTTP")
 
 		if err != nil {
-			httpError(w, err, http.StatusBadRequest)
+			h.writeError(w, r, err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
 
 	} else {
-		httpError(w, errors.New("This id is empty"), http.StatusBadRequest)
+		httpError(w, r, errors.New("This id is empty"), http.StatusBadRequest)
 	}
 }
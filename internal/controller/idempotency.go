@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+)
+
+// idempotentRoutes accept the Idempotency-Key header. Routes that hand out secrets are left out
+// so that tokens and API keys are never stored.
+var idempotentRoutes = map[string]bool{
+	"POST /question":               true,
+	"POST /question/{id}/answer":   true,
+	"POST /question/{id}/comments": true,
+	"POST /answer/{id}/comments":   true,
+}
+
+// maxIdempotentBody is the largest request body that is fingerprinted.
+const maxIdempotentBody = 1 << 20
+
+// idempotent replays the stored response when a client repeats a request with the same
+// Idempotency-Key, so a retry after a timeout does not create a second question or answer.
+func (s *HTTPServer) idempotent(router *http.ServeMux, next http.Handler) http.Handler {
+	keys := s.Idempotency
+	if keys == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		user, ok := currentUser(r)
+		_, pattern := router.Handler(r)
+		if key == "" || !ok || !idempotentRoutes[pattern] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBody))
+		if err != nil {
+			httpError(w, r, err, http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		record, replay, err := keys.Begin(user.ID, key, fingerprint(r, body))
+		if err != nil {
+			s.Handlers.writeError(w, r, err)
+			return
+		}
+
+		if replay {
+			s.Handlers.log(r).Info("idempotent response replayed", "user_id", user.ID, "path", r.URL.Path)
+			w.Header().Set("Content-Type", record.ContentType)
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(record.Status)
+			if _, err := w.Write(record.Body); err != nil {
+				requestLogger(r).Warn("failed to write response", "error", err)
+			}
+			return
+		}
+
+		recorder := &recordingWriter{ResponseWriter: w}
+		completed := false
+		defer func() {
+			if !completed {
+				if err := keys.Abandon(record); err != nil {
+					s.Handlers.log(r).Error("failed to free idempotency key", "user_id", user.ID, "error", err)
+				}
+			}
+		}()
+
+		next.ServeHTTP(recorder, r)
+
+		// Server errors are not stored, the client should be able to retry them.
+		if recorder.status == 0 || recorder.status >= http.StatusInternalServerError {
+			return
+		}
+
+		record.Status = recorder.status
+		record.ContentType = recorder.Header().Get("Content-Type")
+		record.Body = recorder.body.Bytes()
+		if err := keys.Complete(record); err != nil {
+			s.Handlers.log(r).Error("failed to store idempotent response", "user_id", user.ID, "error", err)
+			return
+		}
+		completed = true
+	})
+}
+
+// fingerprint tells requests apart, a key may only be repeated with the same route and body.
+func fingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// recordingWriter passes the response through and keeps a copy of it.
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
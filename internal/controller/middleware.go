@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"runtime/debug"
+	"testovoe/internal/pkg"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// withRequestScope gives every request an ID and a logger that carries it. The ID comes from the
+// X-Request-ID header of the client when it is valid and is sent back in the same header.
+func (s *HTTPServer) withRequestScope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set("X-Request-ID", id)
+
+		logger := s.Handlers.logger.WithFields(map[string]interface{}{"request_id": id})
+		ctx := context.WithValue(r.Context(), requestIDContextKey, id)
+		ctx = context.WithValue(ctx, loggerContextKey, logger)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// accessLog writes one line per request once it is answered. The route is the pattern it matched,
+// so requests to /question/1 and /question/2 are counted together.
+func (s *HTTPServer) accessLog(router *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusWriter{ResponseWriter: w}
+
+		next.ServeHTTP(recorder, r)
+
+		_, route := router.Handler(r)
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		requestLogger(r).Info("HTTP request",
+			"method", r.Method,
+			"route", route,
+			"status", recorder.status,
+			"bytes", recorder.bytes,
+			"duration", time.Since(start),
+			"user_agent", r.UserAgent(),
+		)
+	})
+}
+
+// recoverPanic turns a panic of a handler into a 500 problem, the server keeps running and
+// the stack goes to the log. Aborted handlers panic on purpose and are left to net/http.
+func (s *HTTPServer) recoverPanic(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusWriter{ResponseWriter: w}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			requestLogger(r).Error("handler panicked", "path", r.URL.Path, "panic", v, "stack", string(debug.Stack()))
+			if recorder.status == 0 {
+				httpError(w, r, errors.New("Internal server error"), http.StatusInternalServerError)
+			}
+		}()
+
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+// statusWriter passes the response through and remembers its status and size.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// requestLogger is the logger of the request with its ID, handlers called without the
+// middleware get one that discards everything.
+func requestLogger(r *http.Request) pkg.Logger {
+	if logger, ok := r.Context().Value(loggerContextKey).(pkg.Logger); ok {
+		return logger
+	}
+	return pkg.NewNopLogger()
+}
+
+// log is the logger of the request, or the logger of the handler outside of the middleware.
+func (h *HTTPHandler) log(r *http.Request) pkg.Logger {
+	if logger, ok := r.Context().Value(loggerContextKey).(pkg.Logger); ok {
+		return logger
+	}
+	return h.logger
+}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testovoe/internal/entity"
+)
+
+// GET held content (moderator)   input - query cursor, limit   output - json page of content waiting for moderation
+func (h *HTTPHandler) ModerationHeldList(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageRequest(r)
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, _ := currentUser(r)
+	held, err := h.moderation.ListHeld(user, page)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(held, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("held content listed via HTTP")
+
+	setNextLink(w, r, held.NextCursor)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
+
+// POST approve held content (moderator)   input - query id   output - json held content with the id of the post
+func (h *HTTPHandler) ModerationHeldApprove(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, _ := currentUser(r)
+	held, err := h.moderation.Approve(user, id)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(held, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("held content approved via HTTP", "held_content_id", id, "post_id", *held.PostID)
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
+
+// DELETE held content (moderator)   input - query id   output - 204
+func (h *HTTPHandler) ModerationHeldReject(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, _ := currentUser(r)
+	err = h.moderation.Reject(user, id)
+
+	h.log(r).Info("held content rejected via HTTP", "held_content_id", id)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeHeld answers a post that a content policy held with 202, the post appears once a moderator approves it.
+func (h *HTTPHandler) writeHeld(w http.ResponseWriter, r *http.Request, held entity.HeldContent) {
+	b, err := json.MarshalIndent(held, "", "    ")
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	h.log(r).Info("content held for moderation", "held_content_id", held.ID, "policy", held.Policy)
+
+	w.WriteHeader(http.StatusAccepted)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testovoe/internal/entity"
+	"testovoe/internal/usecase"
+)
+
+// parsePageRequest reads "cursor" and "limit" query parameters.
+func parsePageRequest(r *http.Request) (entity.PageRequest, error) {
+	limit := 0
+	if s := r.URL.Query().Get("limit"); s != "" {
+		l, err := strconv.Atoi(s)
+		if err != nil {
+			return entity.PageRequest{}, errors.New("Limit must be a number")
+		}
+		limit = l
+	}
+
+	return usecase.NewPageRequest(r.URL.Query().Get("cursor"), limit)
+}
+
+// setNextLink adds an RFC 8288 Link header pointing at the next page, if there is one.
+func setNextLink(w http.ResponseWriter, r *http.Request, nextCursor string) {
+	if nextCursor == "" {
+		return
+	}
+
+	query := r.URL.Query()
+	query.Set("cursor", nextCursor)
+
+	next := *r.URL
+	next.RawQuery = query.Encode()
+
+	w.Header().Add("Link", "<"+next.RequestURI()+`>; rel="next"`)
+}
@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// pathID reads a numeric path parameter.
+func pathID(r *http.Request, name string) (int, error) {
+	s := r.PathValue(name)
+	if s == "" {
+		return 0, errors.New("This " + name + " is empty")
+	}
+
+	return strconv.Atoi(s)
+}
+
+// pathUUID reads a UUID path parameter.
+func pathUUID(r *http.Request, name string) (uuid.UUID, error) {
+	s := r.PathValue(name)
+	if s == "" {
+		return uuid.Nil, errors.New("This " + name + " is empty")
+	}
+
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return uuid.Nil, errors.New("This " + name + " must be a UUID")
+	}
+
+	return id, nil
+}
+
+// queryInt reads a required numeric query parameter.
+func queryInt(r *http.Request, name string) (int, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return 0, errors.New("Query parameter " + name + " is required")
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.New("Query parameter " + name + " must be a number")
+	}
+
+	return n, nil
+}
@@ -0,0 +1,328 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit allows Requests per Per window, a client may spend them all at once and then gets
+// them back evenly over the window. Zero Requests means no limit.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// ParseRateLimit reads a limit written as "30/1m", "0" turns the limit off.
+func ParseRateLimit(value string) (RateLimit, error) {
+	if strings.TrimSpace(value) == "0" {
+		return RateLimit{}, nil
+	}
+
+	requests, per, ok := strings.Cut(value, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("rate limit %q must look like 30/1m", value)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || n < 0 {
+		return RateLimit{}, fmt.Errorf("rate limit %q has an invalid number of requests", value)
+	}
+
+	window, err := time.ParseDuration(strings.TrimSpace(per))
+	if err != nil || window <= 0 {
+		return RateLimit{}, fmt.Errorf("rate limit %q has an invalid window", value)
+	}
+
+	return RateLimit{Requests: n, Per: window}, nil
+}
+
+// ParseTrustedProxies reads a comma separated list of proxy addresses or networks, like "10.0.0.0/8, 192.168.1.2".
+func ParseTrustedProxies(value string) ([]netip.Prefix, error) {
+	var proxies []netip.Prefix
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if prefix, err := netip.ParsePrefix(item); err == nil {
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(item)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q is neither an address nor a network", item)
+		}
+		proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return proxies, nil
+}
+
+// RateLimitResult is the state of a bucket after a request took a token from it.
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// Reset is when the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is when the next token appears, set for rejected requests only.
+	RetryAfter time.Duration
+}
+
+// RateLimitStore keeps the token buckets, a shared store makes the limits work across several instances.
+type RateLimitStore interface {
+	Take(key string, limit RateLimit, now time.Time) (RateLimitResult, error)
+	// Refund gives back a token taken for a request that turned out not to count.
+	Refund(key string, limit RateLimit, now time.Time) error
+}
+
+// RateLimiter limits reads and writes of every client separately, and failed authentications of
+// every IP so that tokens and API keys can not be guessed. Requests that come through one of the
+// trusted proxies are counted by the client address the proxy put in X-Forwarded-For.
+type RateLimiter struct {
+	store          RateLimitStore
+	read           RateLimit
+	write          RateLimit
+	failedAuth     RateLimit
+	trustedProxies []netip.Prefix
+}
+
+func NewRateLimiter(store RateLimitStore, read, write, failedAuth RateLimit, trustedProxies []netip.Prefix) *RateLimiter {
+	return &RateLimiter{
+		store:          store,
+		read:           read,
+		write:          write,
+		failedAuth:     failedAuth,
+		trustedProxies: trustedProxies,
+	}
+}
+
+// limitFailedAuth runs before authentication, which costs a database lookup or a JWKS check.
+// Every request takes a token from the bucket of the IP before its credentials are looked at and
+// gets it back unless it is answered 401, so concurrent guesses can not all pass an almost empty
+// bucket. An IP with an empty bucket gets 429.
+func (s *HTTPServer) limitFailedAuth(next http.Handler) http.Handler {
+	limiter := s.RateLimiter
+	if limiter == nil || limiter.failedAuth.Requests == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := "auth:" + limiter.clientIP(r)
+		result, err := limiter.store.Take(key, limiter.failedAuth, time.Now())
+		if err != nil {
+			s.Handlers.log(r).Error("rate limit store failed, request let through", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !result.Allowed {
+			s.Handlers.log(r).Warn("too many failed authentications", "client", key, "path", r.URL.Path)
+			w.Header().Set("Retry-After", strconv.Itoa(seconds(result.RetryAfter)))
+			httpError(w, r, errors.New("Too many failed authentications, retry later"), http.StatusTooManyRequests)
+			return
+		}
+
+		recorder := &statusWriter{ResponseWriter: w}
+		defer func() {
+			if recorder.status != http.StatusUnauthorized {
+				if err := limiter.store.Refund(key, limiter.failedAuth, time.Now()); err != nil {
+					s.Handlers.log(r).Error("rate limit store failed, token not given back", "error", err)
+				}
+			}
+		}()
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+// rateLimit answers 429 to clients that ran out of tokens. Clients are told apart by API key,
+// then by user, and anonymous ones by IP, so it runs after authentication.
+func (s *HTTPServer) rateLimit(next http.Handler) http.Handler {
+	limiter := s.RateLimiter
+	if limiter == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		class, limit := "read", limiter.read
+		if !isReadOnly(r.Method) {
+			class, limit = "write", limiter.write
+		}
+
+		if limit.Requests == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		result, err := limiter.store.Take(class+":"+limiter.clientKey(r), limit, time.Now())
+		if err != nil {
+			s.Handlers.log(r).Error("rate limit store failed, request let through", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", limit.Requests, int(limit.Per.Seconds())))
+		header.Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+
+		if !result.Allowed {
+			s.Handlers.log(r).Warn("rate limit exceeded", "client", limiter.clientKey(r), "class", class, "path", r.URL.Path)
+			header.Set("Retry-After", strconv.Itoa(seconds(result.RetryAfter)))
+			httpError(w, r, errors.New("Too many requests, retry later"), http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientKey is whose bucket the request takes tokens from.
+func (limiter *RateLimiter) clientKey(r *http.Request) string {
+	if key, ok := currentAPIKey(r); ok {
+		return "key:" + strconv.Itoa(key.ID)
+	}
+
+	if user, ok := currentUser(r); ok {
+		return "user:" + user.ID.String()
+	}
+
+	return "ip:" + limiter.clientIP(r)
+}
+
+// clientIP is the address of the client. Only a trusted proxy is believed about it: X-Forwarded-For
+// is read from the right and the first address that is not a trusted proxy is the client. Without
+// trusted proxies every client behind a proxy has the address of the proxy.
+func (limiter *RateLimiter) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !limiter.trusted(addr) {
+		return host
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+		addr = hop
+		if !limiter.trusted(hop) {
+			break
+		}
+	}
+	return addr.Unmap().String()
+}
+
+func (limiter *RateLimiter) trusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, proxy := range limiter.trustedProxies {
+		if proxy.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// seconds rounds up so that a client waiting that long surely gets a token.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	fullAt  time.Time
+}
+
+// MemoryRateLimitStore keeps buckets of one instance, full buckets are forgotten since a new one is full too.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// sweepInterval is how often forgotten clients are dropped from memory.
+const sweepInterval = time.Minute
+
+// Take refills the bucket of the key and takes a token from it if there is one.
+func (s *MemoryRateLimitStore) Take(key string, limit RateLimit, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		for k, b := range s.buckets {
+			if !now.Before(b.fullAt) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	capacity := float64(limit.Requests)
+	rate := capacity / limit.Per.Seconds()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		s.buckets[key] = b
+	}
+	b.refill(capacity, rate, now)
+
+	result := RateLimitResult{}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+
+	result.Remaining = int(b.tokens)
+	result.Reset = time.Duration((capacity - b.tokens) / rate * float64(time.Second))
+	b.fullAt = now.Add(result.Reset)
+
+	return result, nil
+}
+
+// Refund puts a token back into the bucket of the key, a forgotten bucket is full already.
+func (s *MemoryRateLimitStore) Refund(key string, limit RateLimit, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		return nil
+	}
+
+	capacity := float64(limit.Requests)
+	rate := capacity / limit.Per.Seconds()
+	b.refill(capacity, rate, now)
+	b.tokens = math.Min(capacity, b.tokens+1)
+	b.fullAt = now.Add(time.Duration((capacity - b.tokens) / rate * float64(time.Second)))
+
+	return nil
+}
+
+// refill adds the tokens that appeared since the last update of the bucket.
+func (b *bucket) refill(capacity, rate float64, now time.Time) {
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+}
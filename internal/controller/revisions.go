@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testovoe/internal/entity"
+)
+
+// GET question revisions   input - query id          output - json all revisions, the last one is current
+func (h *HTTPHandler) QuestionRevisions(w http.ResponseWriter, r *http.Request) {
+	h.revisions(w, r, "question", h.question.GetRevisions)
+}
+
+// GET question revisions diff   input - query id, from, to   output - json word diff
+func (h *HTTPHandler) QuestionRevisionsDiff(w http.ResponseWriter, r *http.Request) {
+	h.revisionsDiff(w, r, "question", h.question.DiffRevisions)
+}
+
+// GET answer revisions     input - query id          output - json all revisions, the last one is current
+func (h *HTTPHandler) AnswerRevisions(w http.ResponseWriter, r *http.Request) {
+	h.revisions(w, r, "answer", h.answer.GetRevisions)
+}
+
+// GET answer revisions diff     input - query id, from, to   output - json word diff
+func (h *HTTPHandler) AnswerRevisionsDiff(w http.ResponseWriter, r *http.Request) {
+	h.revisionsDiff(w, r, "answer", h.answer.DiffRevisions)
+}
+
+func (h *HTTPHandler) revisions(w http.ResponseWriter, r *http.Request, kind string, list func(int) ([]entity.Revision, error)) {
+	id, err := pathID(r, "id")
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := list(id)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(revisions, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info(kind + " revisions listed via HTTP")
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
+
+func (h *HTTPHandler) revisionsDiff(w http.ResponseWriter, r *http.Request, kind string, diff func(int, int, int) (entity.RevisionDiff, error)) {
+	id, err := pathID(r, "id")
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	from, err := queryInt(r, "from")
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	to, err := queryInt(r, "to")
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	result, err := diff(id, from, to)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(result, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info(kind + " revisions diffed via HTTP")
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
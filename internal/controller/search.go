@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// GET search        input - query q, limit           output - json ranked questions and answers
+func (h *HTTPHandler) Search(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if s := r.URL.Query().Get("limit"); s != "" {
+		l, err := strconv.Atoi(s)
+		if err != nil {
+			httpError(w, r, errors.New("Limit must be a number"), http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+
+	results, err := h.search.Search(r.URL.Query().Get("q"), limit)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(results, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("search done via HTTP", "count", len(results.Items))
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
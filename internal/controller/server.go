@@ -1,22 +1,87 @@
 package controller
 
-import "net/http"
+import (
+	"net/http"
+	"testovoe/internal/usecase"
+)
 
 type HTTPServer struct {
 	Handlers HTTPHandler
+	// RateLimiter limits requests of every client, nil turns limiting off.
+	RateLimiter *RateLimiter
+	// Idempotency stores responses to requests with an Idempotency-Key, nil ignores the header.
+	Idempotency *usecase.IdempotencyUseCase
 }
 
-func (s *HTTPServer) Run() error {
+// Handler builds the router with all API routes registered.
+func (s *HTTPServer) Handler() http.Handler {
 	router := http.NewServeMux()
 
 	router.HandleFunc("GET /question", s.Handlers.QuestionGetAll)
 	router.HandleFunc("GET /question/{id}", s.Handlers.QuestionGetById)
 	router.HandleFunc("POST /question", s.Handlers.QuestionCreate)
+	router.HandleFunc("PATCH /question/{id}", s.Handlers.QuestionUpdate)
 	router.HandleFunc("DELETE /question/{id}", s.Handlers.QuestionDelete)
+	router.HandleFunc("POST /question/{id}/restore", s.Handlers.QuestionRestore)
+	router.HandleFunc("POST /question/{id}/vote", s.Handlers.QuestionVote)
+	router.HandleFunc("DELETE /question/{id}/vote", s.Handlers.QuestionUnvote)
+	router.HandleFunc("POST /question/{id}/accept/{answerID}", s.Handlers.QuestionAccept)
+	router.HandleFunc("DELETE /question/{id}/accept", s.Handlers.QuestionUnaccept)
+	router.HandleFunc("GET /question/{id}/revisions", s.Handlers.QuestionRevisions)
+	router.HandleFunc("GET /question/{id}/revisions/diff", s.Handlers.QuestionRevisionsDiff)
+	router.HandleFunc("GET /question/{id}/comments", s.Handlers.QuestionComments)
+	router.HandleFunc("POST /question/{id}/comments", s.Handlers.QuestionCommentCreate)
+	router.HandleFunc("POST /question/{id}/flag", s.Handlers.QuestionFlag)
 
 	router.HandleFunc("GET /answer/{id}", s.Handlers.AnswerGetById)
+	router.HandleFunc("GET /question/{id}/answers", s.Handlers.AnswerListByQuestion)
 	router.HandleFunc("POST /question/{id}/answer", s.Handlers.AnswerCreate)
+	router.HandleFunc("PATCH /answer/{id}", s.Handlers.AnswerUpdate)
 	router.HandleFunc("DELETE /answer/{id}", s.Handlers.AnswerDelete)
+	router.HandleFunc("POST /answer/{id}/restore", s.Handlers.AnswerRestore)
+	router.HandleFunc("POST /answer/{id}/vote", s.Handlers.AnswerVote)
+	router.HandleFunc("DELETE /answer/{id}/vote", s.Handlers.AnswerUnvote)
+	router.HandleFunc("GET /answer/{id}/revisions", s.Handlers.AnswerRevisions)
+	router.HandleFunc("GET /answer/{id}/revisions/diff", s.Handlers.AnswerRevisionsDiff)
+	router.HandleFunc("GET /answer/{id}/comments", s.Handlers.AnswerComments)
+	router.HandleFunc("POST /answer/{id}/comments", s.Handlers.AnswerCommentCreate)
+	router.HandleFunc("POST /answer/{id}/flag", s.Handlers.AnswerFlag)
+
+	router.HandleFunc("DELETE /comment/{id}", s.Handlers.CommentDelete)
+
+	router.HandleFunc("GET /search", s.Handlers.Search)
+
+	router.HandleFunc("POST /users", s.Handlers.UserCreate)
+	router.HandleFunc("GET /users/{id}", s.Handlers.UserGetById)
+	router.HandleFunc("PATCH /users/{id}", s.Handlers.UserUpdate)
+	router.HandleFunc("POST /users/{id}/roles/{role}", s.Handlers.UserGrantRole)
+	router.HandleFunc("DELETE /users/{id}/roles/{role}", s.Handlers.UserRevokeRole)
+
+	router.HandleFunc("POST /auth/register", s.Handlers.AuthRegister)
+	router.HandleFunc("POST /auth/login", s.Handlers.AuthLogin)
+	router.HandleFunc("POST /auth/refresh", s.Handlers.AuthRefresh)
+	router.HandleFunc("POST /auth/logout", s.Handlers.AuthLogout)
+
+	router.HandleFunc("POST /api-keys", s.Handlers.APIKeyCreate)
+	router.HandleFunc("GET /api-keys", s.Handlers.APIKeyList)
+	router.HandleFunc("DELETE /api-keys/{id}", s.Handlers.APIKeyRevoke)
 
-	return http.ListenAndServe(":8080", router)
+	router.HandleFunc("GET /tags", s.Handlers.TagList)
+	router.HandleFunc("GET /tags/{name}/questions", s.Handlers.TagQuestions)
+
+	router.HandleFunc("GET /trash", s.Handlers.TrashList)
+
+	router.HandleFunc("GET /moderation/held", s.Handlers.ModerationHeldList)
+	router.HandleFunc("POST /moderation/held/{id}/approve", s.Handlers.ModerationHeldApprove)
+	router.HandleFunc("DELETE /moderation/held/{id}", s.Handlers.ModerationHeldReject)
+	router.HandleFunc("GET /moderation/flags", s.Handlers.ModerationFlagList)
+	router.HandleFunc("POST /moderation/flags/{id}/decision", s.Handlers.ModerationFlagDecide)
+	router.HandleFunc("GET /moderation/decisions", s.Handlers.ModerationDecisionList)
+
+	api := s.authenticate(router, s.rateLimit(s.idempotent(router, s.conditional(router))))
+	return s.withRequestScope(s.accessLog(router, s.recoverPanic(s.limitFailedAuth(api))))
+}
+
+func (s *HTTPServer) Run() error {
+	return http.ListenAndServe(":8080", s.Handler())
 }
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testovoe/internal/entity"
+)
+
+// POST register            input - json with display name, email and password   output - json tokens and user
+func (h *HTTPHandler) AuthRegister(w http.ResponseWriter, r *http.Request) {
+	issueTokens(h, w, r, http.StatusCreated, h.auth.Register)
+}
+
+// POST login               input - json with email and password   output - json tokens and user
+func (h *HTTPHandler) AuthLogin(w http.ResponseWriter, r *http.Request) {
+	issueTokens(h, w, r, http.StatusOK, h.auth.Login)
+}
+
+// POST refresh             input - json with refresh token        output - json new tokens and user
+func (h *HTTPHandler) AuthRefresh(w http.ResponseWriter, r *http.Request) {
+	issueTokens(h, w, r, http.StatusOK, h.auth.Refresh)
+}
+
+// POST logout              input - json with refresh token        output - 204
+func (h *HTTPHandler) AuthLogout(w http.ResponseWriter, r *http.Request) {
+	refreshDTO := entity.RefreshDto{}
+
+	err := json.NewDecoder(r.Body).Decode(&refreshDTO)
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	err = h.auth.Logout(refreshDTO)
+
+	h.log(r).Info("logged out via HTTP")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// issueTokens decodes the body into the dto of the endpoint and answers with a new token pair.
+func issueTokens[T any](h *HTTPHandler, w http.ResponseWriter, r *http.Request, status int, issue func(T) (entity.TokenPair, error)) {
+	var dto T
+
+	err := json.NewDecoder(r.Body).Decode(&dto)
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := issue(dto)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(tokens, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("tokens issued via HTTP", "user_id", tokens.User.ID)
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// GET tags                 input - query cursor, limit          output - json page of tags with question counts
+func (h *HTTPHandler) TagList(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageRequest(r)
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	tags, err := h.tags.List(page)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(tags, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("tags listed via HTTP")
+
+	setNextLink(w, r, tags.NextCursor)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
+
+// GET questions of tag     input - query name, question list filters   output - json page of questions
+func (h *HTTPHandler) TagQuestions(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseQuestionFilter(r)
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	filter.Tags = append(filter.Tags, r.PathValue("name"))
+
+	questions, err := h.question.List(filter)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(questions, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("tag questions listed via HTTP")
+
+	setNextLink(w, r, questions.NextCursor)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testovoe/internal/entity"
+)
+
+// POST restore question    input - query id         output - json restored question
+func (h *HTTPHandler) QuestionRestore(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, _ := currentUser(r)
+	question, err := h.question.Restore(user, id)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(question, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("question restored via HTTP")
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
+
+// POST restore answer      input - query id         output - json restored answer
+func (h *HTTPHandler) AnswerRestore(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, _ := currentUser(r)
+	answer, err := h.answer.Restore(user, id)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(answer, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("answer restored via HTTP")
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
+
+// GET trash (moderator)       input - query type, cursor, limit   output - json page of deleted questions or answers
+func (h *HTTPHandler) TrashList(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageRequest(r)
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, _ := currentUser(r)
+
+	var (
+		items      interface{}
+		nextCursor string
+	)
+
+	switch r.URL.Query().Get("type") {
+	case "", entity.TrashQuestions:
+		questions, err := h.trash.ListQuestions(user, page)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		items, nextCursor = questions, questions.NextCursor
+	case entity.TrashAnswers:
+		answers, err := h.trash.ListAnswers(user, page)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+		items, nextCursor = answers, answers.NextCursor
+	default:
+		httpError(w, r, errors.New("Type must be question or answer"), http.StatusBadRequest)
+		return
+	}
+
+	b, err := json.MarshalIndent(items, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("trash listed via HTTP")
+
+	setNextLink(w, r, nextCursor)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
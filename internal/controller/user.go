@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testovoe/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+// POST user                input - json with display name and email   output - json created user
+func (h *HTTPHandler) UserCreate(w http.ResponseWriter, r *http.Request) {
+	userDTO := entity.UserDto{}
+
+	err := json.NewDecoder(r.Body).Decode(&userDTO)
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.users.Register(userDTO)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(user, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("user created via HTTP")
+
+	w.WriteHeader(http.StatusCreated)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
+
+// GET user                 input - query id             output - json user profile, only id and name for others
+func (h *HTTPHandler) UserGetById(w http.ResponseWriter, r *http.Request) {
+	id, err := pathUUID(r, "id")
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	actor, _ := currentUser(r)
+	user, err := h.users.Profile(actor, id)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(user, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("user getet via HTTP")
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
+
+// PATCH user               input - query id, json with new name or email   output - json updated user
+func (h *HTTPHandler) UserUpdate(w http.ResponseWriter, r *http.Request) {
+	id, err := pathUUID(r, "id")
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	updateDTO := entity.UserUpdateDto{}
+
+	err = json.NewDecoder(r.Body).Decode(&updateDTO)
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	actor, _ := currentUser(r)
+	user, err := h.users.Update(actor, id, updateDTO)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(user, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("user updated via HTTP")
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
+
+// POST user role (admin)   input - query id, role       output - json user with the role
+func (h *HTTPHandler) UserGrantRole(w http.ResponseWriter, r *http.Request) {
+	h.role(w, r, h.users.GrantRole)
+}
+
+// DELETE user role (admin) input - query id, role       output - json user without the role
+func (h *HTTPHandler) UserRevokeRole(w http.ResponseWriter, r *http.Request) {
+	h.role(w, r, h.users.RevokeRole)
+}
+
+func (h *HTTPHandler) role(w http.ResponseWriter, r *http.Request, change func(entity.User, uuid.UUID, string) (entity.User, error)) {
+	id, err := pathUUID(r, "id")
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	actor, _ := currentUser(r)
+	user, err := change(actor, id, r.PathValue("role"))
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(user, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info("user role changed via HTTP")
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testovoe/internal/entity"
+)
+
+// POST vote for question   input - query id, json with value (1 or -1)  output - json score
+func (h *HTTPHandler) QuestionVote(w http.ResponseWriter, r *http.Request) {
+	h.vote(w, r, "question", h.votes.VoteQuestion)
+}
+
+// DELETE vote for question input - query id             output - json score
+func (h *HTTPHandler) QuestionUnvote(w http.ResponseWriter, r *http.Request) {
+	h.vote(w, r, "question", func(id int, dto entity.VoteDto) (entity.VoteResult, error) {
+		return h.votes.RetractQuestion(id, dto.UserID)
+	})
+}
+
+// POST vote for answer     input - query id, json with value (1 or -1)  output - json score
+func (h *HTTPHandler) AnswerVote(w http.ResponseWriter, r *http.Request) {
+	h.vote(w, r, "answer", h.votes.VoteAnswer)
+}
+
+// DELETE vote for answer   input - query id             output - json score
+func (h *HTTPHandler) AnswerUnvote(w http.ResponseWriter, r *http.Request) {
+	h.vote(w, r, "answer", func(id int, dto entity.VoteDto) (entity.VoteResult, error) {
+		return h.votes.RetractAnswer(id, dto.UserID)
+	})
+}
+
+func (h *HTTPHandler) vote(w http.ResponseWriter, r *http.Request, kind string, vote func(int, entity.VoteDto) (entity.VoteResult, error)) {
+	id, err := pathID(r, "id")
+
+	if err != nil {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	voteDTO := entity.VoteDto{}
+
+	err = json.NewDecoder(r.Body).Decode(&voteDTO)
+
+	if err != nil && err != io.EOF {
+		httpError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	voteDTO.UserID = currentUserID(r)
+
+	result, err := vote(id, voteDTO)
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	b, err := json.MarshalIndent(result, "", "    ")
+
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	h.log(r).Info(kind + " vote changed via HTTP")
+
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(b); err != nil {
+		h.log(r).Warn("failed to write response", "error", err)
+	}
+}
@@ -7,14 +7,38 @@ import (
 )
 
 type Answer struct {
-	ID         int       `json:"id"`
-	QuestionID int       `json:"question_id"`
-	UserID     uuid.UUID `json:"user_id"`
-	Text       string    `json:"text"`
-	CreatedAt  time.Time `json:"created_at"`
+	ID         int          `json:"id"`
+	QuestionID int          `json:"question_id"`
+	UserID     uuid.UUID    `json:"user_id"`
+	Author     *UserSummary `json:"author,omitempty"`
+	Text       string       `json:"text"`
+	Score      int          `json:"score"`
+	IsAccepted bool         `json:"is_accepted"`
+	Version    int          `json:"version"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  *time.Time   `json:"updated_at"`
+	DeletedAt  *time.Time   `json:"deleted_at,omitempty"`
+	HiddenAt   *time.Time   `json:"hidden_at,omitempty"`
+	LockedAt   *time.Time   `json:"locked_at,omitempty"`
+	// ChangedAt is the last change of the answer, its votes or comments, sent as Last-Modified.
+	ChangedAt time.Time `json:"-"`
 }
 
 type AnswerDto struct {
-	UserID uuid.UUID `json:"user_id"`
+	UserID uuid.UUID `json:"-"`
 	Text   string    `json:"text"`
 }
+
+// AnswerUpdateDto is a PATCH body, nil fields are left as they are.
+// Versions are the ones the client accepts, taken from If-Match, nil skips the check.
+type AnswerUpdateDto struct {
+	Text     *string `json:"text"`
+	Versions []int   `json:"-"`
+}
+
+type AnswerSort string
+
+const (
+	AnswerSortOldest AnswerSort = "oldest"
+	AnswerSortScore  AnswerSort = "score"
+)
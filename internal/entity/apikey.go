@@ -0,0 +1,49 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scopes limit what a request signed with an API key may do, admin allows everything.
+const (
+	ScopeQuestionsRead  = "questions:read"
+	ScopeQuestionsWrite = "questions:write"
+	ScopeAnswersWrite   = "answers:write"
+	ScopeAdmin          = "admin"
+)
+
+// APIKey belongs to a user and acts on their behalf within its scopes.
+// Only the prefix of the key is kept in clear text so that users can tell their keys apart.
+type APIKey struct {
+	ID         int        `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+type APIKeyDto struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// CreatedAPIKey is the only response that contains the key itself.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
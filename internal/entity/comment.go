@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	CommentTargetQuestion = "question"
+	CommentTargetAnswer   = "answer"
+)
+
+// Comment is a short clarifying note attached to a question or an answer, it is not an answer itself.
+type Comment struct {
+	ID         int       `json:"id"`
+	TargetType string    `json:"target_type"`
+	TargetID   int       `json:"target_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	Text       string    `json:"text"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CommentDto struct {
+	UserID uuid.UUID `json:"-"`
+	Text   string    `json:"text"`
+}
+
+// AnswerWithComments is an answer on a question page together with its comments.
+type AnswerWithComments struct {
+	Answer
+	Comments []Comment `json:"comments"`
+}
@@ -0,0 +1,72 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FlagTargetQuestion = "question"
+	FlagTargetAnswer   = "answer"
+)
+
+// Reasons a user may give for flagging a post.
+const (
+	FlagSpam       = "spam"
+	FlagOffensive  = "offensive"
+	FlagOffTopic   = "off_topic"
+	FlagLowQuality = "low_quality"
+	FlagOther      = "other"
+)
+
+// Statuses of a flag, a flag stays pending until a moderator decides on its post.
+const (
+	FlagPending   = "pending"
+	FlagDismissed = "dismissed"
+	FlagActioned  = "actioned"
+)
+
+// Actions of moderation decisions. Hide takes a post out of lists and pages, lock stops edits,
+// answers and comments, delete moves it to the trash, dismiss clears the flags and shows a hidden post again.
+const (
+	ActionHide    = "hide"
+	ActionLock    = "lock"
+	ActionDelete  = "delete"
+	ActionDismiss = "dismiss"
+)
+
+// Flag is a report of one user about a question or an answer, a user flags a post once.
+type Flag struct {
+	ID         int        `json:"id"`
+	TargetType string     `json:"target_type"`
+	TargetID   int        `json:"target_id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Reason     string     `json:"reason"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+type FlagDto struct {
+	UserID uuid.UUID `json:"-"`
+	Reason string    `json:"reason"`
+}
+
+// ModerationDecision records what was done with a flagged post and who did it,
+// ModeratorID is nil for posts hidden automatically.
+type ModerationDecision struct {
+	ID          int        `json:"id"`
+	TargetType  string     `json:"target_type"`
+	TargetID    int        `json:"target_id"`
+	ModeratorID *uuid.UUID `json:"moderator_id"`
+	Action      string     `json:"action"`
+	Note        string     `json:"note"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// DecisionDto is the body of a moderator action on a flag.
+type DecisionDto struct {
+	Action string `json:"action"`
+	Note   string `json:"note"`
+}
@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyRecord remembers the response to a request sent with an Idempotency-Key header.
+// Status is zero while the first request is still being handled.
+type IdempotencyRecord struct {
+	UserID      uuid.UUID
+	Key         string
+	Fingerprint string
+	Status      int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Completed tells whether the response is stored and can be replayed.
+func (r IdempotencyRecord) Completed() bool {
+	return r.Status != 0
+}
@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ContentQuestion = "question"
+	ContentAnswer   = "answer"
+)
+
+// Actions a content policy may take.
+const (
+	PolicyAllow  = "allow"
+	PolicyReject = "reject"
+	PolicyHold   = "hold"
+)
+
+// Content is a new or edited question or answer that content policies look at before it is published.
+type Content struct {
+	Kind   string    `json:"kind"`
+	UserID uuid.UUID `json:"user_id"`
+	Text   string    `json:"text"`
+	Tags   []string  `json:"tags,omitempty"`
+	// Edit tells an edit of a post from a new post.
+	Edit bool `json:"edit"`
+}
+
+// PolicyDecision is what a content policy decided, Policy and Reason explain rejections and holds.
+type PolicyDecision struct {
+	Action string
+	Policy string
+	Reason string
+}
+
+// HeldContent is content a policy held back, it is published when a moderator approves it.
+type HeldContent struct {
+	ID int `json:"id"`
+	Content
+	// QuestionID is the question a held answer is for.
+	QuestionID *int `json:"question_id,omitempty"`
+	// PostID is the edited post, for a new post it is set once the post is approved.
+	PostID *int `json:"post_id,omitempty"`
+	// Version is the version of the post the edit was made to, the post must still have it on approval.
+	Version   *int      `json:"version,omitempty"`
+	Policy    string    `json:"policy"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
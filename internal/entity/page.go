@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// Cursor points at the last row of a page: its sort key (Time or Count, depending on the order) and id as a tie-breaker.
+// Sort is the order the cursor was made for, empty for lists that have only one.
+type Cursor struct {
+	Sort  string
+	Time  time.Time
+	Count int
+	ID    int
+}
+
+// PageRequest asks for up to Limit rows strictly after the cursor, or from the start when After is nil.
+type PageRequest struct {
+	After *Cursor
+	Limit int
+}
+
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
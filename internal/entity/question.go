@@ -7,13 +7,73 @@ import (
 )
 
 type Question struct {
-	ID        int       `json:"id"`
-	UserID    uuid.UUID `json:"user_id"`
-	Text      string    `json:"text"`
-	CreatedAt time.Time `json:"created_at"`
+	ID               int          `json:"id"`
+	UserID           uuid.UUID    `json:"user_id"`
+	Author           *UserSummary `json:"author,omitempty"`
+	Text             string       `json:"text"`
+	AnswerCount      int          `json:"answer_count"`
+	Score            int          `json:"score"`
+	AcceptedAnswerID *int         `json:"accepted_answer_id"`
+	Tags             []string     `json:"tags"`
+	Version          int          `json:"version"`
+	LastActivityAt   time.Time    `json:"last_activity_at"`
+	CreatedAt        time.Time    `json:"created_at"`
+	UpdatedAt        *time.Time   `json:"updated_at"`
+	DeletedAt        *time.Time   `json:"deleted_at,omitempty"`
+	HiddenAt         *time.Time   `json:"hidden_at,omitempty"`
+	LockedAt         *time.Time   `json:"locked_at,omitempty"`
+	// ChangedAt is the last change of anything on the page of the question, sent as Last-Modified.
+	ChangedAt time.Time `json:"-"`
 }
 
+// QuestionDto is the body of a new question, UserID is filled from the authenticated user, not from JSON.
 type QuestionDto struct {
-	UserID uuid.UUID `json:"user_id"`
+	UserID uuid.UUID `json:"-"`
 	Text   string    `json:"text"`
+	Tags   []string  `json:"tags"`
+}
+
+// QuestionUpdateDto is a PATCH body, nil fields are left as they are.
+// Versions are the ones the client accepts, taken from If-Match, nil skips the check.
+type QuestionUpdateDto struct {
+	Text     *string `json:"text"`
+	Versions []int   `json:"-"`
+}
+
+// AcceptDto names who accepts or unaccepts an answer, only the author of the question may do it.
+// It has no body, UserID is the authenticated user.
+type AcceptDto struct {
+	UserID uuid.UUID `json:"-"`
+}
+
+// QuestionWithAnswers is a question page: the question itself with its comments
+// and the first page of its answers ordered by creation time, each with its comments.
+type QuestionWithAnswers struct {
+	Question
+	Comments          []Comment            `json:"comments"`
+	Answers           []AnswerWithComments `json:"answers"`
+	AnswersNextCursor string               `json:"answers_next_cursor,omitempty"`
+}
+
+type QuestionSort string
+
+const (
+	SortNewest         QuestionSort = "newest"
+	SortOldest         QuestionSort = "oldest"
+	SortMostAnswered   QuestionSort = "most_answered"
+	SortRecentActivity QuestionSort = "recent_activity"
+)
+
+// QuestionFilter narrows and orders the question list. Nil and zero fields are not applied,
+// a question must carry every one of Tags.
+type QuestionFilter struct {
+	UserID        *uuid.UUID
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Answered      *bool
+	Accepted      *bool
+	Tags          []string
+	MinAnswers    int
+	Sort          QuestionSort
+	Page          PageRequest
 }
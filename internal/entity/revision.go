@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// Revision is one version of question or answer text. Revisions are numbered from 1, the last one is the current text.
+type Revision struct {
+	Revision  int       `json:"revision"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+type DiffChange struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// RevisionDiff shows what changed in text from one revision to another, word by word.
+type RevisionDiff struct {
+	From    int          `json:"from"`
+	To      int          `json:"to"`
+	Changes []DiffChange `json:"changes"`
+}
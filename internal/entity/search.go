@@ -0,0 +1,16 @@
+package entity
+
+const (
+	SearchTypeQuestion = "question"
+	SearchTypeAnswer   = "answer"
+)
+
+// SearchResult is one hit of full-text search. Snippet is HTML: the text is escaped and matched
+// words are wrapped in <mark></mark>.
+type SearchResult struct {
+	Type       string  `json:"type"`
+	ID         int     `json:"id"`
+	QuestionID int     `json:"question_id"`
+	Snippet    string  `json:"snippet"`
+	Rank       float64 `json:"rank"`
+}
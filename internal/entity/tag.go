@@ -0,0 +1,8 @@
+package entity
+
+// Tag is a question category with the number of live questions carrying it.
+type Tag struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	QuestionCount int    `json:"question_count"`
+}
@@ -0,0 +1,12 @@
+package entity
+
+const (
+	TrashQuestions = "question"
+	TrashAnswers   = "answer"
+)
+
+// PurgeResult counts rows removed permanently from the trash.
+type PurgeResult struct {
+	Questions int64 `json:"questions"`
+	Answers   int64 `json:"answers"`
+}
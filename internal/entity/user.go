@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Roles of users: moderators may change any content, admins also manage roles of other users.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+type User struct {
+	ID          uuid.UUID `json:"id"`
+	DisplayName string    `json:"display_name"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	// PasswordHash is empty for users registered without a password, they can not log in.
+	PasswordHash string     `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at"`
+}
+
+type UserDto struct {
+	DisplayName string `json:"display_name"`
+	Email       string `json:"email"`
+}
+
+// UserUpdateDto is a PATCH body, nil fields are left as they are.
+type UserUpdateDto struct {
+	DisplayName *string `json:"display_name"`
+	Email       *string `json:"email"`
+}
+
+// UserSummary is the author shown next to questions and answers, without private data.
+type UserSummary struct {
+	ID          uuid.UUID `json:"id"`
+	DisplayName string    `json:"display_name"`
+}
+
+func (u User) Summary() *UserSummary {
+	return &UserSummary{ID: u.ID, DisplayName: u.DisplayName}
+}
+
+// IsModerator is true for moderators and admins.
+func (u User) IsModerator() bool {
+	return u.Role == RoleModerator || u.Role == RoleAdmin
+}
+
+func (u User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
@@ -0,0 +1,32 @@
+package entity
+
+import "github.com/google/uuid"
+
+const (
+	VoteTargetQuestion = "question"
+	VoteTargetAnswer   = "answer"
+)
+
+const (
+	VoteUp   = 1
+	VoteDown = -1
+)
+
+// Vote is the only vote of a user for a question or an answer.
+type Vote struct {
+	UserID     uuid.UUID
+	TargetType string
+	TargetID   int
+	Value      int
+}
+
+type VoteDto struct {
+	UserID uuid.UUID `json:"-"`
+	Value  int       `json:"value"`
+}
+
+// VoteResult is the score of the target after voting and the vote of the user, 0 when retracted.
+type VoteResult struct {
+	Score int `json:"score"`
+	Vote  int `json:"vote"`
+}
@@ -0,0 +1,249 @@
+package pkg
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"testovoe/internal/entity"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minJWKSRefresh limits refetching when tokens come with a kid the provider does not know,
+// so forged tokens can not make us hammer the provider.
+const minJWKSRefresh = time.Minute
+
+// OIDCVerifier checks RS256 and ES256 tokens of an OpenID Connect provider
+// against its JWKS, which is cached and refetched when it gets old or a new kid appears.
+// Only one fetch runs at a time and no lock is held during it, so tokens with cached keys
+// are checked while the provider is slow.
+type OIDCVerifier struct {
+	jwksURL  string
+	issuer   string
+	audience string
+	cacheTTL time.Duration
+	client   *http.Client
+
+	mu          sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	// refreshing is closed when the running fetch ends, nil when none runs.
+	refreshing chan struct{}
+	fetchErr   error
+}
+
+func NewOIDCVerifier(jwksURL, issuer, audience string, cacheTTL time.Duration, client *http.Client) *OIDCVerifier {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &OIDCVerifier{
+		jwksURL:  jwksURL,
+		issuer:   issuer,
+		audience: audience,
+		cacheTTL: cacheTTL,
+		client:   client,
+	}
+}
+
+type oidcClaims struct {
+	jwt.RegisteredClaims
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+// VerifyIdentity checks the signature, issuer, audience and expiry of the token.
+func (v *OIDCVerifier) VerifyIdentity(token string) (entity.ExternalIdentity, error) {
+	var claims oidcClaims
+	_, err := jwt.ParseWithClaims(token, &claims, v.keyFor,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}),
+		jwt.WithIssuer(v.issuer),
+		jwt.WithAudience(v.audience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return entity.ExternalIdentity{}, err
+	}
+
+	if claims.Subject == "" {
+		return entity.ExternalIdentity{}, errors.New("token has no subject")
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = claims.PreferredUsername
+	}
+
+	return entity.ExternalIdentity{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          name,
+	}, nil
+}
+
+func (v *OIDCVerifier) keyFor(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token has no kid")
+	}
+
+	v.mu.RLock()
+	key, ok := v.keys[kid]
+	stale := time.Since(v.fetchedAt) > v.cacheTTL
+	v.mu.RUnlock()
+
+	if ok {
+		// an old key is still better than waiting for the provider or failing while it is down
+		if stale {
+			go v.refresh()
+		}
+		return key, nil
+	}
+
+	err := v.refresh()
+
+	v.mu.RLock()
+	key, ok = v.keys[kid]
+	v.mu.RUnlock()
+	if ok {
+		return key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("unknown kid %q", kid)
+}
+
+// refresh fetches the keys unless a fetch was tried too recently. Callers that come while
+// a fetch runs wait for it and get its error instead of starting another one.
+func (v *OIDCVerifier) refresh() error {
+	v.mu.Lock()
+	if running := v.refreshing; running != nil {
+		v.mu.Unlock()
+		<-running
+		v.mu.RLock()
+		defer v.mu.RUnlock()
+		return v.fetchErr
+	}
+	if time.Since(v.attemptedAt) < min(minJWKSRefresh, v.cacheTTL) {
+		v.mu.Unlock()
+		return nil
+	}
+	done := make(chan struct{})
+	v.refreshing = done
+	v.attemptedAt = time.Now()
+	v.mu.Unlock()
+
+	keys, err := v.fetch()
+
+	v.mu.Lock()
+	if err == nil {
+		v.keys = keys
+		v.fetchedAt = time.Now()
+	}
+	v.fetchErr = err
+	v.refreshing = nil
+	v.mu.Unlock()
+	close(done)
+
+	return err
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetch downloads the signing keys of the provider, it is called without the lock.
+func (v *OIDCVerifier) fetch() (map[string]crypto.PublicKey, error) {
+	resp, err := v.client.Get(v.jwksURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS endpoint answered %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kid == "" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("curve %s is not supported", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if _, err := key.ECDH(); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("key type %s is not supported", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
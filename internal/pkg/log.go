@@ -50,3 +50,22 @@ func (l *ZapLogger) WithFields(fields map[string]interface{}) Logger {
 func (l *ZapLogger) Sync() error {
 	return l.logger.Sync()
 }
+
+// NopLogger discards everything, used when no logger is provided.
+type NopLogger struct{}
+
+func NewNopLogger() Logger {
+	return NopLogger{}
+}
+
+func (NopLogger) Debug(msg string, fields ...interface{}) {}
+
+func (NopLogger) Info(msg string, fields ...interface{}) {}
+
+func (NopLogger) Warn(msg string, fields ...interface{}) {}
+
+func (NopLogger) Error(msg string, fields ...interface{}) {}
+
+func (l NopLogger) WithFields(fields map[string]interface{}) Logger {
+	return l
+}
@@ -0,0 +1,53 @@
+package pkg
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// HMACTokens issues and verifies HS256 JWTs whose subject is a user id.
+type HMACTokens struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewHMACTokens(secret []byte, ttl time.Duration) *HMACTokens {
+	return &HMACTokens{secret: secret, ttl: ttl}
+}
+
+// Issue signs a token for the user that expires after the configured ttl.
+func (t *HMACTokens) Issue(userID uuid.UUID) (string, error) {
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		Subject:   userID.String(),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(t.ttl)),
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(t.secret)
+}
+
+func (t *HMACTokens) TTL() time.Duration {
+	return t.ttl
+}
+
+// Verify checks the signature and expiry of the token and returns the user it was issued to.
+func (t *HMACTokens) Verify(token string) (uuid.UUID, error) {
+	var claims jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return t.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, errors.New("token subject is not a user id")
+	}
+
+	return userID, nil
+}
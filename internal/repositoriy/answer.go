@@ -4,8 +4,10 @@ import (
 	"testovoe/internal/entity"
 	"testovoe/internal/pkg"
 	"testovoe/internal/usecase"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type GormAnswerRepository struct {
@@ -14,6 +16,9 @@ type GormAnswerRepository struct {
 }
 
 func NewGormAnswerRepository(db *gorm.DB, logger pkg.Logger) usecase.AnswerRepositoriy {
+	if logger == nil {
+		logger = pkg.NewNopLogger()
+	}
 	return &GormAnswerRepository{
 		db:     db,
 		logger: logger.WithFields(map[string]interface{}{"component": "answer_repository"}),
@@ -24,20 +29,49 @@ func (r *GormAnswerRepository) GetByID(id int) (entity.Answer, error) {
 	r.logger.Debug("getting answer by ID", "answer_id", id)
 
 	var gormAnswer Answer
-	result := r.db.First(&gormAnswer, id)
+	result := r.db.Preload("Author").First(&gormAnswer, id)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
 			r.logger.Warn("answer not found", "answer_id", id)
-			return entity.Answer{}, result.Error
+			return entity.Answer{}, translate(result.Error, "answer")
 		}
 		r.logger.Error("failed to get answer", "answer_id", id, "error", result.Error)
-		return entity.Answer{}, result.Error
+		return entity.Answer{}, translate(result.Error, "answer")
 	}
 
 	r.logger.Debug("answer found", "answer_id", id)
 	return r.toEntity(gormAnswer), nil
 }
 
+var answerOrders = map[entity.AnswerSort]keysetOrder{
+	entity.AnswerSortOldest: byTime("created_at", false),
+	entity.AnswerSortScore:  byCount("score", true),
+}
+
+func (r *GormAnswerRepository) ListByQuestion(questionID int, sort entity.AnswerSort, page entity.PageRequest) ([]entity.Answer, error) {
+	r.logger.Debug("listing answers of question", "question_id", questionID, "sort", sort, "limit", page.Limit)
+
+	order, ok := answerOrders[sort]
+	if !ok {
+		order = answerOrders[entity.AnswerSortOldest]
+	}
+
+	var gormAnswers []Answer
+	result := r.db.Preload("Author").Where("question_id = ? AND hidden_at IS NULL", questionID).Scopes(paginate("answers", order, page)).Find(&gormAnswers)
+	if result.Error != nil {
+		r.logger.Error("failed to list answers", "question_id", questionID, "error", result.Error)
+		return nil, translate(result.Error, "answer")
+	}
+
+	answers := make([]entity.Answer, len(gormAnswers))
+	for i, ga := range gormAnswers {
+		answers[i] = r.toEntity(ga)
+	}
+
+	r.logger.Debug("retrieved answers", "question_id", questionID, "count", len(answers))
+	return answers, nil
+}
+
 func (r *GormAnswerRepository) Save(answer entity.Answer) (entity.Answer, error) {
 	r.logger.Debug("saving answer",
 		"question_id", answer.QuestionID,
@@ -45,11 +79,30 @@ func (r *GormAnswerRepository) Save(answer entity.Answer) (entity.Answer, error)
 		"text_length", len(answer.Text))
 
 	gormAnswer := r.toGormModel(answer)
+	if gormAnswer.CreatedAt.IsZero() {
+		gormAnswer.CreatedAt = time.Now()
+	}
+	if gormAnswer.Version == 0 {
+		gormAnswer.Version = 1
+	}
+	if gormAnswer.ChangedAt.IsZero() {
+		gormAnswer.ChangedAt = gormAnswer.CreatedAt
+	}
 
-	result := r.db.Create(&gormAnswer)
-	if result.Error != nil {
-		r.logger.Error("failed to save answer", "error", result.Error, "question_id", answer.QuestionID)
-		return entity.Answer{}, result.Error
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&gormAnswer).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&Question{}).Where("id = ?", gormAnswer.QuestionID).Updates(map[string]interface{}{
+			"answer_count":     gorm.Expr("answer_count + 1"),
+			"last_activity_at": gormAnswer.CreatedAt,
+			"changed_at":       gormAnswer.CreatedAt,
+		}).Error
+	})
+	if err != nil {
+		r.logger.Error("failed to save answer", "error", err, "question_id", answer.QuestionID)
+		return entity.Answer{}, translate(err, "answer")
 	}
 
 	savedAnswer := r.toEntity(gormAnswer)
@@ -60,31 +113,306 @@ func (r *GormAnswerRepository) Save(answer entity.Answer) (entity.Answer, error)
 	return savedAnswer, nil
 }
 
-func (r *GormAnswerRepository) Delete(id int) error {
-	r.logger.Debug("deleting answer", "answer_id", id)
+func (r *GormAnswerRepository) Update(answer entity.Answer) (entity.Answer, error) {
+	r.logger.Debug("updating answer", "answer_id", answer.ID, "text_length", len(answer.Text))
+
+	var gormAnswer Answer
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Author").First(&gormAnswer, answer.ID).Error; err != nil {
+			return err
+		}
+		if gormAnswer.Version != answer.Version {
+			return usecase.ErrVersionMismatch
+		}
+
+		var count int64
+		if err := tx.Model(&AnswerRevision{}).Where("answer_id = ?", answer.ID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		revision := AnswerRevision{
+			AnswerID:  answer.ID,
+			Revision:  int(count) + 1,
+			Text:      gormAnswer.Text,
+			CreatedAt: writtenAt(gormAnswer.CreatedAt, gormAnswer.UpdatedAt),
+		}
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+
+		gormAnswer.Text = answer.Text
+		gormAnswer.UpdatedAt = answer.UpdatedAt
+		gormAnswer.ChangedAt = time.Now()
+		gormAnswer.Version++
+		if err := tx.Model(&gormAnswer).Select("text", "updated_at", "changed_at", "version").Updates(&gormAnswer).Error; err != nil {
+			return err
+		}
+		return touchQuestion(tx, gormAnswer.QuestionID, gormAnswer.ChangedAt)
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.logger.Warn("answer not found for update", "answer_id", answer.ID)
+			return entity.Answer{}, translate(err, "answer")
+		}
+		if err == usecase.ErrVersionMismatch {
+			r.logger.Warn("answer changed concurrently", "answer_id", answer.ID, "version", answer.Version)
+			return entity.Answer{}, translate(err, "answer")
+		}
+		r.logger.Error("failed to update answer", "answer_id", answer.ID, "error", err)
+		return entity.Answer{}, translate(err, "answer")
+	}
+
+	r.logger.Info("answer updated successfully", "answer_id", answer.ID)
+	return r.toEntity(gormAnswer), nil
+}
+
+func (r *GormAnswerRepository) ListRevisions(id int) ([]entity.Revision, error) {
+	r.logger.Debug("listing answer revisions", "answer_id", id)
 
-	result := r.db.Delete(&Answer{}, id)
+	var gormRevisions []AnswerRevision
+	result := r.db.Where("answer_id = ?", id).Order("revision").Find(&gormRevisions)
 	if result.Error != nil {
-		r.logger.Error("failed to delete answer", "answer_id", id, "error", result.Error)
-		return result.Error
+		r.logger.Error("failed to list answer revisions", "answer_id", id, "error", result.Error)
+		return nil, translate(result.Error, "answer")
 	}
 
-	if result.RowsAffected == 0 {
-		r.logger.Warn("answer not found for deletion", "answer_id", id)
-		return gorm.ErrRecordNotFound
+	return answerRevisionsToEntity(gormRevisions), nil
+}
+
+// Delete moves the answer to the trash, the answer must still have the given version.
+func (r *GormAnswerRepository) Delete(id int, version int) error {
+	r.logger.Debug("deleting answer", "answer_id", id)
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return trashAnswer(tx, id, &version)
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.logger.Warn("answer not found for deletion", "answer_id", id)
+			return translate(err, "answer")
+		}
+		if err == usecase.ErrVersionMismatch {
+			r.logger.Warn("answer changed concurrently", "answer_id", id, "version", version)
+			return translate(err, "answer")
+		}
+		r.logger.Error("failed to delete answer", "answer_id", id, "error", err)
+		return translate(err, "answer")
 	}
 
 	r.logger.Info("answer deleted successfully", "answer_id", id)
 	return nil
 }
 
+// trashAnswer moves the answer to the trash and takes it off the counts of its question.
+// A nil version deletes whatever version is current.
+func trashAnswer(tx *gorm.DB, id int, version *int) error {
+	var gormAnswer Answer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&gormAnswer, id).Error; err != nil {
+		return err
+	}
+	if version != nil && gormAnswer.Version != *version {
+		return usecase.ErrVersionMismatch
+	}
+
+	now := time.Now()
+	err := tx.Model(&Answer{}).Where("id = ?", id).Updates(map[string]interface{}{"is_accepted": false, "changed_at": now}).Error
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Delete(&Answer{}, id).Error; err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{"answer_count": gorm.Expr("answer_count - 1"), "changed_at": now}
+	if gormAnswer.IsAccepted {
+		updates["accepted_answer_id"] = nil
+	}
+	return tx.Model(&Question{}).Where("id = ?", gormAnswer.QuestionID).Updates(updates).Error
+}
+
+// touchAnswer moves changed_at of the answer and of its question, whose page shows the answer.
+// A missing answer has no page to change.
+func touchAnswer(tx *gorm.DB, id int, at time.Time) error {
+	var gormAnswer Answer
+	result := tx.Unscoped().Select("question_id").Where("id = ?", id).Limit(1).Find(&gormAnswer)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.Error
+	}
+
+	if err := tx.Unscoped().Model(&Answer{}).Where("id = ?", id).Update("changed_at", at).Error; err != nil {
+		return err
+	}
+	return touchQuestion(tx, gormAnswer.QuestionID, at)
+}
+
+// Accept marks the answer as accepted for its question, nil answerID unaccepts whatever was accepted.
+func (r *GormAnswerRepository) Accept(questionID int, answerID *int) error {
+	r.logger.Debug("accepting answer", "question_id", questionID, "answer_id", answerID)
+
+	now := time.Now()
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Question{}).Where("id = ?", questionID).
+			Updates(map[string]interface{}{"accepted_answer_id": answerID, "changed_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		err := tx.Model(&Answer{}).Where("question_id = ? AND is_accepted", questionID).
+			Updates(map[string]interface{}{"is_accepted": false, "changed_at": now}).Error
+		if err != nil || answerID == nil {
+			return err
+		}
+
+		result = tx.Model(&Answer{}).Where("id = ? AND question_id = ?", *answerID, questionID).
+			Updates(map[string]interface{}{"is_accepted": true, "changed_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.logger.Warn("question or answer not found for accept", "question_id", questionID, "answer_id", answerID)
+			return translate(err, "answer")
+		}
+		r.logger.Error("failed to accept answer", "question_id", questionID, "error", err)
+		return translate(err, "answer")
+	}
+
+	r.logger.Info("accepted answer changed", "question_id", questionID, "answer_id", answerID)
+	return nil
+}
+
+// Restore brings the answer back from the trash, its question must not be deleted.
+func (r *GormAnswerRepository) Restore(id int) (entity.Answer, error) {
+	r.logger.Debug("restoring answer", "answer_id", id)
+
+	var gormAnswer Answer
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(onlyDeleted("answers")).Preload("Author").First(&gormAnswer, id).Error; err != nil {
+			return err
+		}
+
+		if err := tx.First(&Question{}, gormAnswer.QuestionID).Error; err != nil {
+			return err
+		}
+
+		gormAnswer.DeletedAt = gorm.DeletedAt{}
+		gormAnswer.ChangedAt = time.Now()
+		err := tx.Unscoped().Model(&Answer{}).Where("id = ?", id).
+			Updates(map[string]interface{}{"deleted_at": nil, "changed_at": gormAnswer.ChangedAt}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&Question{}).Where("id = ?", gormAnswer.QuestionID).Updates(map[string]interface{}{
+			"answer_count": gorm.Expr("answer_count + 1"),
+			"changed_at":   gormAnswer.ChangedAt,
+		}).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.logger.Warn("answer or its question not found for restore", "answer_id", id)
+			return entity.Answer{}, translate(err, "answer")
+		}
+		r.logger.Error("failed to restore answer", "answer_id", id, "error", err)
+		return entity.Answer{}, translate(err, "answer")
+	}
+
+	r.logger.Info("answer restored successfully", "answer_id", id)
+	return r.toEntity(gormAnswer), nil
+}
+
+func (r *GormAnswerRepository) GetDeleted(id int) (entity.Answer, error) {
+	r.logger.Debug("getting deleted answer", "answer_id", id)
+
+	var gormAnswer Answer
+	result := r.db.Scopes(onlyDeleted("answers")).Preload("Author").First(&gormAnswer, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			r.logger.Warn("answer not found in trash", "answer_id", id)
+			return entity.Answer{}, translate(result.Error, "answer")
+		}
+		r.logger.Error("failed to get deleted answer", "answer_id", id, "error", result.Error)
+		return entity.Answer{}, translate(result.Error, "answer")
+	}
+
+	return r.toEntity(gormAnswer), nil
+}
+
+func (r *GormAnswerRepository) ListDeleted(page entity.PageRequest) ([]entity.Answer, error) {
+	r.logger.Debug("listing deleted answers", "limit", page.Limit)
+
+	var gormAnswers []Answer
+	result := r.db.Preload("Author").Scopes(onlyDeleted("answers"), paginate("answers", byTime("deleted_at", true), page)).Find(&gormAnswers)
+	if result.Error != nil {
+		r.logger.Error("failed to list deleted answers", "error", result.Error)
+		return nil, translate(result.Error, "answer")
+	}
+
+	answers := make([]entity.Answer, len(gormAnswers))
+	for i, ga := range gormAnswers {
+		answers[i] = r.toEntity(ga)
+	}
+
+	return answers, nil
+}
+
+// Purge permanently removes answers deleted before the given time.
+func (r *GormAnswerRepository) Purge(before time.Time) (int64, error) {
+	r.logger.Debug("purging deleted answers", "before", before)
+
+	var purged int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var ids []int
+		if err := tx.Unscoped().Model(&Answer{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("deleted_at < ?", before).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		if err := purgeTargets(tx, entity.VoteTargetAnswer, ids); err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&Answer{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		r.logger.Error("failed to purge answers", "error", err)
+		return 0, translate(err, "answer")
+	}
+
+	r.logger.Info("answers purged", "count", purged)
+	return purged, nil
+}
+
 func (r *GormAnswerRepository) toEntity(gormAnswer Answer) entity.Answer {
 	return entity.Answer{
 		ID:         gormAnswer.ID,
 		QuestionID: gormAnswer.QuestionID,
 		UserID:     gormAnswer.UserID,
+		Author:     userSummaryToEntity(gormAnswer.Author),
 		Text:       gormAnswer.Text,
+		Score:      gormAnswer.Score,
+		IsAccepted: gormAnswer.IsAccepted,
+		Version:    gormAnswer.Version,
+		ChangedAt:  gormAnswer.ChangedAt,
 		CreatedAt:  gormAnswer.CreatedAt,
+		UpdatedAt:  gormAnswer.UpdatedAt,
+		DeletedAt:  deletedAtToEntity(gormAnswer.DeletedAt),
+		HiddenAt:   gormAnswer.HiddenAt,
+		LockedAt:   gormAnswer.LockedAt,
 	}
 }
 
@@ -94,6 +422,11 @@ func (r *GormAnswerRepository) toGormModel(answer entity.Answer) Answer {
 		QuestionID: answer.QuestionID,
 		UserID:     answer.UserID,
 		Text:       answer.Text,
+		Score:      answer.Score,
+		IsAccepted: answer.IsAccepted,
+		Version:    answer.Version,
+		ChangedAt:  answer.ChangedAt,
 		CreatedAt:  answer.CreatedAt,
+		UpdatedAt:  answer.UpdatedAt,
 	}
 }
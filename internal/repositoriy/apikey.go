@@ -0,0 +1,140 @@
+package repositoriy
+
+import (
+	"strings"
+	"testovoe/internal/entity"
+	"testovoe/internal/pkg"
+	"testovoe/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type GormAPIKeyRepository struct {
+	db     *gorm.DB
+	logger pkg.Logger
+}
+
+func NewGormAPIKeyRepository(db *gorm.DB, logger pkg.Logger) usecase.APIKeyRepositoriy {
+	if logger == nil {
+		logger = pkg.NewNopLogger()
+	}
+	return &GormAPIKeyRepository{
+		db:     db,
+		logger: logger.WithFields(map[string]interface{}{"component": "api_key_repository"}),
+	}
+}
+
+func (r *GormAPIKeyRepository) GetByID(id int) (entity.APIKey, error) {
+	r.logger.Debug("getting API key by ID", "api_key_id", id)
+
+	var gormKey APIKey
+	result := r.db.First(&gormKey, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			r.logger.Warn("API key not found", "api_key_id", id)
+			return entity.APIKey{}, translate(result.Error, "API key")
+		}
+		r.logger.Error("failed to get API key", "api_key_id", id, "error", result.Error)
+		return entity.APIKey{}, translate(result.Error, "API key")
+	}
+
+	return r.toEntity(gormKey), nil
+}
+
+func (r *GormAPIKeyRepository) GetByPrefix(prefix string) (entity.APIKey, error) {
+	var gormKey APIKey
+	result := r.db.First(&gormKey, "prefix = ?", prefix)
+	if result.Error != nil {
+		if result.Error != gorm.ErrRecordNotFound {
+			r.logger.Error("failed to get API key by prefix", "prefix", prefix, "error", result.Error)
+		}
+		return entity.APIKey{}, translate(result.Error, "API key")
+	}
+
+	return r.toEntity(gormKey), nil
+}
+
+func (r *GormAPIKeyRepository) ListByUser(userID uuid.UUID) ([]entity.APIKey, error) {
+	r.logger.Debug("listing API keys", "user_id", userID)
+
+	var gormKeys []APIKey
+	result := r.db.Where("user_id = ?", userID).Order("created_at DESC, id DESC").Find(&gormKeys)
+	if result.Error != nil {
+		r.logger.Error("failed to list API keys", "user_id", userID, "error", result.Error)
+		return nil, translate(result.Error, "API key")
+	}
+
+	keys := make([]entity.APIKey, len(gormKeys))
+	for i, gormKey := range gormKeys {
+		keys[i] = r.toEntity(gormKey)
+	}
+
+	return keys, nil
+}
+
+func (r *GormAPIKeyRepository) Save(key entity.APIKey) (entity.APIKey, error) {
+	r.logger.Debug("saving API key", "user_id", key.UserID, "prefix", key.Prefix)
+
+	gormKey := r.toGormModel(key)
+	result := r.db.Create(&gormKey)
+	if result.Error != nil {
+		r.logger.Error("failed to save API key", "user_id", key.UserID, "error", result.Error)
+		return entity.APIKey{}, translate(result.Error, "API key")
+	}
+
+	r.logger.Info("API key saved successfully", "api_key_id", gormKey.ID, "user_id", key.UserID)
+	return r.toEntity(gormKey), nil
+}
+
+func (r *GormAPIKeyRepository) Revoke(id int, at time.Time) error {
+	r.logger.Debug("revoking API key", "api_key_id", id)
+
+	result := r.db.Model(&APIKey{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", at)
+	if result.Error != nil {
+		r.logger.Error("failed to revoke API key", "api_key_id", id, "error", result.Error)
+		return translate(result.Error, "API key")
+	}
+
+	r.logger.Info("API key revoked", "api_key_id", id)
+	return nil
+}
+
+func (r *GormAPIKeyRepository) Touch(id int, at time.Time) error {
+	result := r.db.Model(&APIKey{}).Where("id = ?", id).Update("last_used_at", at)
+	if result.Error != nil {
+		r.logger.Error("failed to record API key use", "api_key_id", id, "error", result.Error)
+		return translate(result.Error, "API key")
+	}
+
+	return nil
+}
+
+func (r *GormAPIKeyRepository) toEntity(gormKey APIKey) entity.APIKey {
+	return entity.APIKey{
+		ID:         gormKey.ID,
+		UserID:     gormKey.UserID,
+		Name:       gormKey.Name,
+		Prefix:     gormKey.Prefix,
+		KeyHash:    gormKey.KeyHash,
+		Scopes:     strings.Fields(gormKey.Scopes),
+		CreatedAt:  gormKey.CreatedAt,
+		LastUsedAt: gormKey.LastUsedAt,
+		RevokedAt:  gormKey.RevokedAt,
+	}
+}
+
+func (r *GormAPIKeyRepository) toGormModel(key entity.APIKey) APIKey {
+	return APIKey{
+		ID:         key.ID,
+		UserID:     key.UserID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		KeyHash:    key.KeyHash,
+		Scopes:     strings.Join(key.Scopes, " "),
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
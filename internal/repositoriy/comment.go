@@ -0,0 +1,178 @@
+package repositoriy
+
+import (
+	"testovoe/internal/entity"
+	"testovoe/internal/pkg"
+	"testovoe/internal/usecase"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type GormCommentRepository struct {
+	db     *gorm.DB
+	logger pkg.Logger
+}
+
+func NewGormCommentRepository(db *gorm.DB, logger pkg.Logger) usecase.CommentRepositoriy {
+	if logger == nil {
+		logger = pkg.NewNopLogger()
+	}
+	return &GormCommentRepository{
+		db:     db,
+		logger: logger.WithFields(map[string]interface{}{"component": "comment_repository"}),
+	}
+}
+
+func (r *GormCommentRepository) GetByID(id int) (entity.Comment, error) {
+	r.logger.Debug("getting comment by ID", "comment_id", id)
+
+	var gormComment Comment
+	result := r.db.First(&gormComment, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			r.logger.Warn("comment not found", "comment_id", id)
+			return entity.Comment{}, translate(result.Error, "comment")
+		}
+		r.logger.Error("failed to get comment", "comment_id", id, "error", result.Error)
+		return entity.Comment{}, translate(result.Error, "comment")
+	}
+
+	return r.toEntity(gormComment), nil
+}
+
+func (r *GormCommentRepository) ListByTarget(targetType string, targetID int, page entity.PageRequest) ([]entity.Comment, error) {
+	r.logger.Debug("listing comments", "target_type", targetType, "target_id", targetID, "limit", page.Limit)
+
+	var gormComments []Comment
+	result := r.db.Where("target_type = ? AND target_id = ?", targetType, targetID).
+		Scopes(paginate("comments", byTime("created_at", false), page)).
+		Find(&gormComments)
+	if result.Error != nil {
+		r.logger.Error("failed to list comments", "target_type", targetType, "target_id", targetID, "error", result.Error)
+		return nil, translate(result.Error, "comment")
+	}
+
+	return r.toEntities(gormComments), nil
+}
+
+func (r *GormCommentRepository) ListByTargets(targetType string, targetIDs []int) ([]entity.Comment, error) {
+	r.logger.Debug("listing comments of targets", "target_type", targetType, "count", len(targetIDs))
+
+	if len(targetIDs) == 0 {
+		return []entity.Comment{}, nil
+	}
+
+	var gormComments []Comment
+	result := r.db.Where("target_type = ? AND target_id IN ?", targetType, targetIDs).
+		Order("created_at, id").
+		Find(&gormComments)
+	if result.Error != nil {
+		r.logger.Error("failed to list comments of targets", "target_type", targetType, "error", result.Error)
+		return nil, translate(result.Error, "comment")
+	}
+
+	return r.toEntities(gormComments), nil
+}
+
+func (r *GormCommentRepository) Save(comment entity.Comment) (entity.Comment, error) {
+	r.logger.Debug("saving comment", "target_type", comment.TargetType, "target_id", comment.TargetID, "user_id", comment.UserID)
+
+	gormComment := r.toGormModel(comment)
+	if gormComment.CreatedAt.IsZero() {
+		gormComment.CreatedAt = time.Now()
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&gormComment).Error; err != nil {
+			return err
+		}
+		return touchTarget(tx, gormComment.TargetType, gormComment.TargetID, gormComment.CreatedAt)
+	})
+	if err != nil {
+		r.logger.Error("failed to save comment", "error", err, "user_id", comment.UserID)
+		return entity.Comment{}, translate(err, "comment")
+	}
+
+	r.logger.Info("comment saved successfully", "comment_id", gormComment.ID)
+	return r.toEntity(gormComment), nil
+}
+
+func (r *GormCommentRepository) Delete(id int) error {
+	r.logger.Debug("deleting comment", "comment_id", id)
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var gormComment Comment
+		if err := tx.First(&gormComment, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&gormComment).Error; err != nil {
+			return err
+		}
+		return touchTarget(tx, gormComment.TargetType, gormComment.TargetID, time.Now())
+	})
+	if err == gorm.ErrRecordNotFound {
+		r.logger.Warn("comment not found for deletion", "comment_id", id)
+		return translate(err, "comment")
+	}
+	if err != nil {
+		r.logger.Error("failed to delete comment", "comment_id", id, "error", err)
+		return translate(err, "comment")
+	}
+
+	r.logger.Info("comment deleted successfully", "comment_id", id)
+	return nil
+}
+
+func (r *GormCommentRepository) toEntities(gormComments []Comment) []entity.Comment {
+	comments := make([]entity.Comment, len(gormComments))
+	for i, gc := range gormComments {
+		comments[i] = r.toEntity(gc)
+	}
+	return comments
+}
+
+func (r *GormCommentRepository) toEntity(gormComment Comment) entity.Comment {
+	return entity.Comment{
+		ID:         gormComment.ID,
+		TargetType: gormComment.TargetType,
+		TargetID:   gormComment.TargetID,
+		UserID:     gormComment.UserID,
+		Text:       gormComment.Text,
+		CreatedAt:  gormComment.CreatedAt,
+	}
+}
+
+func (r *GormCommentRepository) toGormModel(comment entity.Comment) Comment {
+	return Comment{
+		ID:         comment.ID,
+		TargetType: comment.TargetType,
+		TargetID:   comment.TargetID,
+		UserID:     comment.UserID,
+		Text:       comment.Text,
+		CreatedAt:  comment.CreatedAt,
+	}
+}
+
+// touchTarget moves changed_at of the commented question or answer.
+func touchTarget(tx *gorm.DB, targetType string, targetID int, at time.Time) error {
+	if targetType == entity.CommentTargetAnswer {
+		return touchAnswer(tx, targetID, at)
+	}
+	return touchQuestion(tx, targetID, at)
+}
+
+// purgeTargets deletes comments, votes and flags of questions or answers that are about to be purged.
+// They point at their target by type and id, so no foreign key removes them with the target.
+func purgeTargets(tx *gorm.DB, targetType string, ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	for _, model := range []interface{}{&Comment{}, &Vote{}, &Flag{}} {
+		if err := tx.Where("target_type = ? AND target_id IN ?", targetType, ids).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -4,14 +4,79 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-type Question struct {
+type User struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey"`
+	DisplayName  string    `gorm:"type:varchar(50);not null"`
+	Email        string    `gorm:"type:varchar(254);not null;uniqueIndex"`
+	Role         string    `gorm:"type:varchar(16);not null;default:user"`
+	PasswordHash string    `gorm:"type:varchar(72);not null;default:''"`
+	CreatedAt    time.Time
+	UpdatedAt    *time.Time `gorm:"autoUpdateTime:false"`
+}
+
+type RefreshToken struct {
 	ID        int       `gorm:"primaryKey;autoIncrement"`
-	UserID    uuid.UUID `gorm:"type:uuid;not null"`
-	Text      string    `gorm:"type:text;not null"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	TokenHash string    `gorm:"type:char(64);not null;uniqueIndex"`
+	ExpiresAt time.Time `gorm:"not null"`
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+type UserIdentity struct {
+	Issuer    string    `gorm:"type:varchar(255);primaryKey"`
+	Subject   string    `gorm:"type:varchar(255);primaryKey"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index"`
 	CreatedAt time.Time
-	Answers   []Answer `gorm:"foreignKey:QuestionID;constraint:OnDelete:CASCADE"`
+}
+
+// IdempotencyKey keeps the response to a request, Status is zero until the response is known.
+type IdempotencyKey struct {
+	UserID         uuid.UUID `gorm:"type:uuid;primaryKey"`
+	IdempotencyKey string    `gorm:"type:varchar(255);primaryKey"`
+	Fingerprint    string    `gorm:"type:char(64);not null"`
+	Status         int       `gorm:"not null;default:0"`
+	ContentType    string    `gorm:"type:varchar(255);not null;default:''"`
+	Body           []byte
+	CreatedAt      time.Time
+	ExpiresAt      time.Time `gorm:"not null;index"`
+}
+
+// APIKey keeps scopes as a space separated list, the way OAuth writes them.
+type APIKey struct {
+	ID         int       `gorm:"primaryKey;autoIncrement"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index"`
+	Name       string    `gorm:"type:varchar(50);not null"`
+	Prefix     string    `gorm:"type:varchar(8);not null;uniqueIndex"`
+	KeyHash    string    `gorm:"type:char(64);not null"`
+	Scopes     string    `gorm:"type:varchar(255);not null"`
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+// Question keeps in ChangedAt the last change shown on its page, including answers, comments,
+// votes and moderation.
+type Question struct {
+	ID               int       `gorm:"primaryKey;autoIncrement"`
+	UserID           uuid.UUID `gorm:"type:uuid;not null"`
+	Text             string    `gorm:"type:text;not null"`
+	AnswerCount      int       `gorm:"not null;default:0"`
+	Score            int       `gorm:"not null;default:0"`
+	AcceptedAnswerID *int
+	Version          int       `gorm:"not null;default:1"`
+	LastActivityAt   time.Time `gorm:"not null"`
+	ChangedAt        time.Time `gorm:"not null"`
+	CreatedAt        time.Time
+	UpdatedAt        *time.Time     `gorm:"autoUpdateTime:false"`
+	DeletedAt        gorm.DeletedAt `gorm:"index"`
+	HiddenAt         *time.Time
+	LockedAt         *time.Time
+	Author           *User    `gorm:"foreignKey:UserID;constraint:-"`
+	Answers          []Answer `gorm:"foreignKey:QuestionID;constraint:OnDelete:CASCADE"`
 }
 
 type Answer struct {
@@ -19,6 +84,103 @@ type Answer struct {
 	QuestionID int       `gorm:"not null;index"`
 	UserID     uuid.UUID `gorm:"type:uuid;not null"`
 	Text       string    `gorm:"type:text;not null"`
+	Score      int       `gorm:"not null;default:0"`
+	IsAccepted bool      `gorm:"not null;default:false"`
+	Version    int       `gorm:"not null;default:1"`
+	ChangedAt  time.Time `gorm:"not null"`
 	CreatedAt  time.Time
+	UpdatedAt  *time.Time     `gorm:"autoUpdateTime:false"`
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
+	HiddenAt   *time.Time
+	LockedAt   *time.Time
+	Author     *User    `gorm:"foreignKey:UserID;constraint:-"`
 	Question   Question `gorm:"foreignKey:QuestionID"`
 }
+
+// QuestionRevision is a previous text of a question, CreatedAt is when that text was written.
+type QuestionRevision struct {
+	ID         int    `gorm:"primaryKey;autoIncrement"`
+	QuestionID int    `gorm:"not null;uniqueIndex:idx_question_revisions_question_id_revision"`
+	Revision   int    `gorm:"not null;uniqueIndex:idx_question_revisions_question_id_revision"`
+	Text       string `gorm:"type:text;not null"`
+	CreatedAt  time.Time
+}
+
+// AnswerRevision is a previous text of an answer, CreatedAt is when that text was written.
+type AnswerRevision struct {
+	ID        int    `gorm:"primaryKey;autoIncrement"`
+	AnswerID  int    `gorm:"not null;uniqueIndex:idx_answer_revisions_answer_id_revision"`
+	Revision  int    `gorm:"not null;uniqueIndex:idx_answer_revisions_answer_id_revision"`
+	Text      string `gorm:"type:text;not null"`
+	CreatedAt time.Time
+}
+
+// Vote is keyed by voter and target, so each user has at most one vote per question or answer.
+type Vote struct {
+	UserID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+	TargetType string    `gorm:"type:varchar(16);primaryKey"`
+	TargetID   int       `gorm:"primaryKey"`
+	Value      int       `gorm:"not null"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type Tag struct {
+	ID   int    `gorm:"primaryKey;autoIncrement"`
+	Name string `gorm:"type:varchar(32);not null;uniqueIndex"`
+}
+
+// QuestionTag links a question to one of its tags.
+type QuestionTag struct {
+	QuestionID int `gorm:"primaryKey"`
+	TagID      int `gorm:"primaryKey;index"`
+}
+
+// Comment belongs to a question or an answer, TargetType tells which.
+type Comment struct {
+	ID         int       `gorm:"primaryKey;autoIncrement"`
+	TargetType string    `gorm:"type:varchar(16);not null;index:idx_comments_target"`
+	TargetID   int       `gorm:"not null;index:idx_comments_target"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null"`
+	Text       string    `gorm:"type:text;not null"`
+	CreatedAt  time.Time
+}
+
+// HeldContent is a question or an answer waiting for a moderator, Tags are joined with commas.
+type HeldContent struct {
+	ID         int       `gorm:"primaryKey;autoIncrement"`
+	Kind       string    `gorm:"type:varchar(16);not null"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index"`
+	Text       string    `gorm:"type:text;not null"`
+	Tags       string    `gorm:"type:text;not null;default:''"`
+	QuestionID *int
+	Edit       bool `gorm:"not null;default:false"`
+	PostID     *int
+	Version    *int
+	Policy     string `gorm:"type:varchar(32);not null"`
+	Reason     string `gorm:"type:text;not null"`
+	CreatedAt  time.Time
+}
+
+// Flag is unique per user and post, so a user can not flag the same post twice.
+type Flag struct {
+	ID         int       `gorm:"primaryKey;autoIncrement"`
+	TargetType string    `gorm:"type:varchar(16);not null;uniqueIndex:idx_flags_user_target;index:idx_flags_target"`
+	TargetID   int       `gorm:"not null;uniqueIndex:idx_flags_user_target;index:idx_flags_target"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_flags_user_target"`
+	Reason     string    `gorm:"type:varchar(16);not null"`
+	Status     string    `gorm:"type:varchar(16);not null;default:pending"`
+	CreatedAt  time.Time
+	ResolvedAt *time.Time
+}
+
+// ModerationDecision is the log of everything done with flagged posts.
+type ModerationDecision struct {
+	ID          int        `gorm:"primaryKey;autoIncrement"`
+	TargetType  string     `gorm:"type:varchar(16);not null"`
+	TargetID    int        `gorm:"not null"`
+	ModeratorID *uuid.UUID `gorm:"type:uuid"`
+	Action      string     `gorm:"type:varchar(16);not null"`
+	Note        string     `gorm:"type:text;not null;default:''"`
+	CreatedAt   time.Time
+}
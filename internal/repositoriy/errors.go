@@ -0,0 +1,24 @@
+package repositoriy
+
+import (
+	"errors"
+	"testovoe/internal/usecase"
+
+	"gorm.io/gorm"
+)
+
+// translate turns a database error into a use case error, what names the missing record for the client.
+// Errors that are already typed, such as a version mismatch, pass through. Duplicate keys are
+// recognized only when the connection is opened with TranslateError.
+func translate(err error, what string) error {
+	var typed *usecase.Error
+	switch {
+	case err == nil, errors.As(err, &typed):
+		return err
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return usecase.NotFound("This " + what + " is not exist")
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return usecase.Conflict("This " + what + " already exists")
+	}
+	return usecase.Internal(err)
+}
@@ -0,0 +1,222 @@
+package repositoriy
+
+import (
+	"testovoe/internal/entity"
+	"testovoe/internal/pkg"
+	"testovoe/internal/usecase"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormFlagRepository struct {
+	db     *gorm.DB
+	logger pkg.Logger
+}
+
+func NewGormFlagRepository(db *gorm.DB, logger pkg.Logger) usecase.FlagRepositoriy {
+	if logger == nil {
+		logger = pkg.NewNopLogger()
+	}
+	return &GormFlagRepository{
+		db:     db,
+		logger: logger.WithFields(map[string]interface{}{"component": "flag_repository"}),
+	}
+}
+
+// Create relies on the unique index of user and post, so a repeated flag is not saved.
+func (r *GormFlagRepository) Create(flag entity.Flag) (entity.Flag, bool, error) {
+	r.logger.Debug("saving flag", "user_id", flag.UserID, "target_type", flag.TargetType, "target_id", flag.TargetID)
+
+	gormFlag := r.toGormModel(flag)
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&gormFlag)
+	if result.Error != nil {
+		r.logger.Error("failed to save flag", "target_type", flag.TargetType, "target_id", flag.TargetID, "error", result.Error)
+		return entity.Flag{}, false, translate(result.Error, "flag")
+	}
+	if result.RowsAffected == 0 {
+		return entity.Flag{}, false, nil
+	}
+
+	r.logger.Info("post flagged", "flag_id", gormFlag.ID, "target_type", flag.TargetType, "target_id", flag.TargetID, "reason", flag.Reason)
+	return r.toEntity(gormFlag), true, nil
+}
+
+func (r *GormFlagRepository) GetByID(id int) (entity.Flag, error) {
+	var gormFlag Flag
+	result := r.db.First(&gormFlag, id)
+	if result.Error != nil {
+		if result.Error != gorm.ErrRecordNotFound {
+			r.logger.Error("failed to get flag", "flag_id", id, "error", result.Error)
+		}
+		return entity.Flag{}, translate(result.Error, "flag")
+	}
+
+	return r.toEntity(gormFlag), nil
+}
+
+func (r *GormFlagRepository) ListPending(page entity.PageRequest) ([]entity.Flag, error) {
+	var gormFlags []Flag
+	result := r.db.Where("status = ?", entity.FlagPending).
+		Scopes(paginate("flags", byTime("created_at", false), page)).
+		Find(&gormFlags)
+	if result.Error != nil {
+		r.logger.Error("failed to list pending flags", "error", result.Error)
+		return nil, translate(result.Error, "flag")
+	}
+
+	flags := make([]entity.Flag, len(gormFlags))
+	for i, gormFlag := range gormFlags {
+		flags[i] = r.toEntity(gormFlag)
+	}
+
+	return flags, nil
+}
+
+func (r *GormFlagRepository) CountPending(targetType string, targetID int) (int, error) {
+	var count int64
+	err := r.db.Model(&Flag{}).
+		Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, entity.FlagPending).
+		Count(&count).Error
+	if err != nil {
+		r.logger.Error("failed to count flags", "target_type", targetType, "target_id", targetID, "error", err)
+		return 0, translate(err, "flag")
+	}
+
+	return int(count), nil
+}
+
+func (r *GormFlagRepository) Decide(decision entity.ModerationDecision, status string) (entity.ModerationDecision, error) {
+	r.logger.Debug("deciding on post", "target_type", decision.TargetType, "target_id", decision.TargetID, "action", decision.Action)
+
+	gormDecision := ModerationDecision{
+		TargetType:  decision.TargetType,
+		TargetID:    decision.TargetID,
+		ModeratorID: decision.ModeratorID,
+		Action:      decision.Action,
+		Note:        decision.Note,
+		CreatedAt:   decision.CreatedAt,
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := applyDecision(tx, decision); err != nil {
+			return err
+		}
+
+		if status != "" {
+			err := tx.Model(&Flag{}).
+				Where("target_type = ? AND target_id = ? AND status = ?", decision.TargetType, decision.TargetID, entity.FlagPending).
+				Updates(map[string]interface{}{"status": status, "resolved_at": decision.CreatedAt}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(&gormDecision).Error
+	})
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			r.logger.Error("failed to decide on post", "target_type", decision.TargetType, "target_id", decision.TargetID, "error", err)
+		}
+		return entity.ModerationDecision{}, translate(err, decision.TargetType)
+	}
+
+	r.logger.Info("moderation decision recorded", "decision_id", gormDecision.ID, "action", decision.Action,
+		"target_type", decision.TargetType, "target_id", decision.TargetID)
+	return r.decisionToEntity(gormDecision), nil
+}
+
+// applyDecision changes the post, also one in the trash so its flags can be resolved.
+// The version moves so cached copies are refreshed. Deleting moves the post to the trash,
+// a post that is already there is not found.
+func applyDecision(tx *gorm.DB, decision entity.ModerationDecision) error {
+	var model interface{} = &Question{}
+	if decision.TargetType == entity.FlagTargetAnswer {
+		model = &Answer{}
+	}
+
+	var changes map[string]interface{}
+	switch decision.Action {
+	case entity.ActionDelete:
+		if decision.TargetType == entity.FlagTargetAnswer {
+			return trashAnswer(tx, decision.TargetID, nil)
+		}
+		return trashQuestion(tx, decision.TargetID, nil, decision.CreatedAt)
+	case entity.ActionHide:
+		changes = map[string]interface{}{"hidden_at": decision.CreatedAt}
+	case entity.ActionLock:
+		changes = map[string]interface{}{"locked_at": decision.CreatedAt}
+	case entity.ActionDismiss:
+		changes = map[string]interface{}{"hidden_at": nil}
+	default:
+		return nil
+	}
+	changes["version"] = gorm.Expr("version + 1")
+
+	result := tx.Unscoped().Model(model).Where("id = ?", decision.TargetID).Updates(changes)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	if decision.TargetType == entity.FlagTargetAnswer {
+		return touchAnswer(tx, decision.TargetID, decision.CreatedAt)
+	}
+	return touchQuestion(tx, decision.TargetID, decision.CreatedAt)
+}
+
+func (r *GormFlagRepository) ListDecisions(page entity.PageRequest) ([]entity.ModerationDecision, error) {
+	var gormDecisions []ModerationDecision
+	result := r.db.Scopes(paginate("moderation_decisions", byTime("created_at", true), page)).Find(&gormDecisions)
+	if result.Error != nil {
+		r.logger.Error("failed to list moderation decisions", "error", result.Error)
+		return nil, translate(result.Error, "moderation decision")
+	}
+
+	decisions := make([]entity.ModerationDecision, len(gormDecisions))
+	for i, gormDecision := range gormDecisions {
+		decisions[i] = r.decisionToEntity(gormDecision)
+	}
+
+	return decisions, nil
+}
+
+func (r *GormFlagRepository) toEntity(gormFlag Flag) entity.Flag {
+	return entity.Flag{
+		ID:         gormFlag.ID,
+		TargetType: gormFlag.TargetType,
+		TargetID:   gormFlag.TargetID,
+		UserID:     gormFlag.UserID,
+		Reason:     gormFlag.Reason,
+		Status:     gormFlag.Status,
+		CreatedAt:  gormFlag.CreatedAt,
+		ResolvedAt: gormFlag.ResolvedAt,
+	}
+}
+
+func (r *GormFlagRepository) toGormModel(flag entity.Flag) Flag {
+	return Flag{
+		ID:         flag.ID,
+		TargetType: flag.TargetType,
+		TargetID:   flag.TargetID,
+		UserID:     flag.UserID,
+		Reason:     flag.Reason,
+		Status:     flag.Status,
+		CreatedAt:  flag.CreatedAt,
+		ResolvedAt: flag.ResolvedAt,
+	}
+}
+
+func (r *GormFlagRepository) decisionToEntity(gormDecision ModerationDecision) entity.ModerationDecision {
+	return entity.ModerationDecision{
+		ID:          gormDecision.ID,
+		TargetType:  gormDecision.TargetType,
+		TargetID:    gormDecision.TargetID,
+		ModeratorID: gormDecision.ModeratorID,
+		Action:      gormDecision.Action,
+		Note:        gormDecision.Note,
+		CreatedAt:   gormDecision.CreatedAt,
+	}
+}
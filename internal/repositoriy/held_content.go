@@ -0,0 +1,178 @@
+package repositoriy
+
+import (
+	"strings"
+	"testovoe/internal/entity"
+	"testovoe/internal/pkg"
+	"testovoe/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormHeldContentRepository struct {
+	db     *gorm.DB
+	logger pkg.Logger
+}
+
+func NewGormHeldContentRepository(db *gorm.DB, logger pkg.Logger) *GormHeldContentRepository {
+	if logger == nil {
+		logger = pkg.NewNopLogger()
+	}
+	return &GormHeldContentRepository{
+		db:     db,
+		logger: logger.WithFields(map[string]interface{}{"component": "held_content_repository"}),
+	}
+}
+
+var (
+	_ usecase.HeldContentRepositoriy   = (*GormHeldContentRepository)(nil)
+	_ usecase.RecentContentRepositoriy = (*GormHeldContentRepository)(nil)
+)
+
+func (r *GormHeldContentRepository) Save(held entity.HeldContent) (entity.HeldContent, error) {
+	r.logger.Debug("holding content", "kind", held.Kind, "user_id", held.UserID, "policy", held.Policy)
+
+	gormHeld := r.toGormModel(held)
+	if err := r.db.Create(&gormHeld).Error; err != nil {
+		r.logger.Error("failed to hold content", "user_id", held.UserID, "error", err)
+		return entity.HeldContent{}, translate(err, "held content")
+	}
+
+	r.logger.Info("content held for moderation", "held_content_id", gormHeld.ID, "policy", held.Policy)
+	return r.toEntity(gormHeld), nil
+}
+
+func (r *GormHeldContentRepository) GetByID(id int) (entity.HeldContent, error) {
+	var gormHeld HeldContent
+	result := r.db.First(&gormHeld, id)
+	if result.Error != nil {
+		if result.Error != gorm.ErrRecordNotFound {
+			r.logger.Error("failed to get held content", "held_content_id", id, "error", result.Error)
+		}
+		return entity.HeldContent{}, translate(result.Error, "held content")
+	}
+
+	return r.toEntity(gormHeld), nil
+}
+
+func (r *GormHeldContentRepository) List(page entity.PageRequest) ([]entity.HeldContent, error) {
+	var gormHeld []HeldContent
+	result := r.db.Scopes(paginate("held_contents", byTime("created_at", false), page)).Find(&gormHeld)
+	if result.Error != nil {
+		r.logger.Error("failed to list held content", "error", result.Error)
+		return nil, translate(result.Error, "held content")
+	}
+
+	held := make([]entity.HeldContent, len(gormHeld))
+	for i, h := range gormHeld {
+		held[i] = r.toEntity(h)
+	}
+
+	return held, nil
+}
+
+func (r *GormHeldContentRepository) Delete(id int) error {
+	if err := r.db.Delete(&HeldContent{}, id).Error; err != nil {
+		r.logger.Error("failed to delete held content", "held_content_id", id, "error", err)
+		return translate(err, "held content")
+	}
+
+	return nil
+}
+
+// Claim deletes the held content and returns it. The row is locked first, so of concurrent claims
+// only one finds it and the others get NotFound.
+func (r *GormHeldContentRepository) Claim(id int) (entity.HeldContent, error) {
+	var gormHeld HeldContent
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&gormHeld, id).Error; err != nil {
+			return err
+		}
+
+		result := tx.Delete(&HeldContent{}, id)
+		if result.Error == nil && result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return result.Error
+	})
+	if err != nil {
+		if err != gorm.ErrRecordNotFound {
+			r.logger.Error("failed to claim held content", "held_content_id", id, "error", err)
+		}
+		return entity.HeldContent{}, translate(err, "held content")
+	}
+
+	r.logger.Info("held content claimed", "held_content_id", id)
+	return r.toEntity(gormHeld), nil
+}
+
+// HasRecent looks at published posts of the kind and at new posts of the kind waiting in the queue.
+func (r *GormHeldContentRepository) HasRecent(kind string, userID uuid.UUID, text string, since time.Time) (bool, error) {
+	var published interface{} = &Question{}
+	if kind == entity.ContentAnswer {
+		published = &Answer{}
+	}
+
+	var count int64
+	if err := r.db.Model(published).Where("user_id = ? AND text = ? AND created_at >= ?", userID, text, since).Count(&count).Error; err != nil {
+		r.logger.Error("failed to look for recent posts", "user_id", userID, "error", err)
+		return false, translate(err, kind)
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	err := r.db.Model(&HeldContent{}).
+		Where("kind = ? AND edit = ? AND user_id = ? AND text = ? AND created_at >= ?", kind, false, userID, text, since).
+		Count(&count).Error
+	if err != nil {
+		r.logger.Error("failed to look for recent held content", "user_id", userID, "error", err)
+		return false, translate(err, "held content")
+	}
+
+	return count > 0, nil
+}
+
+func (r *GormHeldContentRepository) toEntity(gormHeld HeldContent) entity.HeldContent {
+	var tags []string
+	if gormHeld.Tags != "" {
+		tags = strings.Split(gormHeld.Tags, ",")
+	}
+
+	return entity.HeldContent{
+		ID: gormHeld.ID,
+		Content: entity.Content{
+			Kind:   gormHeld.Kind,
+			UserID: gormHeld.UserID,
+			Text:   gormHeld.Text,
+			Tags:   tags,
+			Edit:   gormHeld.Edit,
+		},
+		QuestionID: gormHeld.QuestionID,
+		PostID:     gormHeld.PostID,
+		Version:    gormHeld.Version,
+		Policy:     gormHeld.Policy,
+		Reason:     gormHeld.Reason,
+		CreatedAt:  gormHeld.CreatedAt,
+	}
+}
+
+func (r *GormHeldContentRepository) toGormModel(held entity.HeldContent) HeldContent {
+	return HeldContent{
+		ID:         held.ID,
+		Kind:       held.Kind,
+		UserID:     held.UserID,
+		Text:       held.Text,
+		Tags:       strings.Join(held.Tags, ","),
+		QuestionID: held.QuestionID,
+		Edit:       held.Edit,
+		PostID:     held.PostID,
+		Version:    held.Version,
+		Policy:     held.Policy,
+		Reason:     held.Reason,
+		CreatedAt:  held.CreatedAt,
+	}
+}
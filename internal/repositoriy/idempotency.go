@@ -0,0 +1,147 @@
+package repositoriy
+
+import (
+	"testovoe/internal/entity"
+	"testovoe/internal/pkg"
+	"testovoe/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormIdempotencyRepository struct {
+	db     *gorm.DB
+	logger pkg.Logger
+}
+
+func NewGormIdempotencyRepository(db *gorm.DB, logger pkg.Logger) usecase.IdempotencyRepositoriy {
+	if logger == nil {
+		logger = pkg.NewNopLogger()
+	}
+	return &GormIdempotencyRepository{
+		db:     db,
+		logger: logger.WithFields(map[string]interface{}{"component": "idempotency_repository"}),
+	}
+}
+
+// Create relies on the primary key, so of two concurrent requests with one key only one creates the record.
+func (r *GormIdempotencyRepository) Create(record entity.IdempotencyRecord) (bool, error) {
+	gormKey := r.toGormModel(record)
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&gormKey)
+	if result.Error != nil {
+		r.logger.Error("failed to save idempotency key", "user_id", record.UserID, "error", result.Error)
+		return false, translate(result.Error, "idempotency key")
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (r *GormIdempotencyRepository) Get(userID uuid.UUID, key string) (entity.IdempotencyRecord, error) {
+	var gormKey IdempotencyKey
+	result := r.db.First(&gormKey, "user_id = ? AND idempotency_key = ?", userID, key)
+	if result.Error != nil {
+		if result.Error != gorm.ErrRecordNotFound {
+			r.logger.Error("failed to get idempotency key", "user_id", userID, "error", result.Error)
+		}
+		return entity.IdempotencyRecord{}, translate(result.Error, "idempotency key")
+	}
+
+	return r.toEntity(gormKey), nil
+}
+
+// Replace overwrites the stale record in one statement, so of two retries only one takes the key.
+func (r *GormIdempotencyRepository) Replace(stale, fresh entity.IdempotencyRecord) (bool, error) {
+	result := r.db.Model(&IdempotencyKey{}).
+		Where("user_id = ? AND idempotency_key = ? AND created_at = ?", stale.UserID, stale.Key, stale.CreatedAt).
+		Updates(map[string]interface{}{
+			"fingerprint":  fresh.Fingerprint,
+			"status":       0,
+			"content_type": "",
+			"body":         nil,
+			"created_at":   fresh.CreatedAt,
+			"expires_at":   fresh.ExpiresAt,
+		})
+	if result.Error != nil {
+		r.logger.Error("failed to take over idempotency key", "user_id", stale.UserID, "error", result.Error)
+		return false, translate(result.Error, "idempotency key")
+	}
+
+	if result.RowsAffected == 1 {
+		r.logger.Info("idempotency key taken over", "user_id", stale.UserID, "status", stale.Status)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Complete stores the response unless a retry has taken the key over meanwhile.
+func (r *GormIdempotencyRepository) Complete(record entity.IdempotencyRecord) error {
+	result := r.db.Model(&IdempotencyKey{}).
+		Where("user_id = ? AND idempotency_key = ? AND created_at = ?", record.UserID, record.Key, record.CreatedAt).
+		Updates(map[string]interface{}{
+			"status":       record.Status,
+			"content_type": record.ContentType,
+			"body":         record.Body,
+		})
+	if result.Error != nil {
+		r.logger.Error("failed to store idempotent response", "user_id", record.UserID, "error", result.Error)
+		return translate(result.Error, "idempotency key")
+	}
+	if result.RowsAffected == 0 {
+		return translate(gorm.ErrRecordNotFound, "idempotency key")
+	}
+
+	return nil
+}
+
+// Delete removes the record of this attempt, a record of a retry that took the key over stays.
+func (r *GormIdempotencyRepository) Delete(record entity.IdempotencyRecord) error {
+	result := r.db.Where("user_id = ? AND idempotency_key = ? AND created_at = ?", record.UserID, record.Key, record.CreatedAt).
+		Delete(&IdempotencyKey{})
+	if result.Error != nil {
+		r.logger.Error("failed to delete idempotency key", "user_id", record.UserID, "error", result.Error)
+		return translate(result.Error, "idempotency key")
+	}
+
+	return nil
+}
+
+// Purge removes keys expired before the given time.
+func (r *GormIdempotencyRepository) Purge(before time.Time) (int64, error) {
+	r.logger.Debug("purging idempotency keys", "before", before)
+
+	result := r.db.Where("expires_at < ?", before).Delete(&IdempotencyKey{})
+	if result.Error != nil {
+		r.logger.Error("failed to purge idempotency keys", "error", result.Error)
+		return 0, translate(result.Error, "idempotency key")
+	}
+
+	r.logger.Info("idempotency keys purged", "count", result.RowsAffected)
+	return result.RowsAffected, nil
+}
+
+func (r *GormIdempotencyRepository) toEntity(gormKey IdempotencyKey) entity.IdempotencyRecord {
+	return entity.IdempotencyRecord{
+		UserID:      gormKey.UserID,
+		Key:         gormKey.IdempotencyKey,
+		Fingerprint: gormKey.Fingerprint,
+		Status:      gormKey.Status,
+		ContentType: gormKey.ContentType,
+		Body:        gormKey.Body,
+		CreatedAt:   gormKey.CreatedAt,
+		ExpiresAt:   gormKey.ExpiresAt,
+	}
+}
+
+func (r *GormIdempotencyRepository) toGormModel(record entity.IdempotencyRecord) IdempotencyKey {
+	return IdempotencyKey{
+		UserID:         record.UserID,
+		IdempotencyKey: record.Key,
+		Fingerprint:    record.Fingerprint,
+		Status:         record.Status,
+		ContentType:    record.ContentType,
+		Body:           record.Body,
+		CreatedAt:      record.CreatedAt,
+		ExpiresAt:      record.ExpiresAt,
+	}
+}
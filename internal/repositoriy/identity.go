@@ -0,0 +1,100 @@
+package repositoriy
+
+import (
+	"testovoe/internal/entity"
+	"testovoe/internal/pkg"
+	"testovoe/internal/usecase"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type GormIdentityRepository struct {
+	db     *gorm.DB
+	logger pkg.Logger
+}
+
+func NewGormIdentityRepository(db *gorm.DB, logger pkg.Logger) usecase.IdentityRepositoriy {
+	if logger == nil {
+		logger = pkg.NewNopLogger()
+	}
+	return &GormIdentityRepository{
+		db:     db,
+		logger: logger.WithFields(map[string]interface{}{"component": "identity_repository"}),
+	}
+}
+
+func (r *GormIdentityRepository) GetUserID(issuer, subject string) (uuid.UUID, error) {
+	var identity UserIdentity
+	result := r.db.First(&identity, "issuer = ? AND subject = ?", issuer, subject)
+	if result.Error != nil {
+		if result.Error != gorm.ErrRecordNotFound {
+			r.logger.Error("failed to get identity", "issuer", issuer, "error", result.Error)
+		}
+		return uuid.Nil, translate(result.Error, "identity")
+	}
+
+	return identity.UserID, nil
+}
+
+func (r *GormIdentityRepository) Save(identity entity.UserIdentity) error {
+	r.logger.Debug("linking identity", "issuer", identity.Issuer, "user_id", identity.UserID)
+
+	result := r.db.Create(&UserIdentity{
+		Issuer:    identity.Issuer,
+		Subject:   identity.Subject,
+		UserID:    identity.UserID,
+		CreatedAt: identity.CreatedAt,
+	})
+	if result.Error != nil {
+		r.logger.Error("failed to link identity", "issuer", identity.Issuer, "user_id", identity.UserID, "error", result.Error)
+		return translate(result.Error, "identity")
+	}
+
+	r.logger.Info("identity linked", "issuer", identity.Issuer, "user_id", identity.UserID)
+	return nil
+}
+
+// Register creates the user together with its first identity, so other requests never see
+// a user of the provider that is not linked yet.
+func (r *GormIdentityRepository) Register(user entity.User, identity entity.UserIdentity) error {
+	r.logger.Debug("registering user of identity", "issuer", identity.Issuer, "user_id", user.ID)
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&User{
+			ID:          user.ID,
+			DisplayName: user.DisplayName,
+			Email:       user.Email,
+			Role:        user.Role,
+			CreatedAt:   user.CreatedAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&UserIdentity{
+			Issuer:    identity.Issuer,
+			Subject:   identity.Subject,
+			UserID:    user.ID,
+			CreatedAt: identity.CreatedAt,
+		}).Error
+	})
+	if err != nil {
+		r.logger.Error("failed to register user of identity", "issuer", identity.Issuer, "user_id", user.ID, "error", err)
+		return translate(err, "user")
+	}
+
+	r.logger.Info("user of identity registered", "issuer", identity.Issuer, "user_id", user.ID)
+	return nil
+}
+
+func (r *GormIdentityRepository) HasIssuer(userID uuid.UUID, issuer string) (bool, error) {
+	var count int64
+	result := r.db.Model(&UserIdentity{}).Where("user_id = ? AND issuer = ?", userID, issuer).Count(&count)
+	if result.Error != nil {
+		r.logger.Error("failed to count identities", "user_id", userID, "issuer", issuer, "error", result.Error)
+		return false, translate(result.Error, "identity")
+	}
+
+	return count > 0, nil
+}
@@ -0,0 +1,44 @@
+package repositoriy
+
+import (
+	"testovoe/internal/entity"
+
+	"gorm.io/gorm"
+)
+
+// keysetOrder is a sort column plus the cursor field holding its value; id breaks ties in the same direction.
+type keysetOrder struct {
+	column string
+	desc   bool
+	key    func(entity.Cursor) interface{}
+}
+
+func byTime(column string, desc bool) keysetOrder {
+	return keysetOrder{column: column, desc: desc, key: func(c entity.Cursor) interface{} { return c.Time }}
+}
+
+func byCount(column string, desc bool) keysetOrder {
+	return keysetOrder{column: column, desc: desc, key: func(c entity.Cursor) interface{} { return c.Count }}
+}
+
+// paginate applies keyset pagination over (order column, id) of the given table.
+func paginate(table string, order keysetOrder, page entity.PageRequest) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		column := table + "." + order.column
+		id := table + ".id"
+
+		cmp, direction := ">", ""
+		if order.desc {
+			cmp, direction = "<", " DESC"
+		}
+
+		if page.After != nil {
+			key := order.key(*page.After)
+			db = db.Where(
+				"("+column+" "+cmp+" ? OR ("+column+" = ? AND "+id+" "+cmp+" ?))",
+				key, key, page.After.ID,
+			)
+		}
+		return db.Order(column + direction + ", " + id + direction).Limit(page.Limit)
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"testovoe/internal/entity"
 	"testovoe/internal/pkg"
 	"testovoe/internal/usecase"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type GormQuestionRepository struct {
@@ -14,25 +16,73 @@ type GormQuestionRepository struct {
 }
 
 func NewGormQuestionRepository(db *gorm.DB, logger pkg.Logger) usecase.QuestionRepositoriy {
+	if logger == nil {
+		logger = pkg.NewNopLogger()
+	}
 	return &GormQuestionRepository{
 		db:     db,
 		logger: logger.WithFields(map[string]interface{}{"component": "question_repository"}),
 	}
 }
 
-func (r *GormQuestionRepository) GetAll() ([]entity.Question, error) {
-	r.logger.Debug("getting all questions")
+var questionOrders = map[entity.QuestionSort]keysetOrder{
+	entity.SortNewest:         byTime("created_at", true),
+	entity.SortOldest:         byTime("created_at", false),
+	entity.SortMostAnswered:   byCount("answer_count", true),
+	entity.SortRecentActivity: byTime("last_activity_at", true),
+}
+
+func (r *GormQuestionRepository) Find(filter entity.QuestionFilter) ([]entity.Question, error) {
+	r.logger.Debug("finding questions", "sort", filter.Sort, "limit", filter.Page.Limit)
+
+	order, ok := questionOrders[filter.Sort]
+	if !ok {
+		order = questionOrders[entity.SortOldest]
+	}
+
+	query := r.db.Model(&Question{}).Preload("Author").Where("questions.hidden_at IS NULL")
+	if filter.UserID != nil {
+		query = query.Where("questions.user_id = ?", *filter.UserID)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("questions.created_at > ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("questions.created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.Answered != nil {
+		if *filter.Answered {
+			query = query.Where("questions.answer_count > 0")
+		} else {
+			query = query.Where("questions.answer_count = 0")
+		}
+	}
+	if filter.Accepted != nil {
+		if *filter.Accepted {
+			query = query.Where("questions.accepted_answer_id IS NOT NULL")
+		} else {
+			query = query.Where("questions.accepted_answer_id IS NULL")
+		}
+	}
+	for _, tag := range filter.Tags {
+		query = query.Where(`EXISTS (SELECT 1 FROM question_tags JOIN tags ON tags.id = question_tags.tag_id
+			WHERE question_tags.question_id = questions.id AND tags.name = ?)`, tag)
+	}
+	if filter.MinAnswers > 0 {
+		query = query.Where("questions.answer_count >= ?", filter.MinAnswers)
+	}
 
 	var gormQuestions []Question
-	result := r.db.Find(&gormQuestions)
+	result := query.Scopes(paginate("questions", order, filter.Page)).Find(&gormQuestions)
 	if result.Error != nil {
-		r.logger.Error("failed to get all questions", "error", result.Error)
-		return nil, result.Error
+		r.logger.Error("failed to find questions", "error", result.Error)
+		return nil, translate(result.Error, "question")
 	}
 
-	questions := make([]entity.Question, len(gormQuestions))
-	for i, gq := range gormQuestions {
-		questions[i] = r.toEntity(gq)
+	questions, err := r.withTags(gormQuestions)
+	if err != nil {
+		r.logger.Error("failed to load question tags", "error", err)
+		return nil, translate(err, "question")
 	}
 
 	r.logger.Debug("retrieved questions", "count", len(questions))
@@ -43,67 +93,354 @@ func (r *GormQuestionRepository) GetByID(id int) (entity.Question, error) {
 	r.logger.Debug("getting question by ID", "question_id", id)
 
 	var gormQuestion Question
-	result := r.db.First(&gormQuestion, id)
+	result := r.db.Preload("Author").First(&gormQuestion, id)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
 			r.logger.Warn("question not found", "question_id", id)
-			return entity.Question{}, result.Error
+			return entity.Question{}, translate(result.Error, "question")
 		}
 		r.logger.Error("failed to get question", "question_id", id, "error", result.Error)
-		return entity.Question{}, result.Error
+		return entity.Question{}, translate(result.Error, "question")
 	}
 
 	r.logger.Debug("question found", "question_id", id)
-	return r.toEntity(gormQuestion), nil
+	return r.withTagsOne(gormQuestion)
 }
 
 func (r *GormQuestionRepository) Save(question entity.Question) (entity.Question, error) {
 	r.logger.Debug("saving question", "user_id", question.UserID, "text_length", len(question.Text))
 
 	gormQuestion := r.toGormModel(question)
+	if gormQuestion.CreatedAt.IsZero() {
+		gormQuestion.CreatedAt = time.Now()
+	}
+	if gormQuestion.LastActivityAt.IsZero() {
+		gormQuestion.LastActivityAt = gormQuestion.CreatedAt
+	}
+	if gormQuestion.ChangedAt.IsZero() {
+		gormQuestion.ChangedAt = gormQuestion.CreatedAt
+	}
+	if gormQuestion.Version == 0 {
+		gormQuestion.Version = 1
+	}
 
-	result := r.db.Create(&gormQuestion)
-	if result.Error != nil {
-		r.logger.Error("failed to save question", "error", result.Error, "user_id", question.UserID)
-		return entity.Question{}, result.Error
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&gormQuestion).Error; err != nil {
+			return err
+		}
+		return saveTags(tx, gormQuestion.ID, question.Tags)
+	})
+	if err != nil {
+		r.logger.Error("failed to save question", "error", err, "user_id", question.UserID)
+		return entity.Question{}, translate(err, "question")
 	}
 	savedQuestion := r.toEntity(gormQuestion)
+	savedQuestion.Tags = question.Tags
+	if savedQuestion.Tags == nil {
+		savedQuestion.Tags = []string{}
+	}
 
 	r.logger.Info("question saved successfully", "question_id", gormQuestion.ID)
 	return savedQuestion, nil
 }
 
-func (r *GormQuestionRepository) Delete(id int) error {
-	r.logger.Debug("deleting question", "question_id", id)
+func (r *GormQuestionRepository) Update(question entity.Question) (entity.Question, error) {
+	r.logger.Debug("updating question", "question_id", question.ID, "text_length", len(question.Text))
+
+	var gormQuestion Question
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Author").First(&gormQuestion, question.ID).Error; err != nil {
+			return err
+		}
+		if gormQuestion.Version != question.Version {
+			return usecase.ErrVersionMismatch
+		}
+
+		var count int64
+		if err := tx.Model(&QuestionRevision{}).Where("question_id = ?", question.ID).Count(&count).Error; err != nil {
+			return err
+		}
+
+		revision := QuestionRevision{
+			QuestionID: question.ID,
+			Revision:   int(count) + 1,
+			Text:       gormQuestion.Text,
+			CreatedAt:  writtenAt(gormQuestion.CreatedAt, gormQuestion.UpdatedAt),
+		}
+		if err := tx.Create(&revision).Error; err != nil {
+			return err
+		}
+
+		gormQuestion.Text = question.Text
+		gormQuestion.UpdatedAt = question.UpdatedAt
+		gormQuestion.ChangedAt = time.Now()
+		gormQuestion.Version++
+		return tx.Model(&gormQuestion).Select("text", "updated_at", "changed_at", "version").Updates(&gormQuestion).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.logger.Warn("question not found for update", "question_id", question.ID)
+			return entity.Question{}, translate(err, "question")
+		}
+		if err == usecase.ErrVersionMismatch {
+			r.logger.Warn("question changed concurrently", "question_id", question.ID, "version", question.Version)
+			return entity.Question{}, translate(err, "question")
+		}
+		r.logger.Error("failed to update question", "question_id", question.ID, "error", err)
+		return entity.Question{}, translate(err, "question")
+	}
 
-	result := r.db.Delete(&Question{}, id)
+	r.logger.Info("question updated successfully", "question_id", question.ID)
+	return r.withTagsOne(gormQuestion)
+}
+
+func (r *GormQuestionRepository) ListRevisions(id int) ([]entity.Revision, error) {
+	r.logger.Debug("listing question revisions", "question_id", id)
+
+	var gormRevisions []QuestionRevision
+	result := r.db.Where("question_id = ?", id).Order("revision").Find(&gormRevisions)
 	if result.Error != nil {
-		r.logger.Error("failed to delete question", "question_id", id, "error", result.Error)
-		return result.Error
+		r.logger.Error("failed to list question revisions", "question_id", id, "error", result.Error)
+		return nil, translate(result.Error, "question")
 	}
 
-	if result.RowsAffected == 0 {
-		r.logger.Warn("question not found for deletion", "question_id", id)
-		return gorm.ErrRecordNotFound
+	return questionRevisionsToEntity(gormRevisions), nil
+}
+
+// Delete moves the question and its answers to the trash with the same deletion time.
+// The question must still have the given version.
+func (r *GormQuestionRepository) Delete(id int, version int) error {
+	r.logger.Debug("deleting question", "question_id", id)
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		return trashQuestion(tx, id, &version, time.Now())
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.logger.Warn("question not found for deletion", "question_id", id)
+			return translate(err, "question")
+		}
+		if err == usecase.ErrVersionMismatch {
+			r.logger.Warn("question changed concurrently", "question_id", id, "version", version)
+			return translate(err, "question")
+		}
+		r.logger.Error("failed to delete question", "question_id", id, "error", err)
+		return translate(err, "question")
 	}
 
 	r.logger.Info("question deleted successfully", "question_id", id)
 	return nil
 }
+
+// trashQuestion moves the question to the trash together with its answers, they share the time
+// of deletion so Restore can tell them from answers deleted before. A nil version deletes
+// whatever version is current.
+func trashQuestion(tx *gorm.DB, id int, version *int, now time.Time) error {
+	query := tx.Model(&Question{}).Where("id = ?", id)
+	if version != nil {
+		query = query.Where("version = ?", *version)
+	}
+	result := query.Updates(map[string]interface{}{"deleted_at": now, "changed_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		if err := tx.Model(&Question{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return usecase.ErrVersionMismatch
+	}
+
+	return tx.Model(&Answer{}).Where("question_id = ?", id).Update("deleted_at", now).Error
+}
+
+// touchQuestion moves changed_at of the question, so Last-Modified of its page notices the change.
+func touchQuestion(tx *gorm.DB, id int, at time.Time) error {
+	return tx.Unscoped().Model(&Question{}).Where("id = ?", id).Update("changed_at", at).Error
+}
+
+// Restore brings the question back from the trash together with answers deleted along with it.
+func (r *GormQuestionRepository) Restore(id int) (entity.Question, error) {
+	r.logger.Debug("restoring question", "question_id", id)
+
+	var gormQuestion Question
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(onlyDeleted("questions")).Preload("Author").First(&gormQuestion, id).Error; err != nil {
+			return err
+		}
+
+		err := tx.Unscoped().Model(&Answer{}).
+			Where("question_id = ? AND deleted_at = ?", id, gormQuestion.DeletedAt.Time).
+			Update("deleted_at", nil).Error
+		if err != nil {
+			return err
+		}
+
+		gormQuestion.DeletedAt = gorm.DeletedAt{}
+		gormQuestion.ChangedAt = time.Now()
+		return tx.Unscoped().Model(&Question{}).Where("id = ?", id).
+			Updates(map[string]interface{}{"deleted_at": nil, "changed_at": gormQuestion.ChangedAt}).Error
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			r.logger.Warn("question not found in trash", "question_id", id)
+			return entity.Question{}, translate(err, "question")
+		}
+		r.logger.Error("failed to restore question", "question_id", id, "error", err)
+		return entity.Question{}, translate(err, "question")
+	}
+
+	r.logger.Info("question restored successfully", "question_id", id)
+	return r.withTagsOne(gormQuestion)
+}
+
+func (r *GormQuestionRepository) GetDeleted(id int) (entity.Question, error) {
+	r.logger.Debug("getting deleted question", "question_id", id)
+
+	var gormQuestion Question
+	result := r.db.Scopes(onlyDeleted("questions")).Preload("Author").First(&gormQuestion, id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			r.logger.Warn("question not found in trash", "question_id", id)
+			return entity.Question{}, translate(result.Error, "question")
+		}
+		r.logger.Error("failed to get deleted question", "question_id", id, "error", result.Error)
+		return entity.Question{}, translate(result.Error, "question")
+	}
+
+	return r.withTagsOne(gormQuestion)
+}
+
+func (r *GormQuestionRepository) ListDeleted(page entity.PageRequest) ([]entity.Question, error) {
+	r.logger.Debug("listing deleted questions", "limit", page.Limit)
+
+	var gormQuestions []Question
+	result := r.db.Preload("Author").Scopes(onlyDeleted("questions"), paginate("questions", byTime("deleted_at", true), page)).Find(&gormQuestions)
+	if result.Error != nil {
+		r.logger.Error("failed to list deleted questions", "error", result.Error)
+		return nil, translate(result.Error, "question")
+	}
+
+	questions, err := r.withTags(gormQuestions)
+	if err != nil {
+		r.logger.Error("failed to load question tags", "error", err)
+		return nil, translate(err, "question")
+	}
+
+	return questions, nil
+}
+
+// Purge permanently removes questions deleted before the given time.
+func (r *GormQuestionRepository) Purge(before time.Time) (int64, error) {
+	r.logger.Debug("purging deleted questions", "before", before)
+
+	var purged int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var ids []int
+		if err := tx.Unscoped().Model(&Question{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("deleted_at < ?", before).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		// Answers go with their question, whether they are in the trash or not.
+		var answerIDs []int
+		if err := tx.Unscoped().Model(&Answer{}).Where("question_id IN ?", ids).Pluck("id", &answerIDs).Error; err != nil {
+			return err
+		}
+		if err := purgeTargets(tx, entity.VoteTargetAnswer, answerIDs); err != nil {
+			return err
+		}
+		if err := purgeTargets(tx, entity.VoteTargetQuestion, ids); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("question_id IN ?", ids).Delete(&Answer{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&Question{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		r.logger.Error("failed to purge questions", "error", err)
+		return 0, translate(err, "question")
+	}
+
+	r.logger.Info("questions purged", "count", purged)
+	return purged, nil
+}
+
+// withTags converts questions to entities, loading tags of all of them with one query.
+func (r *GormQuestionRepository) withTags(gormQuestions []Question) ([]entity.Question, error) {
+	ids := make([]int, len(gormQuestions))
+	for i, gq := range gormQuestions {
+		ids[i] = gq.ID
+	}
+
+	tags, err := loadTags(r.db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	questions := make([]entity.Question, len(gormQuestions))
+	for i, gq := range gormQuestions {
+		questions[i] = r.toEntity(gq)
+		questions[i].Tags = tags[gq.ID]
+		if questions[i].Tags == nil {
+			questions[i].Tags = []string{}
+		}
+	}
+	return questions, nil
+}
+
+func (r *GormQuestionRepository) withTagsOne(gormQuestion Question) (entity.Question, error) {
+	questions, err := r.withTags([]Question{gormQuestion})
+	if err != nil {
+		r.logger.Error("failed to load question tags", "question_id", gormQuestion.ID, "error", err)
+		return entity.Question{}, translate(err, "question")
+	}
+	return questions[0], nil
+}
+
 func (r *GormQuestionRepository) toEntity(gormQuestion Question) entity.Question {
 	return entity.Question{
-		ID:        gormQuestion.ID,
-		UserID:    gormQuestion.UserID,
-		Text:      gormQuestion.Text,
-		CreatedAt: gormQuestion.CreatedAt,
+		ID:               gormQuestion.ID,
+		UserID:           gormQuestion.UserID,
+		Author:           userSummaryToEntity(gormQuestion.Author),
+		Text:             gormQuestion.Text,
+		AnswerCount:      gormQuestion.AnswerCount,
+		Score:            gormQuestion.Score,
+		AcceptedAnswerID: gormQuestion.AcceptedAnswerID,
+		Version:          gormQuestion.Version,
+		LastActivityAt:   gormQuestion.LastActivityAt,
+		ChangedAt:        gormQuestion.ChangedAt,
+		CreatedAt:        gormQuestion.CreatedAt,
+		UpdatedAt:        gormQuestion.UpdatedAt,
+		DeletedAt:        deletedAtToEntity(gormQuestion.DeletedAt),
+		HiddenAt:         gormQuestion.HiddenAt,
+		LockedAt:         gormQuestion.LockedAt,
 	}
 }
 
 func (r *GormQuestionRepository) toGormModel(question entity.Question) Question {
 	return Question{
-		ID:        question.ID,
-		UserID:    question.UserID,
-		Text:      question.Text,
-		CreatedAt: question.CreatedAt,
+		ID:               question.ID,
+		UserID:           question.UserID,
+		Text:             question.Text,
+		AnswerCount:      question.AnswerCount,
+		Score:            question.Score,
+		AcceptedAnswerID: question.AcceptedAnswerID,
+		Version:          question.Version,
+		LastActivityAt:   question.LastActivityAt,
+		ChangedAt:        question.ChangedAt,
+		CreatedAt:        question.CreatedAt,
+		UpdatedAt:        question.UpdatedAt,
 	}
 }
@@ -0,0 +1,102 @@
+package repositoriy
+
+import (
+	"testovoe/internal/entity"
+	"testovoe/internal/pkg"
+	"testovoe/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type GormRefreshTokenRepository struct {
+	db     *gorm.DB
+	logger pkg.Logger
+}
+
+func NewGormRefreshTokenRepository(db *gorm.DB, logger pkg.Logger) usecase.RefreshTokenRepositoriy {
+	if logger == nil {
+		logger = pkg.NewNopLogger()
+	}
+	return &GormRefreshTokenRepository{
+		db:     db,
+		logger: logger.WithFields(map[string]interface{}{"component": "refresh_token_repository"}),
+	}
+}
+
+func (r *GormRefreshTokenRepository) Save(token entity.RefreshToken) (entity.RefreshToken, error) {
+	r.logger.Debug("saving refresh token", "user_id", token.UserID)
+
+	gormToken := r.toGormModel(token)
+	result := r.db.Create(&gormToken)
+	if result.Error != nil {
+		r.logger.Error("failed to save refresh token", "user_id", token.UserID, "error", result.Error)
+		return entity.RefreshToken{}, translate(result.Error, "refresh token")
+	}
+
+	return r.toEntity(gormToken), nil
+}
+
+func (r *GormRefreshTokenRepository) GetByHash(hash string) (entity.RefreshToken, error) {
+	var gormToken RefreshToken
+	result := r.db.First(&gormToken, "token_hash = ?", hash)
+	if result.Error != nil {
+		if result.Error != gorm.ErrRecordNotFound {
+			r.logger.Error("failed to get refresh token", "error", result.Error)
+		}
+		return entity.RefreshToken{}, translate(result.Error, "refresh token")
+	}
+
+	return r.toEntity(gormToken), nil
+}
+
+// Revoke only touches a token that is still active, so of two concurrent refreshes only one wins.
+func (r *GormRefreshTokenRepository) Revoke(hash string, at time.Time) (bool, error) {
+	result := r.db.Model(&RefreshToken{}).
+		Where("token_hash = ? AND revoked_at IS NULL", hash).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		r.logger.Error("failed to revoke refresh token", "error", result.Error)
+		return false, translate(result.Error, "refresh token")
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (r *GormRefreshTokenRepository) RevokeAll(userID uuid.UUID, at time.Time) error {
+	r.logger.Warn("revoking all refresh tokens", "user_id", userID)
+
+	result := r.db.Model(&RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		r.logger.Error("failed to revoke refresh tokens", "user_id", userID, "error", result.Error)
+		return translate(result.Error, "refresh token")
+	}
+
+	r.logger.Info("refresh tokens revoked", "user_id", userID, "count", result.RowsAffected)
+	return nil
+}
+
+func (r *GormRefreshTokenRepository) toEntity(gormToken RefreshToken) entity.RefreshToken {
+	return entity.RefreshToken{
+		ID:        gormToken.ID,
+		UserID:    gormToken.UserID,
+		TokenHash: gormToken.TokenHash,
+		ExpiresAt: gormToken.ExpiresAt,
+		CreatedAt: gormToken.CreatedAt,
+		RevokedAt: gormToken.RevokedAt,
+	}
+}
+
+func (r *GormRefreshTokenRepository) toGormModel(token entity.RefreshToken) RefreshToken {
+	return RefreshToken{
+		ID:        token.ID,
+		UserID:    token.UserID,
+		TokenHash: token.TokenHash,
+		ExpiresAt: token.ExpiresAt,
+		CreatedAt: token.CreatedAt,
+		RevokedAt: token.RevokedAt,
+	}
+}
@@ -2,9 +2,12 @@
 package repositoriy_test
 
 import (
+	"strings"
 	"testing"
 	"testovoe/internal/entity"
 	"testovoe/internal/repositoriy"
+	"testovoe/internal/usecase"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -13,9 +16,9 @@ import (
 )
 
 func setupTestDB(t *testing.T) *gorm.DB {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
 	assert.NoError(t, err)
-	db.AutoMigrate(&repositoriy.Question{})
+	db.AutoMigrate(&repositoriy.Question{}, &repositoriy.Answer{}, &repositoriy.QuestionRevision{}, &repositoriy.AnswerRevision{}, &repositoriy.Vote{}, &repositoriy.Tag{}, &repositoriy.QuestionTag{}, &repositoriy.Comment{}, &repositoriy.User{}, &repositoriy.APIKey{}, &repositoriy.IdempotencyKey{}, &repositoriy.HeldContent{}, &repositoriy.Flag{}, &repositoriy.ModerationDecision{})
 	return db
 }
 
@@ -46,17 +49,729 @@ func TestQuestionRepository_GetByID(t *testing.T) {
 	found, err := repo.GetByID(saved.ID)
 	assert.NoError(t, err)
 	assert.Equal(t, saved.ID, found.ID)
+
+	_, err = repo.GetByID(saved.ID + 1)
+	assert.Equal(t, usecase.KindNotFound, usecase.KindOf(err))
 }
 
-func TestQuestionRepository_GetAll(t *testing.T) {
+func TestQuestionRepository_Find(t *testing.T) {
 	db := setupTestDB(t)
 	repo := repositoriy.NewGormQuestionRepository(db, nil)
 	userID := uuid.New()
 
-	repo.Save(entity.Question{UserID: userID, Text: "Q1"})
-	repo.Save(entity.Question{UserID: userID, Text: "Q2"})
+	now := time.Now()
+	repo.Save(entity.Question{UserID: userID, Text: "Q1", CreatedAt: now})
+	repo.Save(entity.Question{UserID: userID, Text: "Q2", CreatedAt: now})
+	repo.Save(entity.Question{UserID: userID, Text: "Q3", CreatedAt: now.Add(time.Minute)})
+
+	questions, err := repo.Find(entity.QuestionFilter{Page: entity.PageRequest{Limit: 10}})
+	assert.NoError(t, err)
+	assert.Len(t, questions, 3)
+
+	firstPage, err := repo.Find(entity.QuestionFilter{Page: entity.PageRequest{Limit: 1}})
+	assert.NoError(t, err)
+	assert.Len(t, firstPage, 1)
+	assert.Equal(t, "Q1", firstPage[0].Text)
+
+	after := &entity.Cursor{Time: firstPage[0].CreatedAt, ID: firstPage[0].ID}
+	rest, err := repo.Find(entity.QuestionFilter{Page: entity.PageRequest{After: after, Limit: 10}})
+	assert.NoError(t, err)
+	assert.Len(t, rest, 2)
+	assert.Equal(t, "Q2", rest[0].Text)
+	assert.Equal(t, "Q3", rest[1].Text)
+}
+
+func TestTagRepository(t *testing.T) {
+	db := setupTestDB(t)
+	questionRepo := repositoriy.NewGormQuestionRepository(db, nil)
+	tagRepo := repositoriy.NewGormTagRepository(db, nil)
+	userID := uuid.New()
+
+	q1, err := questionRepo.Save(entity.Question{UserID: userID, Text: "Q1", Tags: []string{"go", "sql"}})
+	assert.NoError(t, err)
+	questionRepo.Save(entity.Question{UserID: userID, Text: "Q2", Tags: []string{"go"}})
+	q3, _ := questionRepo.Save(entity.Question{UserID: userID, Text: "Q3", Tags: []string{"go", "docker"}})
+	questionRepo.Save(entity.Question{UserID: userID, Text: "Q4"})
+
+	found, _ := questionRepo.GetByID(q1.ID)
+	assert.Equal(t, []string{"go", "sql"}, found.Tags)
+
+	questions, err := questionRepo.Find(entity.QuestionFilter{Tags: []string{"go", "sql"}, Page: entity.PageRequest{Limit: 10}})
+	assert.NoError(t, err)
+	assert.Len(t, questions, 1)
+	assert.Equal(t, "Q1", questions[0].Text)
+
+	assert.NoError(t, questionRepo.Delete(q3.ID, 1))
+
+	tags, err := tagRepo.List(entity.PageRequest{Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, tags, 2)
+	assert.Equal(t, "go", tags[0].Name)
+	assert.Equal(t, 2, tags[0].QuestionCount)
+	assert.Equal(t, "sql", tags[1].Name)
+
+	after := &entity.Cursor{Count: tags[0].QuestionCount, ID: tags[0].ID}
+	rest, err := tagRepo.List(entity.PageRequest{After: after, Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, rest, 1)
+	assert.Equal(t, "sql", rest[0].Name)
+}
+
+func TestQuestionRepository_FindFiltered(t *testing.T) {
+	db := setupTestDB(t)
+	questionRepo := repositoriy.NewGormQuestionRepository(db, nil)
+	answerRepo := repositoriy.NewGormAnswerRepository(db, nil)
+	alice, bob := uuid.New(), uuid.New()
+
+	now := time.Now()
+	unanswered, _ := questionRepo.Save(entity.Question{UserID: alice, Text: "unanswered", CreatedAt: now.Add(-2 * time.Hour)})
+	once, _ := questionRepo.Save(entity.Question{UserID: alice, Text: "once", CreatedAt: now.Add(-time.Hour)})
+	twice, _ := questionRepo.Save(entity.Question{UserID: bob, Text: "twice", CreatedAt: now})
+
+	answerRepo.Save(entity.Answer{QuestionID: twice.ID, UserID: alice, Text: "a1", CreatedAt: now})
+	answerRepo.Save(entity.Answer{QuestionID: twice.ID, UserID: alice, Text: "a2", CreatedAt: now})
+	answerRepo.Save(entity.Answer{QuestionID: once.ID, UserID: bob, Text: "a3", CreatedAt: now.Add(time.Minute)})
+
+	page := entity.PageRequest{Limit: 10}
+	texts := func(questions []entity.Question) []string {
+		result := make([]string, len(questions))
+		for i, q := range questions {
+			result[i] = q.Text
+		}
+		return result
+	}
+
+	answered, unansweredOnly := true, false
+
+	found, err := questionRepo.Find(entity.QuestionFilter{UserID: &alice, Page: page})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"unanswered", "once"}, texts(found))
+
+	found, err = questionRepo.Find(entity.QuestionFilter{Answered: &unansweredOnly, Page: page})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{unanswered.Text}, texts(found))
+
+	found, err = questionRepo.Find(entity.QuestionFilter{Answered: &answered, Sort: entity.SortNewest, Page: page})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"twice", "once"}, texts(found))
+
+	found, err = questionRepo.Find(entity.QuestionFilter{MinAnswers: 2, Page: page})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"twice"}, texts(found))
+
+	after := now.Add(-90 * time.Minute)
+	found, err = questionRepo.Find(entity.QuestionFilter{CreatedAfter: &after, Page: page})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"once", "twice"}, texts(found))
+
+	found, err = questionRepo.Find(entity.QuestionFilter{Sort: entity.SortMostAnswered, Page: page})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"twice", "once", "unanswered"}, texts(found))
+	assert.Equal(t, 2, found[0].AnswerCount)
+
+	found, err = questionRepo.Find(entity.QuestionFilter{Sort: entity.SortRecentActivity, Page: page})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"once", "twice", "unanswered"}, texts(found))
+
+	cursor := &entity.Cursor{Count: found[0].AnswerCount, ID: once.ID}
+	found, err = questionRepo.Find(entity.QuestionFilter{Sort: entity.SortMostAnswered, Page: entity.PageRequest{After: cursor, Limit: 10}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"unanswered"}, texts(found))
+}
+
+func TestAnswerRepository_Delete(t *testing.T) {
+	db := setupTestDB(t)
+	questionRepo := repositoriy.NewGormQuestionRepository(db, nil)
+	answerRepo := repositoriy.NewGormAnswerRepository(db, nil)
+	userID := uuid.New()
+
+	question, _ := questionRepo.Save(entity.Question{UserID: userID, Text: "Q"})
+	answer, _ := answerRepo.Save(entity.Answer{QuestionID: question.ID, UserID: userID, Text: "A"})
+
+	found, _ := questionRepo.GetByID(question.ID)
+	assert.Equal(t, 1, found.AnswerCount)
+
+	assert.NoError(t, answerRepo.Delete(answer.ID, 1))
+	found, _ = questionRepo.GetByID(question.ID)
+	assert.Equal(t, 0, found.AnswerCount)
+
+	assert.Error(t, answerRepo.Delete(answer.ID, 1))
+}
+
+func TestAnswerRepository_Accept(t *testing.T) {
+	db := setupTestDB(t)
+	questionRepo := repositoriy.NewGormQuestionRepository(db, nil)
+	answerRepo := repositoriy.NewGormAnswerRepository(db, nil)
+	userID := uuid.New()
+
+	question, _ := questionRepo.Save(entity.Question{UserID: userID, Text: "Q"})
+	first, _ := answerRepo.Save(entity.Answer{QuestionID: question.ID, UserID: userID, Text: "A1"})
+	second, _ := answerRepo.Save(entity.Answer{QuestionID: question.ID, UserID: userID, Text: "A2"})
+
+	assert.NoError(t, answerRepo.Accept(question.ID, &first.ID))
+	assert.NoError(t, answerRepo.Accept(question.ID, &second.ID))
+
+	found, _ := questionRepo.GetByID(question.ID)
+	assert.Equal(t, second.ID, *found.AcceptedAnswerID)
+	a1, _ := answerRepo.GetByID(first.ID)
+	a2, _ := answerRepo.GetByID(second.ID)
+	assert.False(t, a1.IsAccepted)
+	assert.True(t, a2.IsAccepted)
+
+	accepted := true
+	questions, err := questionRepo.Find(entity.QuestionFilter{Accepted: &accepted, Sort: entity.SortNewest, Page: entity.PageRequest{Limit: 10}})
+	assert.NoError(t, err)
+	assert.Len(t, questions, 1)
+
+	assert.NoError(t, answerRepo.Delete(second.ID, 1))
+	found, _ = questionRepo.GetByID(question.ID)
+	assert.Nil(t, found.AcceptedAnswerID)
+}
+
+func TestAnswerRepository_ListByQuestion(t *testing.T) {
+	db := setupTestDB(t)
+	questionRepo := repositoriy.NewGormQuestionRepository(db, nil)
+	answerRepo := repositoriy.NewGormAnswerRepository(db, nil)
+	userID := uuid.New()
+
+	q1, _ := questionRepo.Save(entity.Question{UserID: userID, Text: "Q1"})
+	q2, _ := questionRepo.Save(entity.Question{UserID: userID, Text: "Q2"})
+
+	now := time.Now()
+	answerRepo.Save(entity.Answer{QuestionID: q1.ID, UserID: userID, Text: "second", CreatedAt: now.Add(time.Minute)})
+	answerRepo.Save(entity.Answer{QuestionID: q1.ID, UserID: userID, Text: "first", CreatedAt: now})
+	answerRepo.Save(entity.Answer{QuestionID: q2.ID, UserID: userID, Text: "other", CreatedAt: now})
+
+	answers, err := answerRepo.ListByQuestion(q1.ID, entity.AnswerSortOldest, entity.PageRequest{Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, answers, 2)
+	assert.Equal(t, "first", answers[0].Text)
+	assert.Equal(t, "second", answers[1].Text)
+}
+
+func TestQuestionRepository_Update(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repositoriy.NewGormQuestionRepository(db, nil)
+	userID := uuid.New()
+
+	saved, _ := repo.Save(entity.Question{UserID: userID, Text: "First"})
+
+	for _, text := range []string{"Second", "Third"} {
+		question, _ := repo.GetByID(saved.ID)
+		now := time.Now()
+		question.Text = text
+		question.UpdatedAt = &now
+
+		updated, err := repo.Update(question)
+		assert.NoError(t, err)
+		assert.Equal(t, text, updated.Text)
+		assert.NotNil(t, updated.UpdatedAt)
+	}
+
+	found, _ := repo.GetByID(saved.ID)
+	assert.Equal(t, "Third", found.Text)
+	assert.Equal(t, 3, found.Version)
+
+	stale := found
+	stale.Version = 2
+	stale.Text = "Lost update"
+	_, err := repo.Update(stale)
+	assert.ErrorIs(t, err, usecase.ErrVersionMismatch)
+	assert.ErrorIs(t, repo.Delete(saved.ID, 2), usecase.ErrVersionMismatch)
+
+	revisions, err := repo.ListRevisions(saved.ID)
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 2)
+	assert.Equal(t, 1, revisions[0].Revision)
+	assert.Equal(t, "First", revisions[0].Text)
+	assert.Equal(t, "Second", revisions[1].Text)
+
+	_, err = repo.Update(entity.Question{ID: 999, Text: "Missing"})
+	assert.Error(t, err)
+}
+
+func TestQuestionRepository_SoftDelete(t *testing.T) {
+	db := setupTestDB(t)
+	questionRepo := repositoriy.NewGormQuestionRepository(db, nil)
+	answerRepo := repositoriy.NewGormAnswerRepository(db, nil)
+	userID := uuid.New()
+
+	question, _ := questionRepo.Save(entity.Question{UserID: userID, Text: "Q"})
+	kept, _ := answerRepo.Save(entity.Answer{QuestionID: question.ID, UserID: userID, Text: "kept"})
+	removed, _ := answerRepo.Save(entity.Answer{QuestionID: question.ID, UserID: userID, Text: "removed"})
+
+	assert.NoError(t, answerRepo.Delete(removed.ID, 1))
+	assert.NoError(t, questionRepo.Delete(question.ID, 1))
+
+	_, err := questionRepo.GetByID(question.ID)
+	assert.Error(t, err)
+	_, err = answerRepo.GetByID(kept.ID)
+	assert.Error(t, err)
+
+	deleted, err := questionRepo.ListDeleted(entity.PageRequest{Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, deleted, 1)
+	assert.NotNil(t, deleted[0].DeletedAt)
+
+	_, err = answerRepo.Restore(kept.ID)
+	assert.Error(t, err, "answer of a deleted question can not be restored")
+
+	restored, err := questionRepo.Restore(question.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, restored.DeletedAt)
+	assert.Equal(t, 1, restored.AnswerCount)
+
+	answers, _ := answerRepo.ListByQuestion(question.ID, entity.AnswerSortOldest, entity.PageRequest{Limit: 10})
+	assert.Len(t, answers, 1)
+	assert.Equal(t, "kept", answers[0].Text)
+
+	_, err = questionRepo.Restore(question.ID)
+	assert.Error(t, err, "question is not in the trash anymore")
+
+	_, err = answerRepo.Restore(removed.ID)
+	assert.NoError(t, err)
+	found, _ := questionRepo.GetByID(question.ID)
+	assert.Equal(t, 2, found.AnswerCount)
+}
+
+func TestRepositories_Purge(t *testing.T) {
+	db := setupTestDB(t)
+	questionRepo := repositoriy.NewGormQuestionRepository(db, nil)
+	answerRepo := repositoriy.NewGormAnswerRepository(db, nil)
+	userID := uuid.New()
+
+	old, _ := questionRepo.Save(entity.Question{UserID: userID, Text: "old"})
+	oldAnswer, _ := answerRepo.Save(entity.Answer{QuestionID: old.ID, UserID: userID, Text: "old answer"})
+	aliveQuestion, _ := questionRepo.Save(entity.Question{UserID: userID, Text: "alive"})
+
+	for _, target := range []struct {
+		kind string
+		id   int
+	}{{"question", old.ID}, {"answer", oldAnswer.ID}, {"question", aliveQuestion.ID}} {
+		assert.NoError(t, db.Create(&repositoriy.Comment{TargetType: target.kind, TargetID: target.id, UserID: userID, Text: "comment"}).Error)
+		assert.NoError(t, db.Create(&repositoriy.Vote{UserID: userID, TargetType: target.kind, TargetID: target.id, Value: 1}).Error)
+		assert.NoError(t, db.Create(&repositoriy.Flag{TargetType: target.kind, TargetID: target.id, UserID: userID, Reason: "spam"}).Error)
+	}
+	assert.NoError(t, questionRepo.Delete(old.ID, 1))
+
+	answers, err := answerRepo.Purge(time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Zero(t, answers)
+
+	answers, err = answerRepo.Purge(time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), answers)
+
+	questions, err := questionRepo.Purge(time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), questions)
+
+	deleted, _ := questionRepo.ListDeleted(entity.PageRequest{Limit: 10})
+	assert.Empty(t, deleted)
+
+	alive, _ := questionRepo.Find(entity.QuestionFilter{Page: entity.PageRequest{Limit: 10}})
+	assert.Len(t, alive, 1)
+
+	for _, model := range []interface{}{&repositoriy.Comment{}, &repositoriy.Vote{}, &repositoriy.Flag{}} {
+		var left []int
+		db.Model(model).Distinct().Pluck("target_id", &left)
+		assert.Equal(t, []int{aliveQuestion.ID}, left, "comments, votes and flags go with their purged target")
+	}
+}
+
+func TestVoteRepository(t *testing.T) {
+	db := setupTestDB(t)
+	questionRepo := repositoriy.NewGormQuestionRepository(db, nil)
+	answerRepo := repositoriy.NewGormAnswerRepository(db, nil)
+	voteRepo := repositoriy.NewGormVoteRepository(db, nil)
+	alice, bob := uuid.New(), uuid.New()
+
+	question, _ := questionRepo.Save(entity.Question{UserID: alice, Text: "Q"})
+	first, _ := answerRepo.Save(entity.Answer{QuestionID: question.ID, UserID: alice, Text: "first"})
+	second, _ := answerRepo.Save(entity.Answer{QuestionID: question.ID, UserID: bob, Text: "second"})
+
+	vote := func(userID uuid.UUID, answerID, value int) int {
+		score, err := voteRepo.Save(entity.Vote{UserID: userID, TargetType: entity.VoteTargetAnswer, TargetID: answerID, Value: value})
+		assert.NoError(t, err)
+		return score
+	}
+
+	assert.Equal(t, 1, vote(alice, second.ID, entity.VoteUp))
+	assert.Equal(t, 2, vote(bob, second.ID, entity.VoteUp))
+	assert.Equal(t, 2, vote(bob, second.ID, entity.VoteUp), "same vote twice counts once")
+	assert.Equal(t, 0, vote(bob, second.ID, entity.VoteDown))
+	assert.Equal(t, -1, vote(alice, first.ID, entity.VoteDown))
+
+	score, err := voteRepo.Delete(bob, entity.VoteTargetAnswer, second.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, score)
+
+	score, err = voteRepo.Delete(bob, entity.VoteTargetAnswer, second.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, score, "retracting twice is harmless")
+
+	answers, err := answerRepo.ListByQuestion(question.ID, entity.AnswerSortScore, entity.PageRequest{Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, "second", answers[0].Text)
+	assert.Equal(t, 1, answers[0].Score)
+	assert.Equal(t, "first", answers[1].Text)
+
+	score, err = voteRepo.Save(entity.Vote{UserID: alice, TargetType: entity.VoteTargetQuestion, TargetID: question.ID, Value: entity.VoteUp})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, score)
+	found, _ := questionRepo.GetByID(question.ID)
+	assert.Equal(t, 1, found.Score)
+
+	_, err = voteRepo.Save(entity.Vote{UserID: alice, TargetType: entity.VoteTargetQuestion, TargetID: 999, Value: entity.VoteUp})
+	assert.Error(t, err)
+}
+
+func TestCommentRepository(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repositoriy.NewGormCommentRepository(db, nil)
+	userID := uuid.New()
+
+	now := time.Now()
+	repo.Save(entity.Comment{TargetType: entity.CommentTargetQuestion, TargetID: 1, UserID: userID, Text: "later", CreatedAt: now.Add(time.Minute)})
+	first, err := repo.Save(entity.Comment{TargetType: entity.CommentTargetQuestion, TargetID: 1, UserID: userID, Text: "first", CreatedAt: now})
+	assert.NoError(t, err)
+	repo.Save(entity.Comment{TargetType: entity.CommentTargetAnswer, TargetID: 1, UserID: userID, Text: "on answer", CreatedAt: now})
+
+	comments, err := repo.ListByTarget(entity.CommentTargetQuestion, 1, entity.PageRequest{Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, comments, 2)
+	assert.Equal(t, "first", comments[0].Text)
+
+	comments, err = repo.ListByTargets(entity.CommentTargetAnswer, []int{1, 2})
+	assert.NoError(t, err)
+	assert.Len(t, comments, 1)
+	assert.Equal(t, "on answer", comments[0].Text)
+
+	assert.NoError(t, repo.Delete(first.ID))
+	assert.Error(t, repo.Delete(first.ID))
+	_, err = repo.GetByID(first.ID)
+	assert.Error(t, err)
+}
+
+func TestUserRepository(t *testing.T) {
+	db := setupTestDB(t)
+	userRepo := repositoriy.NewGormUserRepository(db, nil)
+	questionRepo := repositoriy.NewGormQuestionRepository(db, nil)
+
+	user, err := userRepo.Save(entity.User{ID: uuid.New(), DisplayName: "Alice", Email: "alice@example.com", CreatedAt: time.Now()})
+	assert.NoError(t, err)
+
+	_, err = userRepo.Save(entity.User{ID: uuid.New(), DisplayName: "Other", Email: "alice@example.com", CreatedAt: time.Now()})
+	assert.Equal(t, usecase.KindConflict, usecase.KindOf(err))
+
+	found, err := userRepo.GetByEmail("alice@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+
+	user.DisplayName = "Alice B."
+	_, err = userRepo.Update(user)
+	assert.NoError(t, err)
+
+	assert.Equal(t, entity.RoleUser, found.Role)
+	promoted, err := userRepo.SetRole(user.ID, entity.RoleModerator)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.RoleModerator, promoted.Role)
+	assert.Equal(t, "Alice B.", promoted.DisplayName)
 
-	questions, err := repo.GetAll()
+	_, err = userRepo.SetRole(uuid.New(), entity.RoleAdmin)
+	assert.Error(t, err)
+
+	questionRepo.Save(entity.Question{UserID: user.ID, Text: "With author"})
+	questionRepo.Save(entity.Question{UserID: uuid.New(), Text: "Legacy author"})
+
+	questions, err := questionRepo.Find(entity.QuestionFilter{Page: entity.PageRequest{Limit: 10}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice B.", questions[0].Author.DisplayName)
+	assert.Nil(t, questions[1].Author)
+}
+
+func TestAPIKeyRepository(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repositoriy.NewGormAPIKeyRepository(db, nil)
+	userID := uuid.New()
+
+	saved, err := repo.Save(entity.APIKey{
+		UserID:    userID,
+		Name:      "bot",
+		Prefix:    "abcdefgh",
+		KeyHash:   strings.Repeat("0", 64),
+		Scopes:    []string{entity.ScopeQuestionsRead, entity.ScopeAnswersWrite},
+		CreatedAt: time.Now(),
+	})
+	assert.NoError(t, err)
+	assert.NotZero(t, saved.ID)
+
+	_, err = repo.Save(entity.APIKey{UserID: userID, Name: "copy", Prefix: "abcdefgh", Scopes: []string{entity.ScopeAdmin}})
+	assert.Error(t, err)
+
+	found, err := repo.GetByPrefix("abcdefgh")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{entity.ScopeQuestionsRead, entity.ScopeAnswersWrite}, found.Scopes)
+	assert.Nil(t, found.LastUsedAt)
+
+	assert.NoError(t, repo.Touch(saved.ID, time.Now()))
+	assert.NoError(t, repo.Revoke(saved.ID, time.Now()))
+
+	keys, err := repo.ListByUser(userID)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.NotNil(t, keys[0].LastUsedAt)
+	assert.NotNil(t, keys[0].RevokedAt)
+}
+
+func TestIdempotencyRepository(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repositoriy.NewGormIdempotencyRepository(db, nil)
+	userID := uuid.New()
+	record := entity.IdempotencyRecord{
+		UserID:      userID,
+		Key:         "retry-1",
+		Fingerprint: strings.Repeat("a", 64),
+		CreatedAt:   time.Now(),
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	created, err := repo.Create(record)
+	assert.NoError(t, err)
+	assert.True(t, created)
+
+	created, err = repo.Create(record)
+	assert.NoError(t, err)
+	assert.False(t, created)
+
+	record.Status = 201
+	record.ContentType = "application/json"
+	record.Body = []byte(`{"id": 1}`)
+	assert.NoError(t, repo.Complete(record))
+
+	found, err := repo.Get(userID, "retry-1")
+	assert.NoError(t, err)
+	assert.True(t, found.Completed())
+	assert.Equal(t, `{"id": 1}`, string(found.Body))
+
+	_, err = repo.Get(uuid.New(), "retry-1")
+	assert.Error(t, err)
+
+	retry := entity.IdempotencyRecord{UserID: userID, Key: "retry-1", Fingerprint: strings.Repeat("b", 64), CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	replaced, err := repo.Replace(found, retry)
+	assert.NoError(t, err)
+	assert.True(t, replaced)
+	replaced, err = repo.Replace(found, retry)
 	assert.NoError(t, err)
-	assert.Len(t, questions, 2)
+	assert.False(t, replaced)
+
+	assert.Error(t, repo.Complete(record))
+	assert.NoError(t, repo.Delete(record))
+	taken, err := repo.Get(userID, "retry-1")
+	assert.NoError(t, err)
+	assert.False(t, taken.Completed())
+	assert.Equal(t, retry.Fingerprint, taken.Fingerprint)
+
+	purged, err := repo.Purge(time.Now().Add(2 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+}
+
+func TestHeldContentRepository(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repositoriy.NewGormHeldContentRepository(db, nil)
+	userID := uuid.New()
+
+	first, err := repo.Save(entity.HeldContent{
+		Content:   entity.Content{Kind: entity.ContentQuestion, UserID: userID, Text: "Read https://example.com", Tags: []string{"go", "web"}},
+		Policy:    "links",
+		Reason:    "Text has more than 0 links",
+		CreatedAt: time.Now().Add(-time.Minute),
+	})
+	assert.NoError(t, err)
+	assert.NotZero(t, first.ID)
+
+	questionID := 1
+	_, err = repo.Save(entity.HeldContent{
+		Content:    entity.Content{Kind: entity.ContentAnswer, UserID: userID, Text: "Answer with a link"},
+		QuestionID: &questionID,
+		Policy:     "links",
+		CreatedAt:  time.Now(),
+	})
+	assert.NoError(t, err)
+
+	found, err := repo.GetByID(first.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"go", "web"}, found.Tags)
+	assert.False(t, found.Edit)
+
+	held, err := repo.List(entity.PageRequest{Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, held, 2)
+	assert.Equal(t, first.ID, held[0].ID)
+	assert.Equal(t, 1, *held[1].QuestionID)
+
+	t.Run("claim", func(t *testing.T) {
+		claimed, err := repo.Claim(first.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Read https://example.com", claimed.Text)
+
+		_, err = repo.Claim(first.ID)
+		assert.Equal(t, usecase.KindNotFound, usecase.KindOf(err), "content is claimed once")
+
+		_, err = repo.Save(claimed)
+		assert.NoError(t, err)
+		found, err := repo.GetByID(first.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, claimed.Tags, found.Tags, "claimed content can be put back")
+	})
+
+	t.Run("recent content", func(t *testing.T) {
+		_, err := repositoriy.NewGormAnswerRepository(db, nil).Save(entity.Answer{QuestionID: 1, UserID: userID, Text: "Posted answer", CreatedAt: time.Now()})
+		assert.NoError(t, err)
+		since := time.Now().Add(-10 * time.Minute)
+
+		for _, tt := range []struct {
+			kind string
+			text string
+			want bool
+		}{
+			{entity.ContentAnswer, "Posted answer", true},
+			{entity.ContentQuestion, "Posted answer", false},
+			{entity.ContentQuestion, "Read https://example.com", true},
+			{entity.ContentAnswer, "Another answer", false},
+		} {
+			recent, err := repo.HasRecent(tt.kind, userID, tt.text, since)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, recent, tt.text)
+		}
+
+		recent, _ := repo.HasRecent(entity.ContentAnswer, userID, "Posted answer", time.Now().Add(time.Minute))
+		assert.False(t, recent)
+		recent, _ = repo.HasRecent(entity.ContentAnswer, uuid.New(), "Posted answer", since)
+		assert.False(t, recent)
+	})
+
+	assert.NoError(t, repo.Delete(first.ID))
+	_, err = repo.GetByID(first.ID)
+	assert.Equal(t, usecase.KindNotFound, usecase.KindOf(err))
+}
+
+func TestFlagRepository(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repositoriy.NewGormFlagRepository(db, nil)
+	questionRepo := repositoriy.NewGormQuestionRepository(db, nil)
+	question, err := questionRepo.Save(entity.Question{UserID: uuid.New(), Text: "Flagged question"})
+	assert.NoError(t, err)
+	alice, bob := uuid.New(), uuid.New()
+
+	flag := entity.Flag{TargetType: entity.FlagTargetQuestion, TargetID: question.ID, UserID: alice, Reason: entity.FlagSpam, Status: entity.FlagPending, CreatedAt: time.Now()}
+	saved, created, err := repo.Create(flag)
+	assert.NoError(t, err)
+	assert.True(t, created)
+	assert.NotZero(t, saved.ID)
+
+	_, created, err = repo.Create(flag)
+	assert.NoError(t, err)
+	assert.False(t, created)
+
+	flag.UserID = bob
+	_, _, err = repo.Create(flag)
+	assert.NoError(t, err)
+
+	count, err := repo.CountPending(entity.FlagTargetQuestion, question.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	_, err = repo.Decide(entity.ModerationDecision{TargetType: entity.FlagTargetQuestion, TargetID: question.ID, Action: entity.ActionHide, CreatedAt: time.Now()}, "")
+	assert.NoError(t, err)
+
+	hidden, err := questionRepo.GetByID(question.ID)
+	assert.NoError(t, err)
+	assert.NotNil(t, hidden.HiddenAt)
+	assert.Equal(t, question.Version+1, hidden.Version)
+	found, err := questionRepo.Find(entity.QuestionFilter{Page: entity.PageRequest{Limit: 10}})
+	assert.NoError(t, err)
+	assert.Empty(t, found)
+
+	moderatorID := uuid.New()
+	_, err = repo.Decide(entity.ModerationDecision{TargetType: entity.FlagTargetQuestion, TargetID: question.ID, ModeratorID: &moderatorID, Action: entity.ActionDismiss, CreatedAt: time.Now()}, entity.FlagDismissed)
+	assert.NoError(t, err)
+
+	shown, _ := questionRepo.GetByID(question.ID)
+	assert.Nil(t, shown.HiddenAt)
+	pending, err := repo.ListPending(entity.PageRequest{Limit: 10})
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+	resolved, _ := repo.GetByID(saved.ID)
+	assert.Equal(t, entity.FlagDismissed, resolved.Status)
+	assert.NotNil(t, resolved.ResolvedAt)
+
+	decisions, err := repo.ListDecisions(entity.PageRequest{Limit: 10})
+	assert.NoError(t, err)
+	assert.Len(t, decisions, 2)
+	assert.Equal(t, entity.ActionDismiss, decisions[0].Action)
+	assert.Nil(t, decisions[1].ModeratorID)
+
+	_, err = repo.Decide(entity.ModerationDecision{TargetType: entity.FlagTargetAnswer, TargetID: 42, Action: entity.ActionLock, CreatedAt: time.Now()}, entity.FlagActioned)
+	assert.Equal(t, usecase.KindNotFound, usecase.KindOf(err))
+
+	t.Run("delete", func(t *testing.T) {
+		answerRepo := repositoriy.NewGormAnswerRepository(db, nil)
+		answer, err := answerRepo.Save(entity.Answer{QuestionID: question.ID, UserID: uuid.New(), Text: "Flagged answer"})
+		assert.NoError(t, err)
+		flag := entity.Flag{TargetType: entity.FlagTargetAnswer, TargetID: answer.ID, UserID: alice, Reason: entity.FlagSpam, Status: entity.FlagPending, CreatedAt: time.Now()}
+		saved, _, err := repo.Create(flag)
+		assert.NoError(t, err)
+
+		_, err = repo.Decide(entity.ModerationDecision{TargetType: entity.FlagTargetAnswer, TargetID: answer.ID, ModeratorID: &moderatorID, Action: entity.ActionDelete, CreatedAt: time.Now()}, entity.FlagActioned)
+		assert.NoError(t, err)
+
+		_, err = answerRepo.GetByID(answer.ID)
+		assert.Equal(t, usecase.KindNotFound, usecase.KindOf(err))
+		resolved, _ := repo.GetByID(saved.ID)
+		assert.Equal(t, entity.FlagActioned, resolved.Status)
+		parent, _ := questionRepo.GetByID(question.ID)
+		assert.Equal(t, 0, parent.AnswerCount)
+
+		_, err = repo.Decide(entity.ModerationDecision{TargetType: entity.FlagTargetAnswer, TargetID: answer.ID, ModeratorID: &moderatorID, Action: entity.ActionDelete, CreatedAt: time.Now()}, entity.FlagActioned)
+		assert.Equal(t, usecase.KindNotFound, usecase.KindOf(err))
+		decisions, _ := repo.ListDecisions(entity.PageRequest{Limit: 10})
+		assert.Len(t, decisions, 3)
+	})
+}
+
+func TestIdentityRepository_Register(t *testing.T) {
+	db := setupTestDB(t)
+	db.AutoMigrate(&repositoriy.UserIdentity{})
+	repo := repositoriy.NewGormIdentityRepository(db, nil)
+	userRepo := repositoriy.NewGormUserRepository(db, nil)
+	const issuer = "https://sso.example.com"
+
+	user := entity.User{ID: uuid.New(), DisplayName: "Alice", Email: "alice@corp.example", Role: entity.RoleUser, CreatedAt: time.Now()}
+	err := repo.Register(user, entity.UserIdentity{Issuer: issuer, Subject: "emp-1", UserID: user.ID, CreatedAt: time.Now()})
+	assert.NoError(t, err)
+
+	userID, err := repo.GetUserID(issuer, "emp-1")
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, userID)
+	known, err := repo.HasIssuer(user.ID, issuer)
+	assert.NoError(t, err)
+	assert.True(t, known)
+	known, _ = repo.HasIssuer(user.ID, "https://other.example.com")
+	assert.False(t, known)
+
+	// the same email under another subject leaves neither the user nor the identity behind
+	other := entity.User{ID: uuid.New(), DisplayName: "Mallory", Email: "alice@corp.example", Role: entity.RoleUser, CreatedAt: time.Now()}
+	err = repo.Register(other, entity.UserIdentity{Issuer: issuer, Subject: "emp-2", UserID: other.ID, CreatedAt: time.Now()})
+	assert.Equal(t, usecase.KindConflict, usecase.KindOf(err))
+	_, err = repo.GetUserID(issuer, "emp-2")
+	assert.Equal(t, usecase.KindNotFound, usecase.KindOf(err))
+
+	// the same subject with another email rolls back the new user
+	twin := entity.User{ID: uuid.New(), DisplayName: "Alice", Email: "alice2@corp.example", Role: entity.RoleUser, CreatedAt: time.Now()}
+	err = repo.Register(twin, entity.UserIdentity{Issuer: issuer, Subject: "emp-1", UserID: twin.ID, CreatedAt: time.Now()})
+	assert.Equal(t, usecase.KindConflict, usecase.KindOf(err))
+	_, err = userRepo.GetByID(twin.ID)
+	assert.Equal(t, usecase.KindNotFound, usecase.KindOf(err))
 }
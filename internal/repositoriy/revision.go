@@ -0,0 +1,38 @@
+package repositoriy
+
+import (
+	"testovoe/internal/entity"
+	"time"
+)
+
+// writtenAt is when the current text of a row was written.
+func writtenAt(createdAt time.Time, updatedAt *time.Time) time.Time {
+	if updatedAt != nil {
+		return *updatedAt
+	}
+	return createdAt
+}
+
+func questionRevisionsToEntity(gormRevisions []QuestionRevision) []entity.Revision {
+	revisions := make([]entity.Revision, len(gormRevisions))
+	for i, gr := range gormRevisions {
+		revisions[i] = entity.Revision{
+			Revision:  gr.Revision,
+			Text:      gr.Text,
+			CreatedAt: gr.CreatedAt,
+		}
+	}
+	return revisions
+}
+
+func answerRevisionsToEntity(gormRevisions []AnswerRevision) []entity.Revision {
+	revisions := make([]entity.Revision, len(gormRevisions))
+	for i, gr := range gormRevisions {
+		revisions[i] = entity.Revision{
+			Revision:  gr.Revision,
+			Text:      gr.Text,
+			CreatedAt: gr.CreatedAt,
+		}
+	}
+	return revisions
+}
@@ -0,0 +1,218 @@
+package repositoriy
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"testovoe/internal/entity"
+	"testovoe/internal/pkg"
+	"testovoe/internal/usecase"
+
+	"gorm.io/gorm"
+)
+
+// The database wraps matches in characters from the private use area, so the text can be escaped
+// as HTML before they are turned into <mark> tags.
+const (
+	markStart = "\uE000"
+	markStop  = "\uE001"
+)
+
+type GormSearchRepository struct {
+	db     *gorm.DB
+	logger pkg.Logger
+}
+
+func NewGormSearchRepository(db *gorm.DB, logger pkg.Logger) usecase.SearchRepositoriy {
+	if logger == nil {
+		logger = pkg.NewNopLogger()
+	}
+	return &GormSearchRepository{
+		db:     db,
+		logger: logger.WithFields(map[string]interface{}{"component": "search_repository"}),
+	}
+}
+
+type searchHit struct {
+	Kind       string
+	RefID      int
+	QuestionID int
+	Snippet    string
+	Rank       float64
+	Offsets    string
+}
+
+func (r *GormSearchRepository) Search(terms []string, limit int) ([]entity.SearchResult, error) {
+	r.logger.Debug("searching", "terms", terms, "limit", limit)
+
+	var (
+		hits []searchHit
+		err  error
+	)
+	if r.db.Dialector.Name() == "sqlite" {
+		hits, err = r.searchSQLite(terms, limit)
+	} else {
+		hits, err = r.searchPostgres(terms, limit)
+	}
+	if err != nil {
+		r.logger.Error("failed to search", "terms", terms, "error", err)
+		return nil, translate(err, "question")
+	}
+
+	results := make([]entity.SearchResult, len(hits))
+	for i, hit := range hits {
+		results[i] = entity.SearchResult{
+			Type:       hit.Kind,
+			ID:         hit.RefID,
+			QuestionID: hit.QuestionID,
+			Snippet:    highlight(hit.Snippet),
+			Rank:       hit.Rank,
+		}
+	}
+
+	r.logger.Debug("search finished", "count", len(results))
+	return results, nil
+}
+
+// highlight escapes the snippet as HTML and marks the matches, the only markup in the result
+// is <mark></mark> whatever the text contains.
+func highlight(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	return strings.NewReplacer(markStart, "<mark>", markStop, "</mark>").Replace(escaped)
+}
+
+// searchPostgres uses search_vector columns from migration 005.
+func (r *GormSearchRepository) searchPostgres(terms []string, limit int) ([]searchHit, error) {
+	headline := "'StartSel=" + markStart + ", StopSel=" + markStop + ", MaxFragments=1, MaxWords=30, MinWords=10'"
+
+	var hits []searchHit
+	err := r.db.Raw(`
+		SELECT kind, ref_id, question_id, snippet, rank FROM (
+			SELECT 'question' AS kind, q.id AS ref_id, q.id AS question_id,
+				ts_headline('simple', q.text, query, `+headline+`) AS snippet,
+				ts_rank(q.search_vector, query) AS rank, q.created_at
+			FROM questions q, to_tsquery('simple', @query) query
+			WHERE q.search_vector @@ query AND q.deleted_at IS NULL AND q.hidden_at IS NULL
+			UNION ALL
+			SELECT 'answer', a.id, a.question_id,
+				ts_headline('simple', a.text, query, `+headline+`),
+				ts_rank(a.search_vector, query), a.created_at
+			FROM answers a JOIN questions q ON q.id = a.question_id, to_tsquery('simple', @query) query
+			WHERE a.search_vector @@ query AND a.deleted_at IS NULL AND a.hidden_at IS NULL
+				AND q.deleted_at IS NULL AND q.hidden_at IS NULL
+		) hits
+		ORDER BY rank DESC, created_at DESC
+		LIMIT @limit`,
+		map[string]interface{}{"query": strings.Join(terms, " & "), "limit": limit},
+	).Scan(&hits).Error
+
+	return hits, err
+}
+
+// searchSQLite uses the search_index table created by SetupSQLiteSearch.
+func (r *GormSearchRepository) searchSQLite(terms []string, limit int) ([]searchHit, error) {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + term + `"`
+	}
+	match := strings.Join(quoted, " ")
+
+	var definition string
+	if err := r.db.Raw("SELECT sql FROM sqlite_master WHERE name = 'search_index'").Scan(&definition).Error; err != nil {
+		return nil, err
+	}
+
+	// Soft-deleted and hidden rows stay in the index. Answers are shown only while their question is,
+	// so every hit needs a visible question and answers also need to be visible themselves.
+	notDeleted := `question_id IN (SELECT id FROM questions WHERE deleted_at IS NULL AND hidden_at IS NULL)
+		AND (kind = 'question' OR ref_id IN (SELECT id FROM answers WHERE deleted_at IS NULL AND hidden_at IS NULL))`
+
+	var hits []searchHit
+	if strings.Contains(strings.ToLower(definition), "fts5") {
+		err := r.db.Raw(`
+			SELECT kind, ref_id, question_id,
+				snippet(search_index, 3, ?, ?, '…', 16) AS snippet,
+				-bm25(search_index) AS rank
+			FROM search_index
+			WHERE search_index MATCH ? AND `+notDeleted+`
+			ORDER BY bm25(search_index)
+			LIMIT ?`,
+			markStart, markStop, match, limit,
+		).Scan(&hits).Error
+		return hits, err
+	}
+
+	// FTS4 has no ranking function, so hits are ranked by how many times the terms occur.
+	err := r.db.Raw(`
+		SELECT kind, ref_id, question_id,
+			snippet(search_index, ?, ?, '…', 3, 16) AS snippet,
+			offsets(search_index) AS offsets
+		FROM search_index
+		WHERE search_index MATCH ? AND `+notDeleted,
+		markStart, markStop, match,
+	).Scan(&hits).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range hits {
+		hits[i].Rank = float64(len(strings.Fields(hits[i].Offsets)) / 4)
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Rank > hits[j].Rank
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits, nil
+}
+
+// SetupSQLiteSearch creates the full-text index for SQLite databases, which are not covered by goose migrations.
+// FTS5 is used when the driver is built with it (the sqlite_fts5 tag), FTS4 otherwise.
+func SetupSQLiteSearch(db *gorm.DB) error {
+	err := db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5(
+		kind UNINDEXED, ref_id UNINDEXED, question_id UNINDEXED, text)`).Error
+	if err != nil {
+		if !strings.Contains(err.Error(), "no such module") {
+			return err
+		}
+		err = db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts4(
+			kind, ref_id, question_id, text, notindexed=kind, notindexed=ref_id, notindexed=question_id)`).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	statements := []string{
+		`CREATE TRIGGER IF NOT EXISTS questions_search_insert AFTER INSERT ON questions BEGIN
+			INSERT INTO search_index (kind, ref_id, question_id, text) VALUES ('question', new.id, new.id, new.text);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS questions_search_update AFTER UPDATE OF text ON questions BEGIN
+			UPDATE search_index SET text = new.text WHERE kind = 'question' AND ref_id = new.id;
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS questions_search_delete AFTER DELETE ON questions BEGIN
+			DELETE FROM search_index WHERE question_id = old.id;
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS answers_search_insert AFTER INSERT ON answers BEGIN
+			INSERT INTO search_index (kind, ref_id, question_id, text) VALUES ('answer', new.id, new.question_id, new.text);
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS answers_search_update AFTER UPDATE OF text ON answers BEGIN
+			UPDATE search_index SET text = new.text WHERE kind = 'answer' AND ref_id = new.id;
+		END`,
+		`CREATE TRIGGER IF NOT EXISTS answers_search_delete AFTER DELETE ON answers BEGIN
+			DELETE FROM search_index WHERE kind = 'answer' AND ref_id = old.id;
+		END`,
+		`DELETE FROM search_index`,
+		`INSERT INTO search_index (kind, ref_id, question_id, text) SELECT 'question', id, id, text FROM questions`,
+		`INSERT INTO search_index (kind, ref_id, question_id, text) SELECT 'answer', id, question_id, text FROM answers`,
+	}
+
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package repositoriy
+
+import (
+	"testovoe/internal/entity"
+	"testovoe/internal/pkg"
+	"testovoe/internal/usecase"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormTagRepository struct {
+	db     *gorm.DB
+	logger pkg.Logger
+}
+
+func NewGormTagRepository(db *gorm.DB, logger pkg.Logger) usecase.TagRepositoriy {
+	if logger == nil {
+		logger = pkg.NewNopLogger()
+	}
+	return &GormTagRepository{
+		db:     db,
+		logger: logger.WithFields(map[string]interface{}{"component": "tag_repository"}),
+	}
+}
+
+// List counts live, not hidden questions per tag and pages over (count, id) descending, tags without questions are skipped.
+func (r *GormTagRepository) List(page entity.PageRequest) ([]entity.Tag, error) {
+	r.logger.Debug("listing tags", "limit", page.Limit)
+
+	query := r.db.Table("tags").
+		Select("tags.id, tags.name, COUNT(*) AS question_count").
+		Joins("JOIN question_tags ON question_tags.tag_id = tags.id").
+		Joins("JOIN questions ON questions.id = question_tags.question_id AND questions.deleted_at IS NULL AND questions.hidden_at IS NULL").
+		Group("tags.id, tags.name")
+
+	if page.After != nil {
+		query = query.Having("(COUNT(*) < ? OR (COUNT(*) = ? AND tags.id < ?))",
+			page.After.Count, page.After.Count, page.After.ID)
+	}
+
+	var tags []entity.Tag
+	result := query.Order("question_count DESC, tags.id DESC").Limit(page.Limit).Scan(&tags)
+	if result.Error != nil {
+		r.logger.Error("failed to list tags", "error", result.Error)
+		return nil, translate(result.Error, "tag")
+	}
+
+	r.logger.Debug("retrieved tags", "count", len(tags))
+	return tags, nil
+}
+
+// saveTags attaches tags to a question, creating the ones that do not exist yet.
+func saveTags(tx *gorm.DB, questionID int, names []string) error {
+	for _, name := range names {
+		tag := Tag{Name: name}
+		if err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).Create(&tag).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("name = ?", name).First(&tag).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&QuestionTag{QuestionID: questionID, TagID: tag.ID}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadTags returns tag names of the given questions ordered by name.
+func loadTags(db *gorm.DB, questionIDs []int) (map[int][]string, error) {
+	tags := make(map[int][]string, len(questionIDs))
+	if len(questionIDs) == 0 {
+		return tags, nil
+	}
+
+	var rows []struct {
+		QuestionID int
+		Name       string
+	}
+	err := db.Table("question_tags").
+		Select("question_tags.question_id, tags.name").
+		Joins("JOIN tags ON tags.id = question_tags.tag_id").
+		Where("question_tags.question_id IN ?", questionIDs).
+		Order("tags.name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		tags[row.QuestionID] = append(tags[row.QuestionID], row.Name)
+	}
+	return tags, nil
+}
@@ -0,0 +1,22 @@
+package repositoriy
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func deletedAtToEntity(deletedAt gorm.DeletedAt) *time.Time {
+	if !deletedAt.Valid {
+		return nil
+	}
+	t := deletedAt.Time
+	return &t
+}
+
+// onlyDeleted selects soft-deleted rows of the table, which gorm hides by default.
+func onlyDeleted(table string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped().Where(table + ".deleted_at IS NOT NULL")
+	}
+}
@@ -0,0 +1,138 @@
+package repositoriy
+
+import (
+	"testovoe/internal/entity"
+	"testovoe/internal/pkg"
+	"testovoe/internal/usecase"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type GormUserRepository struct {
+	db     *gorm.DB
+	logger pkg.Logger
+}
+
+func NewGormUserRepository(db *gorm.DB, logger pkg.Logger) usecase.UserRepositoriy {
+	if logger == nil {
+		logger = pkg.NewNopLogger()
+	}
+	return &GormUserRepository{
+		db:     db,
+		logger: logger.WithFields(map[string]interface{}{"component": "user_repository"}),
+	}
+}
+
+func (r *GormUserRepository) GetByID(id uuid.UUID) (entity.User, error) {
+	r.logger.Debug("getting user by ID", "user_id", id)
+
+	var gormUser User
+	result := r.db.First(&gormUser, "id = ?", id)
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			r.logger.Warn("user not found", "user_id", id)
+			return entity.User{}, translate(result.Error, "user")
+		}
+		r.logger.Error("failed to get user", "user_id", id, "error", result.Error)
+		return entity.User{}, translate(result.Error, "user")
+	}
+
+	return r.toEntity(gormUser), nil
+}
+
+func (r *GormUserRepository) GetByEmail(email string) (entity.User, error) {
+	r.logger.Debug("getting user by email")
+
+	var gormUser User
+	result := r.db.First(&gormUser, "email = ?", email)
+	if result.Error != nil {
+		if result.Error != gorm.ErrRecordNotFound {
+			r.logger.Error("failed to get user by email", "error", result.Error)
+		}
+		return entity.User{}, translate(result.Error, "user")
+	}
+
+	return r.toEntity(gormUser), nil
+}
+
+func (r *GormUserRepository) Save(user entity.User) (entity.User, error) {
+	r.logger.Debug("saving user", "user_id", user.ID)
+
+	gormUser := r.toGormModel(user)
+	result := r.db.Create(&gormUser)
+	if result.Error != nil {
+		r.logger.Error("failed to save user", "user_id", user.ID, "error", result.Error)
+		return entity.User{}, translate(result.Error, "user")
+	}
+
+	r.logger.Info("user saved successfully", "user_id", user.ID)
+	return r.toEntity(gormUser), nil
+}
+
+func (r *GormUserRepository) Update(user entity.User) (entity.User, error) {
+	r.logger.Debug("updating user", "user_id", user.ID)
+
+	gormUser := r.toGormModel(user)
+	result := r.db.Model(&gormUser).Select("display_name", "email", "updated_at").Updates(&gormUser)
+	if result.Error != nil {
+		r.logger.Error("failed to update user", "user_id", user.ID, "error", result.Error)
+		return entity.User{}, translate(result.Error, "user")
+	}
+	if result.RowsAffected == 0 {
+		r.logger.Warn("user not found for update", "user_id", user.ID)
+		return entity.User{}, translate(gorm.ErrRecordNotFound, "user")
+	}
+
+	r.logger.Info("user updated successfully", "user_id", user.ID)
+	return r.toEntity(gormUser), nil
+}
+
+func (r *GormUserRepository) SetRole(id uuid.UUID, role string) (entity.User, error) {
+	r.logger.Debug("setting user role", "user_id", id, "role", role)
+
+	result := r.db.Model(&User{}).Where("id = ?", id).Update("role", role)
+	if result.Error != nil {
+		r.logger.Error("failed to set user role", "user_id", id, "error", result.Error)
+		return entity.User{}, translate(result.Error, "user")
+	}
+	if result.RowsAffected == 0 {
+		r.logger.Warn("user not found for role change", "user_id", id)
+		return entity.User{}, translate(gorm.ErrRecordNotFound, "user")
+	}
+
+	r.logger.Info("user role changed", "user_id", id, "role", role)
+	return r.GetByID(id)
+}
+
+func (r *GormUserRepository) toEntity(gormUser User) entity.User {
+	return entity.User{
+		ID:           gormUser.ID,
+		DisplayName:  gormUser.DisplayName,
+		Email:        gormUser.Email,
+		Role:         gormUser.Role,
+		PasswordHash: gormUser.PasswordHash,
+		CreatedAt:    gormUser.CreatedAt,
+		UpdatedAt:    gormUser.UpdatedAt,
+	}
+}
+
+func (r *GormUserRepository) toGormModel(user entity.User) User {
+	return User{
+		ID:           user.ID,
+		DisplayName:  user.DisplayName,
+		Email:        user.Email,
+		Role:         user.Role,
+		PasswordHash: user.PasswordHash,
+		CreatedAt:    user.CreatedAt,
+		UpdatedAt:    user.UpdatedAt,
+	}
+}
+
+// userSummaryToEntity turns a preloaded author into a summary, authors without a profile are left out.
+func userSummaryToEntity(gormUser *User) *entity.UserSummary {
+	if gormUser == nil {
+		return nil
+	}
+	return &entity.UserSummary{ID: gormUser.ID, DisplayName: gormUser.DisplayName}
+}
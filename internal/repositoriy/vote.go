@@ -0,0 +1,145 @@
+package repositoriy
+
+import (
+	"testovoe/internal/entity"
+	"testovoe/internal/pkg"
+	"testovoe/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormVoteRepository struct {
+	db     *gorm.DB
+	logger pkg.Logger
+}
+
+func NewGormVoteRepository(db *gorm.DB, logger pkg.Logger) usecase.VoteRepositoriy {
+	if logger == nil {
+		logger = pkg.NewNopLogger()
+	}
+	return &GormVoteRepository{
+		db:     db,
+		logger: logger.WithFields(map[string]interface{}{"component": "vote_repository"}),
+	}
+}
+
+func (r *GormVoteRepository) Save(vote entity.Vote) (int, error) {
+	r.logger.Debug("saving vote",
+		"user_id", vote.UserID,
+		"target_type", vote.TargetType,
+		"target_id", vote.TargetID,
+		"value", vote.Value)
+
+	var score int
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		previous, err := r.lockVote(tx, vote.UserID, vote.TargetType, vote.TargetID)
+		if err != nil {
+			return err
+		}
+
+		delta := vote.Value
+		if previous != nil {
+			delta -= previous.Value
+		}
+
+		gormVote := Vote{
+			UserID:     vote.UserID,
+			TargetType: vote.TargetType,
+			TargetID:   vote.TargetID,
+			Value:      vote.Value,
+		}
+		if previous == nil {
+			err = tx.Create(&gormVote).Error
+		} else {
+			err = tx.Model(previous).Update("value", vote.Value).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		score, err = moveScore(tx, vote.TargetType, vote.TargetID, delta)
+		return err
+	})
+	if err != nil {
+		r.logger.Error("failed to save vote", "target_type", vote.TargetType, "target_id", vote.TargetID, "error", err)
+		return 0, translate(err, "vote")
+	}
+
+	r.logger.Info("vote saved successfully", "target_type", vote.TargetType, "target_id", vote.TargetID, "score", score)
+	return score, nil
+}
+
+func (r *GormVoteRepository) Delete(userID uuid.UUID, targetType string, targetID int) (int, error) {
+	r.logger.Debug("deleting vote", "user_id", userID, "target_type", targetType, "target_id", targetID)
+
+	var score int
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		previous, err := r.lockVote(tx, userID, targetType, targetID)
+		if err != nil {
+			return err
+		}
+
+		delta := 0
+		if previous != nil {
+			if err := tx.Delete(previous).Error; err != nil {
+				return err
+			}
+			delta = -previous.Value
+		}
+
+		score, err = moveScore(tx, targetType, targetID, delta)
+		return err
+	})
+	if err != nil {
+		r.logger.Error("failed to delete vote", "target_type", targetType, "target_id", targetID, "error", err)
+		return 0, translate(err, "vote")
+	}
+
+	r.logger.Info("vote deleted successfully", "target_type", targetType, "target_id", targetID, "score", score)
+	return score, nil
+}
+
+// lockVote returns the current vote of the user or nil if there is none.
+func (r *GormVoteRepository) lockVote(tx *gorm.DB, userID uuid.UUID, targetType string, targetID int) (*Vote, error) {
+	var gormVote Vote
+	result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID).
+		Limit(1).Find(&gormVote)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &gormVote, nil
+}
+
+// moveScore changes the denormalized score of the voted question or answer and returns the new value.
+func moveScore(tx *gorm.DB, targetType string, targetID int, delta int) (int, error) {
+	var model interface{} = &Question{}
+	touch := touchQuestion
+	if targetType == entity.VoteTargetAnswer {
+		model = &Answer{}
+		touch = touchAnswer
+	}
+
+	result := tx.Model(model).Where("id = ?", targetID).Update("score", gorm.Expr("score + ?", delta))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	if delta != 0 {
+		if err := touch(tx, targetID, time.Now()); err != nil {
+			return 0, err
+		}
+	}
+
+	var score int
+	err := tx.Model(model).Select("score").Where("id = ?", targetID).Row().Scan(&score)
+	return score, err
+}
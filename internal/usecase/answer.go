@@ -1,58 +1,315 @@
 package usecase
 
 import (
-	"errors"
 	"testovoe/internal/entity"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type AnswerRepositoriy interface {
 	GetByID(int) (entity.Answer, error)
+	ListByQuestion(int, entity.AnswerSort, entity.PageRequest) ([]entity.Answer, error)
 	Save(entity.Answer) (entity.Answer, error)
-	Delete(int) error
+	// Update stores the text before the change as a new revision and saves the answer
+	// if it still has the version it was read with, the version is then increased.
+	Update(entity.Answer) (entity.Answer, error)
+	ListRevisions(int) ([]entity.Revision, error)
+	// Accept marks the answer as accepted for the question, nil unaccepts.
+	Accept(questionID int, answerID *int) error
+	// Delete moves the answer to the trash if it still has the given version.
+	Delete(id int, version int) error
+	// Restore brings the answer back from the trash if its question is not deleted.
+	Restore(int) (entity.Answer, error)
+	// GetDeleted returns an answer from the trash.
+	GetDeleted(int) (entity.Answer, error)
+	ListDeleted(entity.PageRequest) ([]entity.Answer, error)
+	// Purge permanently removes answers deleted before the given time.
+	Purge(time.Time) (int64, error)
 }
 
+// ErrNotQuestionAuthor is returned when somebody else than the author of the question tries to accept an answer.
+var ErrNotQuestionAuthor = Forbidden("Only the author of the question can accept answers")
+
 type AnswerUseCase struct {
-	ansRepo   AnswerRepositoriy
-	questRepo QuestionRepositoriy
+	ansRepo    AnswerRepositoriy
+	questRepo  QuestionRepositoriy
+	userRepo   UserRepositoriy
+	limit      LengthLimit
+	moderation *ContentModeration
 }
 
-func NewAnswerUseCase(ansrepo AnswerRepositoriy, quest QuestionRepositoriy) *AnswerUseCase {
+// NewAnswerUseCase makes answers checked against the text limits and, unless moderation is nil, content policies.
+func NewAnswerUseCase(ansrepo AnswerRepositoriy, quest QuestionRepositoriy, users UserRepositoriy, limits TextLimits, moderation *ContentModeration) *AnswerUseCase {
 	return &AnswerUseCase{
-		ansRepo:   ansrepo,
-		questRepo: quest,
+		ansRepo:    ansrepo,
+		questRepo:  quest,
+		userRepo:   users,
+		limit:      limits.Answer,
+		moderation: moderation,
 	}
 }
 
 func (uc *AnswerUseCase) Save(dto entity.AnswerDto, questionID int) (entity.Answer, error) {
-	if 5 > len(dto.Text) {
-		return entity.Answer{}, errors.New("Text of Answer is short")
+	if dto.UserID == uuid.Nil {
+		return entity.Answer{}, ErrUnauthenticated
+	}
+
+	text, err := cleanText("answer", dto.Text, uc.limit)
+	if err != nil {
+		return entity.Answer{}, err
+	}
+
+	question, err := visibleQuestion(uc.questRepo, questionID)
+
+	if err != nil {
+		return entity.Answer{}, err
 	}
 
-	if len(dto.Text) > 200 {
-		return entity.Answer{}, errors.New("Text of Answer is long")
+	if question.LockedAt != nil {
+		return entity.Answer{}, ErrLocked
 	}
 
-	_, err := uc.questRepo.GetByID(questionID)
+	content := entity.Content{Kind: entity.ContentAnswer, UserID: dto.UserID, Text: text}
+	if err := uc.moderation.screen(entity.HeldContent{Content: content, QuestionID: &questionID}); err != nil {
+		return entity.Answer{}, err
+	}
 
+	return uc.publish(questionID, dto.UserID, text)
+}
+
+// publish stores an answer that passed validation and content policies.
+func (uc *AnswerUseCase) publish(questionID int, userID uuid.UUID, text string) (entity.Answer, error) {
+	author, err := authorOf(uc.userRepo, userID)
 	if err != nil {
-		return entity.Answer{}, errors.New("This question is not exist")
+		return entity.Answer{}, err
 	}
 
 	answer := entity.Answer{
 		QuestionID: questionID,
-		UserID:     dto.UserID,
-		Text:       dto.Text,
+		UserID:     userID,
+		Text:       text,
 		CreatedAt:  time.Now(),
 	}
 
-	return uc.ansRepo.Save(answer)
+	saved, err := uc.ansRepo.Save(answer)
+	if err != nil {
+		return entity.Answer{}, err
+	}
+
+	saved.Author = author
+	return saved, nil
 }
 
 func (uc *AnswerUseCase) GetByID(questionID int) (entity.Answer, error) {
-	return uc.ansRepo.GetByID(questionID)
+	return visibleAnswer(uc.ansRepo, uc.questRepo, questionID)
+}
+
+func (uc *AnswerUseCase) ListByQuestion(questionID int, sort entity.AnswerSort, page entity.PageRequest) (entity.Page[entity.Answer], error) {
+	if sort == "" {
+		sort = entity.AnswerSortOldest
+	}
+
+	cursorOf, ok := answerCursors[sort]
+	if !ok {
+		return entity.Page[entity.Answer]{}, Invalid("sort", "one_of", "Sort is unknown")
+	}
+
+	_, err := visibleQuestion(uc.questRepo, questionID)
+
+	if err != nil {
+		return entity.Page[entity.Answer]{}, err
+	}
+
+	return fetchSortedPage(
+		page,
+		string(sort),
+		func(page entity.PageRequest) ([]entity.Answer, error) {
+			return uc.ansRepo.ListByQuestion(questionID, sort, page)
+		},
+		cursorOf,
+	)
+}
+
+// Update changes the text of the answer, only its author or a moderator may do it.
+func (uc *AnswerUseCase) Update(actor entity.User, answerID int, dto entity.AnswerUpdateDto) (entity.Answer, error) {
+	if dto.Text == nil {
+		return entity.Answer{}, Validation("Nothing to update")
+	}
+
+	text, err := cleanText("answer", *dto.Text, uc.limit)
+	if err != nil {
+		return entity.Answer{}, err
+	}
+
+	answer, err := uc.ansRepo.GetByID(answerID)
+	if err != nil {
+		return entity.Answer{}, err
+	}
+
+	if err := canModify(actor, answer.UserID); err != nil {
+		return entity.Answer{}, err
+	}
+
+	if answer.LockedAt != nil && !actor.IsModerator() {
+		return entity.Answer{}, ErrLocked
+	}
+
+	if err := checkVersion(answer.Version, dto.Versions); err != nil {
+		return entity.Answer{}, err
+	}
+
+	if answer.Text == text {
+		return answer, nil
+	}
+
+	if !actor.IsModerator() {
+		content := entity.Content{Kind: entity.ContentAnswer, UserID: actor.ID, Text: text, Edit: true}
+		held := entity.HeldContent{Content: content, QuestionID: &answer.QuestionID, PostID: &answer.ID, Version: &answer.Version}
+		if err := uc.moderation.screen(held); err != nil {
+			return entity.Answer{}, err
+		}
+	}
+
+	return uc.change(answer, text)
+}
+
+// rewrite applies an approved edit made to the given version of the answer.
+func (uc *AnswerUseCase) rewrite(answerID int, version int, text string) (entity.Answer, error) {
+	answer, err := uc.ansRepo.GetByID(answerID)
+	if err != nil {
+		return entity.Answer{}, err
+	}
+
+	if err := checkVersion(answer.Version, []int{version}); err != nil {
+		return entity.Answer{}, err
+	}
+
+	return uc.change(answer, text)
+}
+
+func (uc *AnswerUseCase) change(answer entity.Answer, text string) (entity.Answer, error) {
+	now := time.Now()
+	answer.Text = text
+	answer.UpdatedAt = &now
+
+	return uc.ansRepo.Update(answer)
+}
+
+func (uc *AnswerUseCase) GetRevisions(answerID int) ([]entity.Revision, error) {
+	answer, err := visibleAnswer(uc.ansRepo, uc.questRepo, answerID)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := uc.ansRepo.ListRevisions(answerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return withCurrent(previous, answer.Text, answer.CreatedAt, answer.UpdatedAt), nil
+}
+
+func (uc *AnswerUseCase) DiffRevisions(answerID, from, to int) (entity.RevisionDiff, error) {
+	revisions, err := uc.GetRevisions(answerID)
+	if err != nil {
+		return entity.RevisionDiff{}, err
+	}
+
+	return diffRevisions(revisions, from, to)
+}
+
+func (uc *AnswerUseCase) Accept(questionID, answerID int, dto entity.AcceptDto) (entity.Question, error) {
+	question, err := uc.questionOfAuthor(questionID, dto.UserID)
+	if err != nil {
+		return entity.Question{}, err
+	}
+
+	answer, err := visibleAnswer(uc.ansRepo, uc.questRepo, answerID)
+	if err != nil {
+		return entity.Question{}, err
+	}
+
+	if answer.QuestionID != question.ID {
+		return entity.Question{}, NotFound("This answer is not for this question")
+	}
+
+	if err := uc.ansRepo.Accept(questionID, &answerID); err != nil {
+		return entity.Question{}, err
+	}
+
+	question.AcceptedAnswerID = &answerID
+	return question, nil
+}
+
+func (uc *AnswerUseCase) Unaccept(questionID int, dto entity.AcceptDto) (entity.Question, error) {
+	question, err := uc.questionOfAuthor(questionID, dto.UserID)
+	if err != nil {
+		return entity.Question{}, err
+	}
+
+	if question.AcceptedAnswerID == nil {
+		return question, nil
+	}
+
+	if err := uc.ansRepo.Accept(questionID, nil); err != nil {
+		return entity.Question{}, err
+	}
+
+	question.AcceptedAnswerID = nil
+	return question, nil
+}
+
+func (uc *AnswerUseCase) questionOfAuthor(questionID int, userID uuid.UUID) (entity.Question, error) {
+	question, err := uc.questRepo.GetByID(questionID)
+	if err != nil {
+		return entity.Question{}, err
+	}
+
+	if question.UserID != userID {
+		return entity.Question{}, ErrNotQuestionAuthor
+	}
+
+	return question, nil
+}
+
+// Delete moves the answer to the trash, only its author or a moderator may do it.
+// The current version must be one of versions unless they are nil.
+func (uc *AnswerUseCase) Delete(actor entity.User, answerID int, versions []int) error {
+	answer, err := uc.ansRepo.GetByID(answerID)
+	if err != nil {
+		return err
+	}
+
+	if err := canModify(actor, answer.UserID); err != nil {
+		return err
+	}
+
+	if err := checkVersion(answer.Version, versions); err != nil {
+		return err
+	}
+
+	return uc.ansRepo.Delete(answerID, answer.Version)
+}
+
+func (uc *AnswerUseCase) Restore(actor entity.User, answerID int) (entity.Answer, error) {
+	answer, err := uc.ansRepo.GetDeleted(answerID)
+	if err != nil {
+		return entity.Answer{}, err
+	}
+
+	if err := canModify(actor, answer.UserID); err != nil {
+		return entity.Answer{}, err
+	}
+
+	return uc.ansRepo.Restore(answerID)
 }
 
-func (uc *AnswerUseCase) Delete(answerID int) error {
-	return uc.ansRepo.Delete(answerID)
+var answerCursors = map[entity.AnswerSort]func(entity.Answer) entity.Cursor{
+	entity.AnswerSortOldest: func(a entity.Answer) entity.Cursor {
+		return entity.Cursor{Time: a.CreatedAt, ID: a.ID}
+	},
+	entity.AnswerSortScore: func(a entity.Answer) entity.Cursor {
+		return entity.Cursor{Count: a.Score, ID: a.ID}
+	},
 }
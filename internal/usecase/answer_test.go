@@ -5,6 +5,7 @@ import (
 	"testing"
 	"testovoe/internal/entity"
 	"testovoe/internal/usecase"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -18,16 +19,56 @@ func (m *MockAnswerRepo) GetByID(id int) (entity.Answer, error) {
 	return args.Get(0).(entity.Answer), args.Error(1)
 }
 
+func (m *MockAnswerRepo) ListByQuestion(questionID int, sort entity.AnswerSort, page entity.PageRequest) ([]entity.Answer, error) {
+	args := m.Called(questionID, sort, page)
+	return args.Get(0).([]entity.Answer), args.Error(1)
+}
+
 func (m *MockAnswerRepo) Save(answer entity.Answer) (entity.Answer, error) {
 	args := m.Called(answer)
 	return args.Get(0).(entity.Answer), args.Error(1)
 }
 
-func (m *MockAnswerRepo) Delete(id int) error {
+func (m *MockAnswerRepo) Update(answer entity.Answer) (entity.Answer, error) {
+	args := m.Called(answer)
+	return args.Get(0).(entity.Answer), args.Error(1)
+}
+
+func (m *MockAnswerRepo) ListRevisions(id int) ([]entity.Revision, error) {
 	args := m.Called(id)
+	return args.Get(0).([]entity.Revision), args.Error(1)
+}
+
+func (m *MockAnswerRepo) Accept(questionID int, answerID *int) error {
+	args := m.Called(questionID, answerID)
+	return args.Error(0)
+}
+
+func (m *MockAnswerRepo) Delete(id int, version int) error {
+	args := m.Called(id, version)
 	return args.Error(0)
 }
 
+func (m *MockAnswerRepo) Restore(id int) (entity.Answer, error) {
+	args := m.Called(id)
+	return args.Get(0).(entity.Answer), args.Error(1)
+}
+
+func (m *MockAnswerRepo) GetDeleted(id int) (entity.Answer, error) {
+	args := m.Called(id)
+	return args.Get(0).(entity.Answer), args.Error(1)
+}
+
+func (m *MockAnswerRepo) ListDeleted(page entity.PageRequest) ([]entity.Answer, error) {
+	args := m.Called(page)
+	return args.Get(0).([]entity.Answer), args.Error(1)
+}
+
+func (m *MockAnswerRepo) Purge(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockQuestionRepo) GetByID(id int) (entity.Question, error) {
 	args := m.Called(id)
 	return args.Get(0).(entity.Question), args.Error(1)
@@ -36,8 +77,10 @@ func (m *MockQuestionRepo) GetByID(id int) (entity.Question, error) {
 func TestAnswerUseCase_Save(t *testing.T) {
 	mockAnswerRepo := new(MockAnswerRepo)
 	mockQuestionRepo := new(MockQuestionRepo)
-	uc := usecase.NewAnswerUseCase(mockAnswerRepo, mockQuestionRepo)
+	mockUserRepo := new(MockUserRepo)
+	uc := usecase.NewAnswerUseCase(mockAnswerRepo, mockQuestionRepo, mockUserRepo, usecase.DefaultTextLimits(), nil)
 	userID := uuid.New()
+	mockUserRepo.On("GetByID", userID).Return(entity.User{ID: userID, DisplayName: "Alice"}, nil)
 
 	t.Run("success", func(t *testing.T) {
 		question := entity.Question{ID: 1}
@@ -51,10 +94,20 @@ func TestAnswerUseCase_Save(t *testing.T) {
 
 		assert.NoError(t, err)
 		assert.Equal(t, 1, result.ID)
+		assert.Equal(t, "Alice", result.Author.DisplayName)
 		mockQuestionRepo.AssertExpectations(t)
 		mockAnswerRepo.AssertExpectations(t)
 	})
 
+	t.Run("unknown user", func(t *testing.T) {
+		stranger := uuid.New()
+		mockUserRepo.On("GetByID", stranger).Return(entity.User{}, errors.New("not found"))
+
+		_, err := uc.Save(entity.AnswerDto{UserID: stranger, Text: "Valid answer"}, 1)
+
+		assert.Error(t, err)
+	})
+
 	t.Run("question not found", func(t *testing.T) {
 		mockQuestionRepo.On("GetByID", 999).Return(entity.Question{}, errors.New("not found"))
 
@@ -65,3 +118,137 @@ func TestAnswerUseCase_Save(t *testing.T) {
 		assert.Equal(t, entity.Answer{}, result)
 	})
 }
+
+func TestAnswerUseCase_Update(t *testing.T) {
+	mockAnswerRepo := new(MockAnswerRepo)
+	uc := usecase.NewAnswerUseCase(mockAnswerRepo, new(MockQuestionRepo), new(MockUserRepo), usecase.DefaultTextLimits(), nil)
+	author := entity.User{ID: uuid.New(), Role: entity.RoleUser}
+
+	t.Run("success", func(t *testing.T) {
+		text := "Better answer"
+		mockAnswerRepo.On("GetByID", 1).Return(entity.Answer{ID: 1, UserID: author.ID, Text: "Old answer"}, nil)
+		mockAnswerRepo.On("Update", mock.MatchedBy(func(a entity.Answer) bool {
+			return a.ID == 1 && a.Text == text && a.UpdatedAt != nil
+		})).Return(entity.Answer{ID: 1, Text: text}, nil)
+
+		result, err := uc.Update(author, 1, entity.AnswerUpdateDto{Text: &text})
+
+		assert.NoError(t, err)
+		assert.Equal(t, text, result.Text)
+		mockAnswerRepo.AssertExpectations(t)
+	})
+
+	t.Run("somebody else", func(t *testing.T) {
+		text := "Hijacked answer"
+		_, err := uc.Update(entity.User{ID: uuid.New(), Role: entity.RoleUser}, 1, entity.AnswerUpdateDto{Text: &text})
+
+		assert.ErrorIs(t, err, usecase.ErrForbidden)
+		mockAnswerRepo.AssertNumberOfCalls(t, "Update", 1)
+	})
+
+	t.Run("answer not found", func(t *testing.T) {
+		text := "Better answer"
+		mockAnswerRepo.On("GetByID", 999).Return(entity.Answer{}, errors.New("not found"))
+
+		_, err := uc.Update(author, 999, entity.AnswerUpdateDto{Text: &text})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestAnswerUseCase_ListByQuestion(t *testing.T) {
+	mockAnswerRepo := new(MockAnswerRepo)
+	mockQuestionRepo := new(MockQuestionRepo)
+	uc := usecase.NewAnswerUseCase(mockAnswerRepo, mockQuestionRepo, new(MockUserRepo), usecase.DefaultTextLimits(), nil)
+	mockQuestionRepo.On("GetByID", 1).Return(entity.Question{ID: 1}, nil)
+
+	t.Run("by score", func(t *testing.T) {
+		answers := []entity.Answer{{ID: 4, Score: 10}, {ID: 2, Score: 3}}
+		mockAnswerRepo.On("ListByQuestion", 1, entity.AnswerSortScore, entity.PageRequest{Limit: 2}).Return(answers, nil)
+
+		page, err := uc.ListByQuestion(1, entity.AnswerSortScore, entity.PageRequest{Limit: 1})
+
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 1)
+		cursor, err := usecase.DecodeCursor(page.NextCursor)
+		assert.NoError(t, err)
+		assert.Equal(t, 10, cursor.Count)
+		assert.Equal(t, 4, cursor.ID)
+	})
+
+	t.Run("unknown sort", func(t *testing.T) {
+		_, err := uc.ListByQuestion(1, "random", entity.PageRequest{Limit: 1})
+		assert.Error(t, err)
+	})
+}
+
+func TestAnswerUseCase_Accept(t *testing.T) {
+	mockAnswerRepo := new(MockAnswerRepo)
+	mockQuestionRepo := new(MockQuestionRepo)
+	uc := usecase.NewAnswerUseCase(mockAnswerRepo, mockQuestionRepo, new(MockUserRepo), usecase.DefaultTextLimits(), nil)
+	author := uuid.New()
+	mockQuestionRepo.On("GetByID", 1).Return(entity.Question{ID: 1, UserID: author}, nil)
+
+	t.Run("success", func(t *testing.T) {
+		answerID := 3
+		mockAnswerRepo.On("GetByID", 3).Return(entity.Answer{ID: 3, QuestionID: 1}, nil)
+		mockAnswerRepo.On("Accept", 1, &answerID).Return(nil)
+
+		question, err := uc.Accept(1, 3, entity.AcceptDto{UserID: author})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, *question.AcceptedAnswerID)
+		mockAnswerRepo.AssertExpectations(t)
+	})
+
+	t.Run("not the author", func(t *testing.T) {
+		_, err := uc.Accept(1, 3, entity.AcceptDto{UserID: uuid.New()})
+
+		assert.ErrorIs(t, err, usecase.ErrNotQuestionAuthor)
+	})
+
+	t.Run("answer of another question", func(t *testing.T) {
+		mockAnswerRepo.On("GetByID", 4).Return(entity.Answer{ID: 4, QuestionID: 2}, nil)
+		mockQuestionRepo.On("GetByID", 2).Return(entity.Question{ID: 2}, nil)
+
+		_, err := uc.Accept(1, 4, entity.AcceptDto{UserID: author})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("hidden answer", func(t *testing.T) {
+		hiddenAt := time.Now()
+		mockAnswerRepo.On("GetByID", 5).Return(entity.Answer{ID: 5, QuestionID: 1, HiddenAt: &hiddenAt}, nil)
+
+		_, err := uc.Accept(1, 5, entity.AcceptDto{UserID: author})
+
+		assert.Equal(t, usecase.KindNotFound, usecase.KindOf(err))
+		mockAnswerRepo.AssertNumberOfCalls(t, "Accept", 1)
+	})
+}
+
+func TestAnswerUseCase_DeleteAndRestore(t *testing.T) {
+	mockAnswerRepo := new(MockAnswerRepo)
+	uc := usecase.NewAnswerUseCase(mockAnswerRepo, new(MockQuestionRepo), new(MockUserRepo), usecase.DefaultTextLimits(), nil)
+	authorID := uuid.New()
+	stranger := entity.User{ID: uuid.New(), Role: entity.RoleUser}
+	moderator := entity.User{ID: uuid.New(), Role: entity.RoleModerator}
+	mockAnswerRepo.On("GetByID", 1).Return(entity.Answer{ID: 1, UserID: authorID}, nil)
+	mockAnswerRepo.On("GetDeleted", 1).Return(entity.Answer{ID: 1, UserID: authorID}, nil)
+
+	err := uc.Delete(stranger, 1, nil)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+	mockAnswerRepo.AssertNotCalled(t, "Delete", 1, 0)
+
+	mockAnswerRepo.On("Delete", 1, 0).Return(nil)
+	err = uc.Delete(moderator, 1, nil)
+	assert.NoError(t, err)
+
+	_, err = uc.Restore(stranger, 1)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+	mockAnswerRepo.AssertNotCalled(t, "Restore", 1)
+
+	mockAnswerRepo.On("Restore", 1).Return(entity.Answer{ID: 1, UserID: authorID}, nil)
+	_, err = uc.Restore(entity.User{ID: authorID, Role: entity.RoleUser}, 1)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,202 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"strings"
+	"testovoe/internal/entity"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+type APIKeyRepositoriy interface {
+	GetByID(int) (entity.APIKey, error)
+	GetByPrefix(string) (entity.APIKey, error)
+	// ListByUser returns all keys of the user including revoked ones, newest first.
+	ListByUser(uuid.UUID) ([]entity.APIKey, error)
+	Save(entity.APIKey) (entity.APIKey, error)
+	Revoke(id int, at time.Time) error
+	// Touch records the use of the key.
+	Touch(id int, at time.Time) error
+}
+
+// MaxAPIKeys is how many active keys one user may have.
+const MaxAPIKeys = 20
+
+// apiKeyPrefix starts every key so that leaked keys are easy to find in logs and code.
+const apiKeyPrefix = "qak_"
+
+// lastUsedPrecision avoids writing to the database on every request of a busy bot.
+const lastUsedPrecision = time.Minute
+
+var apiKeyScopes = map[string]bool{
+	entity.ScopeQuestionsRead:  true,
+	entity.ScopeQuestionsWrite: true,
+	entity.ScopeAnswersWrite:   true,
+	entity.ScopeAdmin:          true,
+}
+
+var lowerBase32 = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+type APIKeyUseCase struct {
+	repo     APIKeyRepositoriy
+	userRepo UserRepositoriy
+}
+
+func NewAPIKeyUseCase(repo APIKeyRepositoriy, users UserRepositoriy) *APIKeyUseCase {
+	return &APIKeyUseCase{
+		repo:     repo,
+		userRepo: users,
+	}
+}
+
+// Create makes a key for the actor, the key itself is returned only here.
+func (uc *APIKeyUseCase) Create(actor entity.User, dto entity.APIKeyDto) (entity.CreatedAPIKey, error) {
+	if actor.ID == uuid.Nil {
+		return entity.CreatedAPIKey{}, ErrUnauthenticated
+	}
+
+	name := strings.TrimSpace(dto.Name)
+	if name == "" || utf8.RuneCountInString(name) > 50 {
+		return entity.CreatedAPIKey{}, Invalid("name", "length 1..50", "Name of API key must be from 1 to 50 characters")
+	}
+
+	scopes, err := normalizeScopes(dto.Scopes)
+	if err != nil {
+		return entity.CreatedAPIKey{}, err
+	}
+
+	keys, err := uc.repo.ListByUser(actor.ID)
+	if err != nil {
+		return entity.CreatedAPIKey{}, err
+	}
+
+	active := 0
+	for _, key := range keys {
+		if key.RevokedAt == nil {
+			active++
+		}
+	}
+	if active >= MaxAPIKeys {
+		return entity.CreatedAPIKey{}, Conflict("Too many API keys, revoke unused ones")
+	}
+
+	prefix, secret, err := newAPIKey()
+	if err != nil {
+		return entity.CreatedAPIKey{}, err
+	}
+	key := apiKeyPrefix + prefix + "_" + secret
+
+	saved, err := uc.repo.Save(entity.APIKey{
+		UserID:    actor.ID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hashToken(key),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return entity.CreatedAPIKey{}, err
+	}
+
+	return entity.CreatedAPIKey{APIKey: saved, Key: key}, nil
+}
+
+func (uc *APIKeyUseCase) List(actor entity.User) ([]entity.APIKey, error) {
+	if actor.ID == uuid.Nil {
+		return nil, ErrUnauthenticated
+	}
+
+	return uc.repo.ListByUser(actor.ID)
+}
+
+// Revoke disables the key for good, owners revoke their keys and admins revoke anybody's.
+func (uc *APIKeyUseCase) Revoke(actor entity.User, ID int) error {
+	key, err := uc.repo.GetByID(ID)
+	if err != nil {
+		return err
+	}
+
+	if key.UserID != actor.ID {
+		if err := canManageRoles(actor); err != nil {
+			return err
+		}
+	}
+
+	if key.RevokedAt != nil {
+		return nil
+	}
+
+	return uc.repo.Revoke(ID, time.Now())
+}
+
+// Authenticate resolves an API key to its owner, the key is returned to check its scopes.
+func (uc *APIKeyUseCase) Authenticate(raw string) (entity.User, entity.APIKey, error) {
+	rest, ok := strings.CutPrefix(raw, apiKeyPrefix)
+	if !ok {
+		return entity.User{}, entity.APIKey{}, ErrUnauthenticated
+	}
+
+	prefix, _, ok := strings.Cut(rest, "_")
+	if !ok {
+		return entity.User{}, entity.APIKey{}, ErrUnauthenticated
+	}
+
+	key, err := uc.repo.GetByPrefix(prefix)
+	if err != nil || key.RevokedAt != nil {
+		return entity.User{}, entity.APIKey{}, ErrUnauthenticated
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(hashToken(raw))) != 1 {
+		return entity.User{}, entity.APIKey{}, ErrUnauthenticated
+	}
+
+	user, err := uc.userRepo.GetByID(key.UserID)
+	if err != nil {
+		return entity.User{}, entity.APIKey{}, ErrUnauthenticated
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedPrecision {
+		if err := uc.repo.Touch(key.ID, now); err != nil {
+			return entity.User{}, entity.APIKey{}, err
+		}
+		key.LastUsedAt = &now
+	}
+
+	return user, key, nil
+}
+
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, Invalid("scopes", "required", "API key needs at least one scope")
+	}
+
+	seen := make(map[string]bool, len(scopes))
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !apiKeyScopes[scope] {
+			return nil, Invalid("scopes", "one_of", "Scope "+scope+" is unknown")
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+
+	return normalized, nil
+}
+
+// newAPIKey makes a random public prefix and a random secret.
+func newAPIKey() (string, string, error) {
+	raw := make([]byte, 5+32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+
+	return lowerBase32.EncodeToString(raw[:5]), base64.RawURLEncoding.EncodeToString(raw[5:]), nil
+}
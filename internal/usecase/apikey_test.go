@@ -0,0 +1,120 @@
+package usecase_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testovoe/internal/entity"
+	"testovoe/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAPIKeyRepo struct{ mock.Mock }
+
+func (m *MockAPIKeyRepo) GetByID(id int) (entity.APIKey, error) {
+	args := m.Called(id)
+	return args.Get(0).(entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) GetByPrefix(prefix string) (entity.APIKey, error) {
+	args := m.Called(prefix)
+	return args.Get(0).(entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) ListByUser(userID uuid.UUID) ([]entity.APIKey, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) Save(key entity.APIKey) (entity.APIKey, error) {
+	args := m.Called(key)
+	return args.Get(0).(entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) Revoke(id int, at time.Time) error {
+	args := m.Called(id, at)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepo) Touch(id int, at time.Time) error {
+	args := m.Called(id, at)
+	return args.Error(0)
+}
+
+func TestAPIKeyUseCase_CreateAndAuthenticate(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepo)
+	mockUserRepo := new(MockUserRepo)
+	uc := usecase.NewAPIKeyUseCase(mockRepo, mockUserRepo)
+	owner := entity.User{ID: uuid.New(), Role: entity.RoleUser}
+	mockRepo.On("ListByUser", owner.ID).Return([]entity.APIKey{}, nil)
+	mockUserRepo.On("GetByID", owner.ID).Return(owner, nil)
+
+	var saved entity.APIKey
+	mockRepo.On("Save", mock.Anything).Run(func(args mock.Arguments) {
+		saved = args.Get(0).(entity.APIKey)
+		saved.ID = 1
+	}).Return(entity.APIKey{ID: 1}, nil).Once()
+
+	created, err := uc.Create(owner, entity.APIKeyDto{Name: " bot ", Scopes: []string{entity.ScopeQuestionsWrite, entity.ScopeQuestionsWrite}})
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.Key, "qak_"+saved.Prefix+"_"))
+	assert.Equal(t, "bot", saved.Name)
+	assert.Equal(t, []string{entity.ScopeQuestionsWrite}, saved.Scopes)
+	assert.Len(t, saved.KeyHash, 64)
+
+	t.Run("authenticate", func(t *testing.T) {
+		mockRepo.On("GetByPrefix", saved.Prefix).Return(saved, nil)
+		mockRepo.On("Touch", 1, mock.Anything).Return(nil).Once()
+
+		user, key, err := uc.Authenticate(created.Key)
+
+		assert.NoError(t, err)
+		assert.Equal(t, owner.ID, user.ID)
+		assert.True(t, key.HasScope(entity.ScopeQuestionsWrite))
+		assert.False(t, key.HasScope(entity.ScopeAnswersWrite))
+		assert.NotNil(t, key.LastUsedAt)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		_, _, err := uc.Authenticate("qak_" + saved.Prefix + "_guessed")
+		assert.ErrorIs(t, err, usecase.ErrUnauthenticated)
+
+		_, _, err = uc.Authenticate("not a key")
+		assert.ErrorIs(t, err, usecase.ErrUnauthenticated)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := uc.Create(owner, entity.APIKeyDto{Name: "bot"})
+		assert.Error(t, err)
+
+		_, err = uc.Create(owner, entity.APIKeyDto{Name: "bot", Scopes: []string{"everything"}})
+		assert.Error(t, err)
+
+		_, err = uc.Create(owner, entity.APIKeyDto{Name: "  ", Scopes: []string{entity.ScopeAdmin}})
+		assert.Error(t, err)
+	})
+}
+
+func TestAPIKeyUseCase_Revoke(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepo)
+	uc := usecase.NewAPIKeyUseCase(mockRepo, new(MockUserRepo))
+	ownerID := uuid.New()
+	mockRepo.On("GetByID", 1).Return(entity.APIKey{ID: 1, UserID: ownerID}, nil)
+	mockRepo.On("GetByID", 2).Return(entity.APIKey{}, errors.New("not found"))
+
+	err := uc.Revoke(entity.User{ID: uuid.New(), Role: entity.RoleModerator}, 1)
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+
+	err = uc.Revoke(entity.User{ID: ownerID}, 2)
+	assert.Error(t, err)
+
+	mockRepo.On("Revoke", 1, mock.Anything).Return(nil).Once()
+	err = uc.Revoke(entity.User{ID: ownerID, Role: entity.RoleUser}, 1)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
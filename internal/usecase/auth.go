@@ -0,0 +1,235 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"testovoe/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TokenVerifier checks a bearer token and returns the id of the user it was issued to.
+type TokenVerifier interface {
+	Verify(token string) (uuid.UUID, error)
+}
+
+// TokenService issues access tokens that live for TTL and verifies them.
+type TokenService interface {
+	TokenVerifier
+	Issue(userID uuid.UUID) (string, error)
+	TTL() time.Duration
+}
+
+type RefreshTokenRepositoriy interface {
+	Save(entity.RefreshToken) (entity.RefreshToken, error)
+	GetByHash(string) (entity.RefreshToken, error)
+	// Revoke marks the token as used, false means it had already been revoked.
+	Revoke(hash string, at time.Time) (bool, error)
+	// RevokeAll ends every session of the user.
+	RevokeAll(userID uuid.UUID, at time.Time) error
+}
+
+// ErrUnauthenticated is returned when a token is missing, invalid, expired or belongs to an unknown user.
+var ErrUnauthenticated = Unauthenticated("Authentication is required")
+
+// ErrInvalidCredentials does not tell whether the email or the password was wrong.
+var ErrInvalidCredentials = Unauthenticated("Email or password is wrong")
+
+// ErrInvalidRefreshToken is returned for unknown, expired and already used refresh tokens.
+var ErrInvalidRefreshToken = Unauthenticated("Refresh token is invalid")
+
+const (
+	MinPasswordLength = 8
+	// MaxPasswordLength is the limit of bcrypt, longer passwords would be silently truncated.
+	MaxPasswordLength = 72
+)
+
+// dummyHash is compared against when the email is unknown, so a login takes the same time either way.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+type AuthUseCase struct {
+	tokens      TokenService
+	userRepo    UserRepositoriy
+	refreshRepo RefreshTokenRepositoriy
+	refreshTTL  time.Duration
+}
+
+func NewAuthUseCase(tokens TokenService, users UserRepositoriy, refreshTokens RefreshTokenRepositoriy, refreshTTL time.Duration) *AuthUseCase {
+	return &AuthUseCase{
+		tokens:      tokens,
+		userRepo:    users,
+		refreshRepo: refreshTokens,
+		refreshTTL:  refreshTTL,
+	}
+}
+
+// Authenticate resolves a bearer token to the user it was issued to.
+func (uc *AuthUseCase) Authenticate(token string) (entity.User, error) {
+	userID, err := uc.tokens.Verify(token)
+	if err != nil {
+		return entity.User{}, ErrUnauthenticated
+	}
+
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return entity.User{}, ErrUnauthenticated
+	}
+
+	return user, nil
+}
+
+// Register creates a user with a password and logs them in.
+func (uc *AuthUseCase) Register(dto entity.RegisterDto) (entity.TokenPair, error) {
+	name, nameErr := normalizeDisplayName(dto.DisplayName)
+	email, emailErr := normalizeEmail(dto.Email)
+	if err := joinInvalid(nameErr, emailErr, validatePassword(dto.Password)); err != nil {
+		return entity.TokenPair{}, err
+	}
+
+	if _, err := uc.userRepo.GetByEmail(email); err == nil {
+		return entity.TokenPair{}, Conflict("This email is already registered")
+	} else if KindOf(err) != KindNotFound {
+		return entity.TokenPair{}, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(dto.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return entity.TokenPair{}, err
+	}
+
+	user, err := uc.userRepo.Save(entity.User{
+		ID:           uuid.New(),
+		DisplayName:  name,
+		Email:        email,
+		Role:         entity.RoleUser,
+		PasswordHash: string(hash),
+		CreatedAt:    time.Now(),
+	})
+	if err != nil {
+		return entity.TokenPair{}, err
+	}
+
+	return uc.issue(user)
+}
+
+func (uc *AuthUseCase) Login(dto entity.LoginDto) (entity.TokenPair, error) {
+	user, err := uc.userRepo.GetByEmail(normalizeLogin(dto.Email))
+	if err != nil || user.PasswordHash == "" {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(dto.Password))
+		return entity.TokenPair{}, ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(dto.Password)); err != nil {
+		return entity.TokenPair{}, ErrInvalidCredentials
+	}
+
+	return uc.issue(user)
+}
+
+// Refresh exchanges a refresh token for a new pair, every refresh token works once.
+// A reused token means it has leaked, so all sessions of its user are ended.
+func (uc *AuthUseCase) Refresh(dto entity.RefreshDto) (entity.TokenPair, error) {
+	stored, err := uc.refreshRepo.GetByHash(hashToken(dto.RefreshToken))
+	if err != nil {
+		return entity.TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	now := time.Now()
+	fresh, err := uc.refreshRepo.Revoke(stored.TokenHash, now)
+	if err != nil {
+		return entity.TokenPair{}, err
+	}
+
+	if !fresh {
+		if err := uc.refreshRepo.RevokeAll(stored.UserID, now); err != nil {
+			return entity.TokenPair{}, err
+		}
+		return entity.TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	if now.After(stored.ExpiresAt) {
+		return entity.TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	user, err := uc.userRepo.GetByID(stored.UserID)
+	if err != nil {
+		return entity.TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	return uc.issue(user)
+}
+
+// Logout revokes the refresh token, access tokens already issued expire on their own.
+func (uc *AuthUseCase) Logout(dto entity.RefreshDto) error {
+	stored, err := uc.refreshRepo.GetByHash(hashToken(dto.RefreshToken))
+	if err != nil {
+		return ErrInvalidRefreshToken
+	}
+
+	_, err = uc.refreshRepo.Revoke(stored.TokenHash, time.Now())
+	return err
+}
+
+func (uc *AuthUseCase) issue(user entity.User) (entity.TokenPair, error) {
+	access, err := uc.tokens.Issue(user.ID)
+	if err != nil {
+		return entity.TokenPair{}, err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return entity.TokenPair{}, err
+	}
+	refresh := base64.RawURLEncoding.EncodeToString(raw)
+
+	now := time.Now()
+	_, err = uc.refreshRepo.Save(entity.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(refresh),
+		ExpiresAt: now.Add(uc.refreshTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return entity.TokenPair{}, err
+	}
+
+	return entity.TokenPair{
+		AccessToken:  access,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(uc.tokens.TTL().Seconds()),
+		RefreshToken: refresh,
+		User:         user,
+	}, nil
+}
+
+// hashToken is what is stored for refresh tokens and API keys, they are random so a fast hash is enough.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func validatePassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return Invalid("password", "min_length "+strconv.Itoa(MinPasswordLength), "Password is short")
+	}
+
+	if len(password) > MaxPasswordLength {
+		return Invalid("password", "max_bytes "+strconv.Itoa(MaxPasswordLength), "Password is long")
+	}
+
+	return nil
+}
+
+// normalizeLogin brings the email to the stored form without rejecting it, a bad email just does not match.
+func normalizeLogin(email string) string {
+	normalized, err := normalizeEmail(email)
+	if err != nil {
+		return email
+	}
+	return normalized
+}
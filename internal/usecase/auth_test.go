@@ -0,0 +1,151 @@
+package usecase_test
+
+import (
+	"errors"
+	"testing"
+	"testovoe/internal/entity"
+	"testovoe/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type MockTokens struct{ mock.Mock }
+
+func (m *MockTokens) Verify(token string) (uuid.UUID, error) {
+	args := m.Called(token)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockTokens) Issue(userID uuid.UUID) (string, error) {
+	args := m.Called(userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTokens) TTL() time.Duration {
+	return time.Minute
+}
+
+type MockRefreshTokenRepo struct{ mock.Mock }
+
+func (m *MockRefreshTokenRepo) Save(token entity.RefreshToken) (entity.RefreshToken, error) {
+	args := m.Called(token)
+	return args.Get(0).(entity.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepo) GetByHash(hash string) (entity.RefreshToken, error) {
+	args := m.Called(hash)
+	return args.Get(0).(entity.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepo) Revoke(hash string, at time.Time) (bool, error) {
+	args := m.Called(hash, at)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepo) RevokeAll(userID uuid.UUID, at time.Time) error {
+	args := m.Called(userID, at)
+	return args.Error(0)
+}
+
+func TestAuthUseCase_Authenticate(t *testing.T) {
+	mockTokens := new(MockTokens)
+	mockUserRepo := new(MockUserRepo)
+	uc := usecase.NewAuthUseCase(mockTokens, mockUserRepo, new(MockRefreshTokenRepo), time.Hour)
+	userID, removedID := uuid.New(), uuid.New()
+
+	mockTokens.On("Verify", "good").Return(userID, nil)
+	mockTokens.On("Verify", "expired").Return(uuid.Nil, errors.New("token is expired"))
+	mockTokens.On("Verify", "removed").Return(removedID, nil)
+	mockUserRepo.On("GetByID", userID).Return(entity.User{ID: userID}, nil)
+	mockUserRepo.On("GetByID", removedID).Return(entity.User{}, usecase.NotFound("not found"))
+
+	user, err := uc.Authenticate("good")
+	assert.NoError(t, err)
+	assert.Equal(t, userID, user.ID)
+
+	_, err = uc.Authenticate("expired")
+	assert.ErrorIs(t, err, usecase.ErrUnauthenticated)
+
+	_, err = uc.Authenticate("removed")
+	assert.ErrorIs(t, err, usecase.ErrUnauthenticated)
+}
+
+func TestAuthUseCase_Login(t *testing.T) {
+	mockTokens := new(MockTokens)
+	mockUserRepo := new(MockUserRepo)
+	mockRefreshRepo := new(MockRefreshTokenRepo)
+	uc := usecase.NewAuthUseCase(mockTokens, mockUserRepo, mockRefreshRepo, time.Hour)
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	user := entity.User{ID: uuid.New(), Email: "alice@example.com", PasswordHash: string(hash)}
+	mockUserRepo.On("GetByEmail", "alice@example.com").Return(user, nil)
+	mockUserRepo.On("GetByEmail", "nobody@example.com").Return(entity.User{}, usecase.NotFound("not found"))
+
+	t.Run("success", func(t *testing.T) {
+		mockTokens.On("Issue", user.ID).Return("access", nil).Once()
+		mockRefreshRepo.On("Save", mock.MatchedBy(func(token entity.RefreshToken) bool {
+			return token.UserID == user.ID && len(token.TokenHash) == 64
+		})).Return(entity.RefreshToken{}, nil).Once()
+
+		tokens, err := uc.Login(entity.LoginDto{Email: " Alice@example.com", Password: "correct horse"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "access", tokens.AccessToken)
+		assert.Equal(t, 60, tokens.ExpiresIn)
+		assert.NotEmpty(t, tokens.RefreshToken)
+		mockRefreshRepo.AssertExpectations(t)
+	})
+
+	t.Run("wrong password", func(t *testing.T) {
+		_, err := uc.Login(entity.LoginDto{Email: "alice@example.com", Password: "wrong horse"})
+		assert.ErrorIs(t, err, usecase.ErrInvalidCredentials)
+	})
+
+	t.Run("unknown email", func(t *testing.T) {
+		_, err := uc.Login(entity.LoginDto{Email: "nobody@example.com", Password: "correct horse"})
+		assert.ErrorIs(t, err, usecase.ErrInvalidCredentials)
+	})
+}
+
+func TestAuthUseCase_Refresh(t *testing.T) {
+	mockTokens := new(MockTokens)
+	mockUserRepo := new(MockUserRepo)
+	mockRefreshRepo := new(MockRefreshTokenRepo)
+	uc := usecase.NewAuthUseCase(mockTokens, mockUserRepo, mockRefreshRepo, time.Hour)
+	userID := uuid.New()
+
+	t.Run("reused token ends all sessions", func(t *testing.T) {
+		stored := entity.RefreshToken{UserID: userID, TokenHash: "used", ExpiresAt: time.Now().Add(time.Hour)}
+		mockRefreshRepo.On("GetByHash", mock.Anything).Return(stored, nil).Once()
+		mockRefreshRepo.On("Revoke", "used", mock.Anything).Return(false, nil).Once()
+		mockRefreshRepo.On("RevokeAll", userID, mock.Anything).Return(nil).Once()
+
+		_, err := uc.Refresh(entity.RefreshDto{RefreshToken: "stolen"})
+
+		assert.ErrorIs(t, err, usecase.ErrInvalidRefreshToken)
+		mockRefreshRepo.AssertExpectations(t)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		stored := entity.RefreshToken{UserID: userID, TokenHash: "old", ExpiresAt: time.Now().Add(-time.Minute)}
+		mockRefreshRepo.On("GetByHash", mock.Anything).Return(stored, nil).Once()
+		mockRefreshRepo.On("Revoke", "old", mock.Anything).Return(true, nil).Once()
+
+		_, err := uc.Refresh(entity.RefreshDto{RefreshToken: "old"})
+
+		assert.ErrorIs(t, err, usecase.ErrInvalidRefreshToken)
+		mockTokens.AssertNotCalled(t, "Issue", userID)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		mockRefreshRepo.On("GetByHash", mock.Anything).Return(entity.RefreshToken{}, usecase.NotFound("not found")).Once()
+
+		_, err := uc.Refresh(entity.RefreshDto{RefreshToken: "made up"})
+
+		assert.ErrorIs(t, err, usecase.ErrInvalidRefreshToken)
+	})
+}
@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"testovoe/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+// ErrForbidden is returned when the user is known but is not allowed to do the action.
+var ErrForbidden = Forbidden("You are not allowed to do this")
+
+// canModify lets authors change their own content and moderators change anything.
+func canModify(actor entity.User, authorID uuid.UUID) error {
+	if actor.ID == uuid.Nil {
+		return ErrUnauthenticated
+	}
+
+	if actor.ID != authorID && !actor.IsModerator() {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+func canModerate(actor entity.User) error {
+	if actor.ID == uuid.Nil {
+		return ErrUnauthenticated
+	}
+
+	if !actor.IsModerator() {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+func canManageRoles(actor entity.User) error {
+	if actor.ID == uuid.Nil {
+		return ErrUnauthenticated
+	}
+
+	if !actor.IsAdmin() {
+		return ErrForbidden
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"testovoe/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CommentRepositoriy interface {
+	GetByID(int) (entity.Comment, error)
+	ListByTarget(targetType string, targetID int, page entity.PageRequest) ([]entity.Comment, error)
+	// ListByTargets returns all comments of the given targets of one type ordered by creation time.
+	ListByTargets(targetType string, targetIDs []int) ([]entity.Comment, error)
+	Save(entity.Comment) (entity.Comment, error)
+	Delete(int) error
+}
+
+type CommentUseCase struct {
+	repo      CommentRepositoriy
+	questRepo QuestionRepositoriy
+	ansRepo   AnswerRepositoriy
+	limit     LengthLimit
+}
+
+func NewCommentUseCase(repo CommentRepositoriy, quest QuestionRepositoriy, ansrepo AnswerRepositoriy, limits TextLimits) *CommentUseCase {
+	return &CommentUseCase{
+		repo:      repo,
+		questRepo: quest,
+		ansRepo:   ansrepo,
+		limit:     limits.Comment,
+	}
+}
+
+func (uc *CommentUseCase) CommentQuestion(questionID int, dto entity.CommentDto) (entity.Comment, error) {
+	question, err := visibleQuestion(uc.questRepo, questionID)
+	if err != nil {
+		return entity.Comment{}, err
+	}
+
+	if question.LockedAt != nil {
+		return entity.Comment{}, ErrLocked
+	}
+
+	return uc.save(entity.CommentTargetQuestion, questionID, dto)
+}
+
+func (uc *CommentUseCase) CommentAnswer(answerID int, dto entity.CommentDto) (entity.Comment, error) {
+	answer, err := visibleAnswer(uc.ansRepo, uc.questRepo, answerID)
+	if err != nil {
+		return entity.Comment{}, err
+	}
+
+	if answer.LockedAt != nil {
+		return entity.Comment{}, ErrLocked
+	}
+
+	return uc.save(entity.CommentTargetAnswer, answerID, dto)
+}
+
+func (uc *CommentUseCase) ListByQuestion(questionID int, page entity.PageRequest) (entity.Page[entity.Comment], error) {
+	if _, err := visibleQuestion(uc.questRepo, questionID); err != nil {
+		return entity.Page[entity.Comment]{}, err
+	}
+
+	return uc.list(entity.CommentTargetQuestion, questionID, page)
+}
+
+func (uc *CommentUseCase) ListByAnswer(answerID int, page entity.PageRequest) (entity.Page[entity.Comment], error) {
+	if _, err := visibleAnswer(uc.ansRepo, uc.questRepo, answerID); err != nil {
+		return entity.Page[entity.Comment]{}, err
+	}
+
+	return uc.list(entity.CommentTargetAnswer, answerID, page)
+}
+
+// Delete removes the comment, only its author or a moderator may do it.
+func (uc *CommentUseCase) Delete(actor entity.User, commentID int) error {
+	comment, err := uc.repo.GetByID(commentID)
+	if err != nil {
+		return err
+	}
+
+	if err := canModify(actor, comment.UserID); err != nil {
+		return err
+	}
+
+	return uc.repo.Delete(commentID)
+}
+
+func (uc *CommentUseCase) save(targetType string, targetID int, dto entity.CommentDto) (entity.Comment, error) {
+	if dto.UserID == uuid.Nil {
+		return entity.Comment{}, ErrUnauthenticated
+	}
+
+	text, err := cleanText("comment", dto.Text, uc.limit)
+	if err != nil {
+		return entity.Comment{}, err
+	}
+
+	comment := entity.Comment{
+		TargetType: targetType,
+		TargetID:   targetID,
+		UserID:     dto.UserID,
+		Text:       text,
+		CreatedAt:  time.Now(),
+	}
+
+	return uc.repo.Save(comment)
+}
+
+func (uc *CommentUseCase) list(targetType string, targetID int, page entity.PageRequest) (entity.Page[entity.Comment], error) {
+	return fetchPage(
+		page,
+		func(page entity.PageRequest) ([]entity.Comment, error) {
+			return uc.repo.ListByTarget(targetType, targetID, page)
+		},
+		func(c entity.Comment) entity.Cursor {
+			return entity.Cursor{Time: c.CreatedAt, ID: c.ID}
+		},
+	)
+}
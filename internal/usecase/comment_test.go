@@ -0,0 +1,75 @@
+package usecase_test
+
+import (
+	"errors"
+	"testing"
+	"testovoe/internal/entity"
+	"testovoe/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCommentRepo struct{ mock.Mock }
+
+func (m *MockCommentRepo) GetByID(id int) (entity.Comment, error) {
+	args := m.Called(id)
+	return args.Get(0).(entity.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepo) ListByTarget(targetType string, targetID int, page entity.PageRequest) ([]entity.Comment, error) {
+	args := m.Called(targetType, targetID, page)
+	return args.Get(0).([]entity.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepo) ListByTargets(targetType string, targetIDs []int) ([]entity.Comment, error) {
+	args := m.Called(targetType, targetIDs)
+	return args.Get(0).([]entity.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepo) Save(comment entity.Comment) (entity.Comment, error) {
+	args := m.Called(comment)
+	return args.Get(0).(entity.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepo) Delete(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func TestCommentUseCase_CommentAnswer(t *testing.T) {
+	mockCommentRepo := new(MockCommentRepo)
+	mockAnswerRepo := new(MockAnswerRepo)
+	mockQuestionRepo := new(MockQuestionRepo)
+	uc := usecase.NewCommentUseCase(mockCommentRepo, mockQuestionRepo, mockAnswerRepo, usecase.DefaultTextLimits())
+	userID := uuid.New()
+
+	t.Run("success", func(t *testing.T) {
+		mockAnswerRepo.On("GetByID", 2).Return(entity.Answer{ID: 2, QuestionID: 1}, nil)
+		mockQuestionRepo.On("GetByID", 1).Return(entity.Question{ID: 1}, nil)
+		mockCommentRepo.On("Save", mock.MatchedBy(func(c entity.Comment) bool {
+			return c.TargetType == entity.CommentTargetAnswer && c.TargetID == 2 && !c.CreatedAt.IsZero()
+		})).Return(entity.Comment{ID: 1, TargetType: entity.CommentTargetAnswer, TargetID: 2}, nil)
+
+		comment, err := uc.CommentAnswer(2, entity.CommentDto{UserID: userID, Text: "Which version?"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, comment.ID)
+		mockCommentRepo.AssertExpectations(t)
+	})
+
+	t.Run("answer not found", func(t *testing.T) {
+		mockAnswerRepo.On("GetByID", 999).Return(entity.Answer{}, errors.New("not found"))
+
+		_, err := uc.CommentAnswer(999, entity.CommentDto{UserID: userID, Text: "Which version?"})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("short text", func(t *testing.T) {
+		_, err := uc.CommentAnswer(2, entity.CommentDto{UserID: userID, Text: "?"})
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,127 @@
+package usecase
+
+import "errors"
+
+// ErrorKind tells transports how to report a failure of a use case.
+type ErrorKind int
+
+const (
+	// KindInternal is a failure the client can not fix, its details are not shown to the client.
+	KindInternal ErrorKind = iota
+	KindValidation
+	KindNotFound
+	KindConflict
+	KindForbidden
+	KindUnauthenticated
+	// KindPrecondition is a change based on a version that is no longer current.
+	KindPrecondition
+	// KindMalformed is a request that could not have come from following our own responses,
+	// such as a cursor of one sort passed to another.
+	KindMalformed
+)
+
+// Error is a failure of a use case. Message is meant for the client, Err is the cause
+// of an internal error and is only logged. Validation errors list the invalid fields.
+type Error struct {
+	Kind    ErrorKind
+	Message string
+	Err     error
+	Fields  []FieldError
+}
+
+// FieldError names an invalid field of the request and the rule it breaks, such as text and "min_length 5".
+type FieldError struct {
+	Field string
+	Rule  string
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func Validation(message string) error {
+	return &Error{Kind: KindValidation, Message: message}
+}
+
+// Invalid is a validation error of one field.
+func Invalid(field, rule, message string) error {
+	return &Error{Kind: KindValidation, Message: message, Fields: []FieldError{{Field: field, Rule: rule}}}
+}
+
+// joinInvalid reports all invalid fields at once. Errors other than validation ones are returned
+// as they are, the first of them wins.
+func joinInvalid(errs ...error) error {
+	var joined *Error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		var typed *Error
+		if !errors.As(err, &typed) || typed.Kind != KindValidation {
+			return err
+		}
+
+		if joined == nil {
+			joined = &Error{Kind: KindValidation, Message: typed.Message}
+		} else {
+			joined.Message += "; " + typed.Message
+		}
+		joined.Fields = append(joined.Fields, typed.Fields...)
+	}
+
+	if joined == nil {
+		return nil
+	}
+	return joined
+}
+
+func NotFound(message string) error {
+	return &Error{Kind: KindNotFound, Message: message}
+}
+
+func Conflict(message string) error {
+	return &Error{Kind: KindConflict, Message: message}
+}
+
+func Forbidden(message string) error {
+	return &Error{Kind: KindForbidden, Message: message}
+}
+
+func Unauthenticated(message string) error {
+	return &Error{Kind: KindUnauthenticated, Message: message}
+}
+
+func Precondition(message string) error {
+	return &Error{Kind: KindPrecondition, Message: message}
+}
+
+// Internal wraps an unexpected failure, typically of the database.
+func Internal(err error) error {
+	return &Error{Kind: KindInternal, Message: "Internal error", Err: err}
+}
+
+// KindOf returns the kind of the error, errors that are not typed are internal.
+func KindOf(err error) ErrorKind {
+	var typed *Error
+	if errors.As(err, &typed) {
+		return typed.Kind
+	}
+	return KindInternal
+}
+
+// FieldsOf returns the invalid fields of a validation error.
+func FieldsOf(err error) []FieldError {
+	var typed *Error
+	if errors.As(err, &typed) {
+		return typed.Fields
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package usecase_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"testovoe/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKindOf(t *testing.T) {
+	assert.Equal(t, usecase.KindValidation, usecase.KindOf(usecase.Validation("Text is short")))
+	assert.Equal(t, usecase.KindNotFound, usecase.KindOf(fmt.Errorf("loading: %w", usecase.NotFound("This question is not exist"))))
+	assert.Equal(t, usecase.KindForbidden, usecase.KindOf(usecase.ErrForbidden))
+	assert.Equal(t, usecase.KindUnauthenticated, usecase.KindOf(usecase.ErrUnauthenticated))
+	assert.Equal(t, usecase.KindPrecondition, usecase.KindOf(usecase.ErrVersionMismatch))
+	assert.Equal(t, usecase.KindInternal, usecase.KindOf(errors.New("connection refused")))
+}
+
+func TestInternal(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := usecase.Internal(cause)
+
+	assert.Equal(t, usecase.KindInternal, usecase.KindOf(err))
+	assert.ErrorIs(t, err, cause)
+}
+
+func TestFieldsOf(t *testing.T) {
+	err := usecase.Invalid("text", "min_length 5", "Text of question is short")
+
+	assert.Equal(t, usecase.KindValidation, usecase.KindOf(err))
+	assert.Equal(t, []usecase.FieldError{{Field: "text", Rule: "min_length 5"}}, usecase.FieldsOf(err))
+	assert.Empty(t, usecase.FieldsOf(usecase.NotFound("This question is not exist")))
+}
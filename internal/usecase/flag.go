@@ -0,0 +1,196 @@
+package usecase
+
+import (
+	"strconv"
+	"testovoe/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type FlagRepositoriy interface {
+	// Create saves the flag unless the user has already flagged the post, false tells it was there.
+	Create(entity.Flag) (entity.Flag, bool, error)
+	GetByID(int) (entity.Flag, error)
+	ListPending(entity.PageRequest) ([]entity.Flag, error)
+	CountPending(targetType string, targetID int) (int, error)
+	// Decide hides, shows, locks or trashes the post as the decision says, moves pending flags of
+	// the post to the status unless it is empty and records the decision, all in one transaction.
+	Decide(decision entity.ModerationDecision, status string) (entity.ModerationDecision, error)
+	ListDecisions(entity.PageRequest) ([]entity.ModerationDecision, error)
+}
+
+var (
+	ErrAlreadyFlagged = Conflict("You have already flagged this post")
+	ErrOwnPost        = Forbidden("You can not flag your own post")
+	ErrFlagResolved   = Conflict("This flag is already resolved")
+	// ErrLocked is returned for edits, answers and comments to a locked post.
+	ErrLocked = Conflict("This post is locked")
+)
+
+var flagReasons = map[string]bool{
+	entity.FlagSpam:       true,
+	entity.FlagOffensive:  true,
+	entity.FlagOffTopic:   true,
+	entity.FlagLowQuality: true,
+	entity.FlagOther:      true,
+}
+
+var decisionActions = map[string]bool{
+	entity.ActionHide:    true,
+	entity.ActionLock:    true,
+	entity.ActionDelete:  true,
+	entity.ActionDismiss: true,
+}
+
+// FlagUseCase lets users report posts and moderators decide on them. A post flagged by hideAfter
+// users is hidden until a moderator looks at it, zero turns hiding off.
+type FlagUseCase struct {
+	flags     FlagRepositoriy
+	questRepo QuestionRepositoriy
+	ansRepo   AnswerRepositoriy
+	hideAfter int
+}
+
+func NewFlagUseCase(flags FlagRepositoriy, quest QuestionRepositoriy, ansrepo AnswerRepositoriy, hideAfter int) *FlagUseCase {
+	return &FlagUseCase{
+		flags:     flags,
+		questRepo: quest,
+		ansRepo:   ansrepo,
+		hideAfter: hideAfter,
+	}
+}
+
+func (uc *FlagUseCase) FlagQuestion(questionID int, dto entity.FlagDto) (entity.Flag, error) {
+	return uc.flag(entity.FlagTargetQuestion, questionID, dto)
+}
+
+func (uc *FlagUseCase) FlagAnswer(answerID int, dto entity.FlagDto) (entity.Flag, error) {
+	return uc.flag(entity.FlagTargetAnswer, answerID, dto)
+}
+
+func (uc *FlagUseCase) flag(targetType string, targetID int, dto entity.FlagDto) (entity.Flag, error) {
+	if dto.UserID == uuid.Nil {
+		return entity.Flag{}, ErrUnauthenticated
+	}
+
+	if !flagReasons[dto.Reason] {
+		return entity.Flag{}, Invalid("reason", "one_of", "Reason must be spam, offensive, off_topic, low_quality or other")
+	}
+
+	authorID, err := uc.author(targetType, targetID)
+	if err != nil {
+		return entity.Flag{}, err
+	}
+
+	if authorID == dto.UserID {
+		return entity.Flag{}, ErrOwnPost
+	}
+
+	flag, created, err := uc.flags.Create(entity.Flag{
+		TargetType: targetType,
+		TargetID:   targetID,
+		UserID:     dto.UserID,
+		Reason:     dto.Reason,
+		Status:     entity.FlagPending,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		return entity.Flag{}, err
+	}
+	if !created {
+		return entity.Flag{}, ErrAlreadyFlagged
+	}
+
+	if uc.hideAfter <= 0 {
+		return flag, nil
+	}
+
+	count, err := uc.flags.CountPending(targetType, targetID)
+	if err != nil {
+		return entity.Flag{}, err
+	}
+
+	if count >= uc.hideAfter {
+		_, err := uc.flags.Decide(entity.ModerationDecision{
+			TargetType: targetType,
+			TargetID:   targetID,
+			Action:     entity.ActionHide,
+			Note:       "Hidden after " + strconv.Itoa(count) + " flags",
+			CreatedAt:  time.Now(),
+		}, "")
+		if err != nil {
+			return entity.Flag{}, err
+		}
+	}
+
+	return flag, nil
+}
+
+// ListPending shows flags waiting for a moderator, oldest first.
+func (uc *FlagUseCase) ListPending(actor entity.User, page entity.PageRequest) (entity.Page[entity.Flag], error) {
+	if err := canModerate(actor); err != nil {
+		return entity.Page[entity.Flag]{}, err
+	}
+
+	return fetchPage(page, uc.flags.ListPending, func(f entity.Flag) entity.Cursor {
+		return entity.Cursor{Time: f.CreatedAt, ID: f.ID}
+	})
+}
+
+// ListDecisions is the log of moderation decisions, newest first.
+func (uc *FlagUseCase) ListDecisions(actor entity.User, page entity.PageRequest) (entity.Page[entity.ModerationDecision], error) {
+	if err := canModerate(actor); err != nil {
+		return entity.Page[entity.ModerationDecision]{}, err
+	}
+
+	return fetchPage(page, uc.flags.ListDecisions, func(d entity.ModerationDecision) entity.Cursor {
+		return entity.Cursor{Time: d.CreatedAt, ID: d.ID}
+	})
+}
+
+// Decide resolves the flag together with all other pending flags of its post. Dismissing shows
+// a hidden post again, other actions are applied to the post along with recording the decision.
+func (uc *FlagUseCase) Decide(actor entity.User, flagID int, dto entity.DecisionDto) (entity.ModerationDecision, error) {
+	if err := canModerate(actor); err != nil {
+		return entity.ModerationDecision{}, err
+	}
+
+	if !decisionActions[dto.Action] {
+		return entity.ModerationDecision{}, Invalid("action", "one_of", "Action must be hide, lock, delete or dismiss")
+	}
+
+	flag, err := uc.flags.GetByID(flagID)
+	if err != nil {
+		return entity.ModerationDecision{}, err
+	}
+
+	if flag.Status != entity.FlagPending {
+		return entity.ModerationDecision{}, ErrFlagResolved
+	}
+
+	status := entity.FlagActioned
+	if dto.Action == entity.ActionDismiss {
+		status = entity.FlagDismissed
+	}
+
+	return uc.flags.Decide(entity.ModerationDecision{
+		TargetType:  flag.TargetType,
+		TargetID:    flag.TargetID,
+		ModeratorID: &actor.ID,
+		Action:      dto.Action,
+		Note:        dto.Note,
+		CreatedAt:   time.Now(),
+	}, status)
+}
+
+// author is the author of a post readers may see, a hidden post can not be flagged again.
+func (uc *FlagUseCase) author(targetType string, targetID int) (uuid.UUID, error) {
+	if targetType == entity.FlagTargetQuestion {
+		question, err := visibleQuestion(uc.questRepo, targetID)
+		return question.UserID, err
+	}
+
+	answer, err := visibleAnswer(uc.ansRepo, uc.questRepo, targetID)
+	return answer.UserID, err
+}
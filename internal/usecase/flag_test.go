@@ -0,0 +1,160 @@
+package usecase_test
+
+import (
+	"testing"
+	"testovoe/internal/entity"
+	"testovoe/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockFlagRepo struct{ mock.Mock }
+
+func (m *MockFlagRepo) Create(flag entity.Flag) (entity.Flag, bool, error) {
+	args := m.Called(flag)
+	return args.Get(0).(entity.Flag), args.Bool(1), args.Error(2)
+}
+
+func (m *MockFlagRepo) GetByID(id int) (entity.Flag, error) {
+	args := m.Called(id)
+	return args.Get(0).(entity.Flag), args.Error(1)
+}
+
+func (m *MockFlagRepo) ListPending(page entity.PageRequest) ([]entity.Flag, error) {
+	args := m.Called(page)
+	return args.Get(0).([]entity.Flag), args.Error(1)
+}
+
+func (m *MockFlagRepo) CountPending(targetType string, targetID int) (int, error) {
+	args := m.Called(targetType, targetID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockFlagRepo) Decide(decision entity.ModerationDecision, status string) (entity.ModerationDecision, error) {
+	args := m.Called(decision, status)
+	return args.Get(0).(entity.ModerationDecision), args.Error(1)
+}
+
+func (m *MockFlagRepo) ListDecisions(page entity.PageRequest) ([]entity.ModerationDecision, error) {
+	args := m.Called(page)
+	return args.Get(0).([]entity.ModerationDecision), args.Error(1)
+}
+
+func TestFlagUseCase_Flag(t *testing.T) {
+	flagRepo := new(MockFlagRepo)
+	mockQuestionRepo := new(MockQuestionRepo)
+	uc := usecase.NewFlagUseCase(flagRepo, mockQuestionRepo, new(MockAnswerRepo), 2)
+	authorID, userID := uuid.New(), uuid.New()
+	mockQuestionRepo.On("GetByID", 1).Return(entity.Question{ID: 1, UserID: authorID}, nil)
+
+	t.Run("unknown reason", func(t *testing.T) {
+		_, err := uc.FlagQuestion(1, entity.FlagDto{UserID: userID, Reason: "boring"})
+
+		assert.Equal(t, []usecase.FieldError{{Field: "reason", Rule: "one_of"}}, usecase.FieldsOf(err))
+	})
+
+	t.Run("own post", func(t *testing.T) {
+		_, err := uc.FlagQuestion(1, entity.FlagDto{UserID: authorID, Reason: entity.FlagSpam})
+
+		assert.ErrorIs(t, err, usecase.ErrOwnPost)
+	})
+
+	t.Run("flagged twice", func(t *testing.T) {
+		flagRepo.On("Create", mock.MatchedBy(func(f entity.Flag) bool { return f.UserID == userID })).Return(entity.Flag{}, false, nil).Once()
+
+		_, err := uc.FlagQuestion(1, entity.FlagDto{UserID: userID, Reason: entity.FlagSpam})
+
+		assert.ErrorIs(t, err, usecase.ErrAlreadyFlagged)
+	})
+
+	t.Run("hidden after enough flags", func(t *testing.T) {
+		flagRepo.On("Create", mock.MatchedBy(func(f entity.Flag) bool {
+			return f.TargetType == entity.FlagTargetQuestion && f.TargetID == 1 && f.Status == entity.FlagPending
+		})).Return(entity.Flag{ID: 2}, true, nil).Once()
+		flagRepo.On("CountPending", entity.FlagTargetQuestion, 1).Return(2, nil).Once()
+		flagRepo.On("Decide", mock.MatchedBy(func(d entity.ModerationDecision) bool {
+			return d.Action == entity.ActionHide && d.ModeratorID == nil && d.TargetID == 1
+		}), "").Return(entity.ModerationDecision{}, nil).Once()
+
+		flag, err := uc.FlagQuestion(1, entity.FlagDto{UserID: userID, Reason: entity.FlagOffensive})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, flag.ID)
+		flagRepo.AssertExpectations(t)
+	})
+
+	t.Run("hidden post", func(t *testing.T) {
+		hiddenAt := time.Now()
+		mockQuestionRepo.On("GetByID", 2).Return(entity.Question{ID: 2, UserID: authorID, HiddenAt: &hiddenAt}, nil)
+
+		_, err := uc.FlagQuestion(2, entity.FlagDto{UserID: userID, Reason: entity.FlagSpam})
+
+		assert.Equal(t, usecase.KindNotFound, usecase.KindOf(err))
+	})
+}
+
+func TestFlagUseCase_Decide(t *testing.T) {
+	flagRepo := new(MockFlagRepo)
+	mockAnswerRepo := new(MockAnswerRepo)
+	uc := usecase.NewFlagUseCase(flagRepo, new(MockQuestionRepo), mockAnswerRepo, 0)
+	moderator := entity.User{ID: uuid.New(), Role: entity.RoleModerator}
+	flagRepo.On("GetByID", 1).Return(entity.Flag{ID: 1, TargetType: entity.FlagTargetAnswer, TargetID: 3, Status: entity.FlagPending}, nil)
+	flagRepo.On("GetByID", 2).Return(entity.Flag{ID: 2, Status: entity.FlagDismissed}, nil)
+
+	t.Run("users may not decide", func(t *testing.T) {
+		_, err := uc.Decide(entity.User{ID: uuid.New()}, 1, entity.DecisionDto{Action: entity.ActionHide})
+		assert.ErrorIs(t, err, usecase.ErrForbidden)
+	})
+
+	t.Run("unknown action", func(t *testing.T) {
+		_, err := uc.Decide(moderator, 1, entity.DecisionDto{Action: "ban"})
+		assert.Equal(t, usecase.KindValidation, usecase.KindOf(err))
+	})
+
+	t.Run("resolved flag", func(t *testing.T) {
+		_, err := uc.Decide(moderator, 2, entity.DecisionDto{Action: entity.ActionHide})
+		assert.ErrorIs(t, err, usecase.ErrFlagResolved)
+	})
+
+	t.Run("dismiss", func(t *testing.T) {
+		flagRepo.On("Decide", mock.MatchedBy(func(d entity.ModerationDecision) bool {
+			return d.Action == entity.ActionDismiss && *d.ModeratorID == moderator.ID && d.Note == "Fine"
+		}), entity.FlagDismissed).Return(entity.ModerationDecision{ID: 1, Action: entity.ActionDismiss}, nil).Once()
+
+		decision, err := uc.Decide(moderator, 1, entity.DecisionDto{Action: entity.ActionDismiss, Note: "Fine"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, entity.ActionDismiss, decision.Action)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		flagRepo.On("Decide", mock.MatchedBy(func(d entity.ModerationDecision) bool {
+			return d.Action == entity.ActionDelete && d.TargetType == entity.FlagTargetAnswer
+		}), entity.FlagActioned).Return(entity.ModerationDecision{ID: 2, Action: entity.ActionDelete}, nil).Once()
+
+		_, err := uc.Decide(moderator, 1, entity.DecisionDto{Action: entity.ActionDelete})
+
+		assert.NoError(t, err)
+		mockAnswerRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+}
+
+func TestLockedPosts(t *testing.T) {
+	mockQuestionRepo := new(MockQuestionRepo)
+	mockAnswerRepo := new(MockAnswerRepo)
+	uc := usecase.NewAnswerUseCase(mockAnswerRepo, mockQuestionRepo, new(MockUserRepo), usecase.DefaultTextLimits(), nil)
+	lockedAt := time.Now()
+	authorID := uuid.New()
+	mockQuestionRepo.On("GetByID", 1).Return(entity.Question{ID: 1, LockedAt: &lockedAt}, nil)
+	mockAnswerRepo.On("GetByID", 2).Return(entity.Answer{ID: 2, UserID: authorID, Text: "Old answer", LockedAt: &lockedAt}, nil)
+
+	_, err := uc.Save(entity.AnswerDto{UserID: authorID, Text: "New answer"}, 1)
+	assert.ErrorIs(t, err, usecase.ErrLocked)
+
+	text := "Edited answer"
+	_, err = uc.Update(entity.User{ID: authorID}, 2, entity.AnswerUpdateDto{Text: &text})
+	assert.ErrorIs(t, err, usecase.ErrLocked)
+}
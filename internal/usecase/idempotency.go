@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"errors"
+	"strconv"
+	"testovoe/internal/entity"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Records of one attempt are told from records of another by CreatedAt, so Complete and Delete
+// leave alone a record that a retry has taken over.
+type IdempotencyRepositoriy interface {
+	// Create stores the record unless the user already has one with the key, false means it existed.
+	Create(entity.IdempotencyRecord) (bool, error)
+	Get(userID uuid.UUID, key string) (entity.IdempotencyRecord, error)
+	// Replace puts fresh in place of stale if stale is still stored, false means another request was first.
+	Replace(stale, fresh entity.IdempotencyRecord) (bool, error)
+	Complete(entity.IdempotencyRecord) error
+	Delete(entity.IdempotencyRecord) error
+	// Purge removes records expired before the given time.
+	Purge(time.Time) (int64, error)
+}
+
+// ErrIdempotencyKeyReused is returned when a key comes again with a different request.
+var ErrIdempotencyKeyReused = Conflict("This idempotency key was used for another request")
+
+// ErrIdempotencyInProgress is returned for a repeat that arrives before the first request is answered.
+var ErrIdempotencyInProgress = Conflict("Request with this idempotency key is still in progress")
+
+// MaxIdempotencyKeyLength keeps keys to the size of the column.
+const MaxIdempotencyKeyLength = 255
+
+// IdempotencyLease is how long a request keeps its key. A request still in progress after that is
+// taken to be lost with a crashed or killed process, and a retry may run in its place.
+const IdempotencyLease = time.Minute
+
+type IdempotencyUseCase struct {
+	repo IdempotencyRepositoriy
+	ttl  time.Duration
+}
+
+func NewIdempotencyUseCase(repo IdempotencyRepositoriy, ttl time.Duration) *IdempotencyUseCase {
+	return &IdempotencyUseCase{
+		repo: repo,
+		ttl:  ttl,
+	}
+}
+
+// Begin reserves the key for the request with the given fingerprint. When the key is already
+// completed for the same request the stored record is returned to be replayed. Expired keys and
+// keys whose lease is over are taken over.
+func (uc *IdempotencyUseCase) Begin(userID uuid.UUID, key, fingerprint string) (entity.IdempotencyRecord, bool, error) {
+	if err := validateIdempotencyKey(key); err != nil {
+		return entity.IdempotencyRecord{}, false, err
+	}
+
+	// Databases keep microseconds, the time has to survive the round trip to identify the attempt.
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	record := entity.IdempotencyRecord{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(uc.ttl),
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		created, err := uc.repo.Create(record)
+		if err != nil {
+			return entity.IdempotencyRecord{}, false, err
+		}
+		if created {
+			return record, false, nil
+		}
+
+		stored, err := uc.repo.Get(userID, key)
+		if KindOf(err) == KindNotFound {
+			continue
+		}
+		if err != nil {
+			return entity.IdempotencyRecord{}, false, err
+		}
+
+		if !now.After(stored.ExpiresAt) {
+			if stored.Fingerprint != fingerprint {
+				return entity.IdempotencyRecord{}, false, ErrIdempotencyKeyReused
+			}
+
+			if stored.Completed() {
+				return stored, true, nil
+			}
+
+			if now.Before(stored.CreatedAt.Add(IdempotencyLease)) {
+				return entity.IdempotencyRecord{}, false, ErrIdempotencyInProgress
+			}
+		}
+
+		replaced, err := uc.repo.Replace(stored, record)
+		if err != nil {
+			return entity.IdempotencyRecord{}, false, err
+		}
+		if replaced {
+			return record, false, nil
+		}
+	}
+
+	return entity.IdempotencyRecord{}, false, ErrIdempotencyInProgress
+}
+
+// Complete stores the response so that repeats get it instead of running the request again.
+func (uc *IdempotencyUseCase) Complete(record entity.IdempotencyRecord) error {
+	if !record.Completed() {
+		return errors.New("Response status is required")
+	}
+
+	return uc.repo.Complete(record)
+}
+
+// Abandon frees the key after a failure that the client should be able to retry.
+func (uc *IdempotencyUseCase) Abandon(record entity.IdempotencyRecord) error {
+	return uc.repo.Delete(record)
+}
+
+// Purge removes expired keys.
+func (uc *IdempotencyUseCase) Purge() (int64, error) {
+	return uc.repo.Purge(time.Now())
+}
+
+func validateIdempotencyKey(key string) error {
+	if key == "" || len(key) > MaxIdempotencyKeyLength {
+		return Invalid("Idempotency-Key", "length 1.."+strconv.Itoa(MaxIdempotencyKeyLength), "Idempotency key must be from 1 to 255 characters")
+	}
+
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return Invalid("Idempotency-Key", "visible_ascii", "Idempotency key must consist of visible ASCII characters")
+		}
+	}
+
+	return nil
+}
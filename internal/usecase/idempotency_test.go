@@ -0,0 +1,151 @@
+package usecase_test
+
+import (
+	"testing"
+	"testovoe/internal/entity"
+	"testovoe/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockIdempotencyRepo struct{ mock.Mock }
+
+func (m *MockIdempotencyRepo) Create(record entity.IdempotencyRecord) (bool, error) {
+	args := m.Called(record)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockIdempotencyRepo) Get(userID uuid.UUID, key string) (entity.IdempotencyRecord, error) {
+	args := m.Called(userID, key)
+	return args.Get(0).(entity.IdempotencyRecord), args.Error(1)
+}
+
+func (m *MockIdempotencyRepo) Complete(record entity.IdempotencyRecord) error {
+	args := m.Called(record)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepo) Replace(stale, fresh entity.IdempotencyRecord) (bool, error) {
+	args := m.Called(stale, fresh)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockIdempotencyRepo) Delete(record entity.IdempotencyRecord) error {
+	args := m.Called(record)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepo) Purge(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestIdempotencyUseCase_Begin(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("new key", func(t *testing.T) {
+		mockRepo := new(MockIdempotencyRepo)
+		uc := usecase.NewIdempotencyUseCase(mockRepo, time.Hour)
+		mockRepo.On("Create", mock.MatchedBy(func(r entity.IdempotencyRecord) bool {
+			return r.Key == "k1" && r.Fingerprint == "f1" && r.Status == 0 && r.ExpiresAt.After(time.Now().Add(59*time.Minute))
+		})).Return(true, nil)
+
+		_, replay, err := uc.Begin(userID, "k1", "f1")
+
+		assert.NoError(t, err)
+		assert.False(t, replay)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("completed key is replayed", func(t *testing.T) {
+		mockRepo := new(MockIdempotencyRepo)
+		uc := usecase.NewIdempotencyUseCase(mockRepo, time.Hour)
+		stored := entity.IdempotencyRecord{UserID: userID, Key: "k1", Fingerprint: "f1", Status: 201, Body: []byte("{}"), ExpiresAt: time.Now().Add(time.Hour)}
+		mockRepo.On("Create", mock.Anything).Return(false, nil)
+		mockRepo.On("Get", userID, "k1").Return(stored, nil)
+
+		record, replay, err := uc.Begin(userID, "k1", "f1")
+
+		assert.NoError(t, err)
+		assert.True(t, replay)
+		assert.Equal(t, 201, record.Status)
+	})
+
+	t.Run("key with another request", func(t *testing.T) {
+		mockRepo := new(MockIdempotencyRepo)
+		uc := usecase.NewIdempotencyUseCase(mockRepo, time.Hour)
+		mockRepo.On("Create", mock.Anything).Return(false, nil)
+		mockRepo.On("Get", userID, "k1").Return(entity.IdempotencyRecord{Fingerprint: "f1", Status: 201, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+
+		_, _, err := uc.Begin(userID, "k1", "f2")
+
+		assert.ErrorIs(t, err, usecase.ErrIdempotencyKeyReused)
+	})
+
+	t.Run("key still in progress", func(t *testing.T) {
+		mockRepo := new(MockIdempotencyRepo)
+		uc := usecase.NewIdempotencyUseCase(mockRepo, time.Hour)
+		mockRepo.On("Create", mock.Anything).Return(false, nil)
+		mockRepo.On("Get", userID, "k1").Return(entity.IdempotencyRecord{Fingerprint: "f1", CreatedAt: time.Now().Add(-time.Second), ExpiresAt: time.Now().Add(time.Hour)}, nil)
+
+		_, _, err := uc.Begin(userID, "k1", "f1")
+
+		assert.ErrorIs(t, err, usecase.ErrIdempotencyInProgress)
+	})
+
+	t.Run("lost request is taken over after its lease", func(t *testing.T) {
+		mockRepo := new(MockIdempotencyRepo)
+		uc := usecase.NewIdempotencyUseCase(mockRepo, time.Hour)
+		lost := entity.IdempotencyRecord{Fingerprint: "f1", CreatedAt: time.Now().Add(-usecase.IdempotencyLease - time.Second), ExpiresAt: time.Now().Add(time.Hour)}
+		mockRepo.On("Create", mock.Anything).Return(false, nil)
+		mockRepo.On("Get", userID, "k1").Return(lost, nil)
+		mockRepo.On("Replace", lost, mock.MatchedBy(func(r entity.IdempotencyRecord) bool {
+			return r.Fingerprint == "f1" && time.Since(r.CreatedAt) < time.Second
+		})).Return(true, nil)
+
+		_, replay, err := uc.Begin(userID, "k1", "f1")
+
+		assert.NoError(t, err)
+		assert.False(t, replay)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("another retry takes over first", func(t *testing.T) {
+		mockRepo := new(MockIdempotencyRepo)
+		uc := usecase.NewIdempotencyUseCase(mockRepo, time.Hour)
+		lost := entity.IdempotencyRecord{Fingerprint: "f1", CreatedAt: time.Now().Add(-time.Hour), ExpiresAt: time.Now().Add(time.Hour)}
+		mockRepo.On("Create", mock.Anything).Return(false, nil)
+		mockRepo.On("Get", userID, "k1").Return(lost, nil).Once()
+		mockRepo.On("Replace", lost, mock.Anything).Return(false, nil)
+		mockRepo.On("Get", userID, "k1").Return(entity.IdempotencyRecord{Fingerprint: "f1", CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}, nil)
+
+		_, _, err := uc.Begin(userID, "k1", "f1")
+
+		assert.ErrorIs(t, err, usecase.ErrIdempotencyInProgress)
+	})
+
+	t.Run("expired key is taken over", func(t *testing.T) {
+		mockRepo := new(MockIdempotencyRepo)
+		uc := usecase.NewIdempotencyUseCase(mockRepo, time.Hour)
+		mockRepo.On("Create", mock.Anything).Return(false, nil)
+		mockRepo.On("Get", userID, "k1").Return(entity.IdempotencyRecord{Fingerprint: "old", Status: 201, ExpiresAt: time.Now().Add(-time.Minute)}, nil)
+		mockRepo.On("Replace", mock.Anything, mock.MatchedBy(func(r entity.IdempotencyRecord) bool { return r.Fingerprint == "f1" })).Return(true, nil)
+
+		_, replay, err := uc.Begin(userID, "k1", "f1")
+
+		assert.NoError(t, err)
+		assert.False(t, replay)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		uc := usecase.NewIdempotencyUseCase(new(MockIdempotencyRepo), time.Hour)
+
+		_, _, err := uc.Begin(userID, "has space", "f1")
+
+		assert.Error(t, err)
+	})
+}
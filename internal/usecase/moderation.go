@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"testovoe/internal/entity"
+	"time"
+)
+
+// ContentPolicy looks at content before it is published. It may allow it, reject it or hold it for a moderator.
+type ContentPolicy interface {
+	Check(entity.Content) (entity.PolicyDecision, error)
+}
+
+type HeldContentRepositoriy interface {
+	Save(entity.HeldContent) (entity.HeldContent, error)
+	GetByID(int) (entity.HeldContent, error)
+	List(entity.PageRequest) ([]entity.HeldContent, error)
+	Delete(int) error
+	// Claim takes the content out of the queue and returns it, NotFound when somebody has already taken it.
+	Claim(int) (entity.HeldContent, error)
+}
+
+// HeldError is returned instead of the published post when a policy holds the content for a moderator.
+// It is not a failure, the content is stored and waits in the queue.
+type HeldError struct {
+	Content entity.HeldContent
+}
+
+func (e *HeldError) Error() string {
+	return "Content is held for moderation"
+}
+
+// ContentModeration runs content policies in order, the first rejection wins and otherwise
+// the first hold sends the content to the moderation queue.
+type ContentModeration struct {
+	held     HeldContentRepositoriy
+	policies []ContentPolicy
+}
+
+func NewContentModeration(held HeldContentRepositoriy, policies ...ContentPolicy) *ContentModeration {
+	return &ContentModeration{
+		held:     held,
+		policies: policies,
+	}
+}
+
+// screen returns nil when the content may be published. Rejections are validation errors of the text,
+// held content is stored and reported with a HeldError. Nil moderation allows everything.
+func (m *ContentModeration) screen(held entity.HeldContent) error {
+	if m == nil {
+		return nil
+	}
+
+	var hold *entity.PolicyDecision
+	for _, policy := range m.policies {
+		decision, err := policy.Check(held.Content)
+		if err != nil {
+			return err
+		}
+
+		switch decision.Action {
+		case entity.PolicyReject:
+			return Invalid("text", "policy "+decision.Policy, decision.Reason)
+		case entity.PolicyHold:
+			if hold == nil {
+				hold = &decision
+			}
+		}
+	}
+
+	if hold == nil {
+		return nil
+	}
+
+	held.Policy = hold.Policy
+	held.Reason = hold.Reason
+	held.CreatedAt = time.Now()
+	saved, err := m.held.Save(held)
+	if err != nil {
+		return err
+	}
+	return &HeldError{Content: saved}
+}
+
+// ModerationUseCase is the queue of held content, moderators publish it or throw it away.
+type ModerationUseCase struct {
+	held      HeldContentRepositoriy
+	questions *QuestionUseCase
+	answers   *AnswerUseCase
+}
+
+func NewModerationUseCase(held HeldContentRepositoriy, questions *QuestionUseCase, answers *AnswerUseCase) *ModerationUseCase {
+	return &ModerationUseCase{
+		held:      held,
+		questions: questions,
+		answers:   answers,
+	}
+}
+
+// ListHeld shows the queue, oldest content first.
+func (uc *ModerationUseCase) ListHeld(actor entity.User, page entity.PageRequest) (entity.Page[entity.HeldContent], error) {
+	if err := canModerate(actor); err != nil {
+		return entity.Page[entity.HeldContent]{}, err
+	}
+
+	return fetchPage(page, uc.held.List, func(h entity.HeldContent) entity.Cursor {
+		return entity.Cursor{Time: h.CreatedAt, ID: h.ID}
+	})
+}
+
+// Approve publishes held content without running the policies again. An edit is applied only if
+// the post has not changed since it was made. The content is claimed before it is published,
+// so moderators approving it at the same time publish it once.
+func (uc *ModerationUseCase) Approve(actor entity.User, id int) (entity.HeldContent, error) {
+	if err := canModerate(actor); err != nil {
+		return entity.HeldContent{}, err
+	}
+
+	held, err := uc.held.Claim(id)
+	if err != nil {
+		return entity.HeldContent{}, err
+	}
+
+	postID, err := uc.publish(held)
+	if err != nil {
+		// Nothing is published, the content goes back to the queue to be rejected or tried again.
+		if _, restoreErr := uc.held.Save(held); restoreErr != nil {
+			return entity.HeldContent{}, restoreErr
+		}
+		return entity.HeldContent{}, err
+	}
+
+	held.PostID = &postID
+	return held, nil
+}
+
+// Reject throws held content away.
+func (uc *ModerationUseCase) Reject(actor entity.User, id int) error {
+	if err := canModerate(actor); err != nil {
+		return err
+	}
+
+	if _, err := uc.held.GetByID(id); err != nil {
+		return err
+	}
+
+	return uc.held.Delete(id)
+}
+
+func (uc *ModerationUseCase) publish(held entity.HeldContent) (int, error) {
+	switch {
+	case held.Kind == entity.ContentQuestion && held.Edit:
+		question, err := uc.questions.rewrite(*held.PostID, *held.Version, held.Text)
+		return question.ID, err
+	case held.Kind == entity.ContentQuestion:
+		question, err := uc.questions.publish(held.UserID, held.Text, held.Tags)
+		return question.ID, err
+	case held.Kind == entity.ContentAnswer && held.Edit:
+		answer, err := uc.answers.rewrite(*held.PostID, *held.Version, held.Text)
+		return answer.ID, err
+	default:
+		answer, err := uc.answers.publish(*held.QuestionID, held.UserID, held.Text)
+		return answer.ID, err
+	}
+}
@@ -0,0 +1,254 @@
+package usecase_test
+
+import (
+	"errors"
+	"testing"
+	"testovoe/internal/entity"
+	"testovoe/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockHeldContentRepo struct{ mock.Mock }
+
+func (m *MockHeldContentRepo) Save(held entity.HeldContent) (entity.HeldContent, error) {
+	args := m.Called(held)
+	return args.Get(0).(entity.HeldContent), args.Error(1)
+}
+
+func (m *MockHeldContentRepo) GetByID(id int) (entity.HeldContent, error) {
+	args := m.Called(id)
+	return args.Get(0).(entity.HeldContent), args.Error(1)
+}
+
+func (m *MockHeldContentRepo) List(page entity.PageRequest) ([]entity.HeldContent, error) {
+	args := m.Called(page)
+	return args.Get(0).([]entity.HeldContent), args.Error(1)
+}
+
+func (m *MockHeldContentRepo) Delete(id int) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockHeldContentRepo) Claim(id int) (entity.HeldContent, error) {
+	args := m.Called(id)
+	return args.Get(0).(entity.HeldContent), args.Error(1)
+}
+
+type MockRecentContentRepo struct{ mock.Mock }
+
+func (m *MockRecentContentRepo) HasRecent(kind string, userID uuid.UUID, text string, since time.Time) (bool, error) {
+	args := m.Called(kind, userID, text, since)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestBannedWordsPolicy(t *testing.T) {
+	policy := usecase.NewBannedWordsPolicy([]string{"scam", " Casino "})
+
+	tests := []struct {
+		text   string
+		action string
+	}{
+		{"Is this a scam?", entity.PolicyReject},
+		{"Is this a SCAM?", entity.PolicyReject},
+		{"Is this a $c4m?", entity.PolicyReject},
+		{"Is this a sccaaam?", entity.PolicyReject},
+		{"Is this a s c a m?", entity.PolicyReject},
+		{"Is this a s.c.a.m?", entity.PolicyReject},
+		{"Best c@s1n0 in town", entity.PolicyReject},
+		{"Why do people scamper?", entity.PolicyAllow},
+		{"It costs $100, is it fine?", entity.PolicyAllow},
+		{"Valid question", entity.PolicyAllow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			decision, err := policy.Check(entity.Content{Kind: entity.ContentQuestion, Text: tt.text})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.action, decision.Action)
+		})
+	}
+
+	t.Run("tags", func(t *testing.T) {
+		decision, _ := policy.Check(entity.Content{Kind: entity.ContentQuestion, Text: "Valid question", Tags: []string{"go", "casino"}})
+		assert.Equal(t, entity.PolicyReject, decision.Action)
+		assert.Equal(t, "banned_words", decision.Policy)
+	})
+
+	t.Run("doubled letters", func(t *testing.T) {
+		policy := usecase.NewBannedWordsPolicy([]string{"butt", "ass"})
+
+		for text, action := range map[string]string{
+			"Fine, but why?":          entity.PolicyAllow,
+			"Same as before":          entity.PolicyAllow,
+			"a s is not a word":       entity.PolicyAllow,
+			"Kick his butt":           entity.PolicyReject,
+			"Kick his buuuttt":        entity.PolicyReject,
+			"What an a$$":             entity.PolicyReject,
+			"What an a s s":           entity.PolicyReject,
+			"Kick his b.u.t.t please": entity.PolicyReject,
+		} {
+			decision, err := policy.Check(entity.Content{Kind: entity.ContentQuestion, Text: text})
+			assert.NoError(t, err)
+			assert.Equal(t, action, decision.Action, text)
+		}
+	})
+}
+
+func TestLinkLimitPolicy(t *testing.T) {
+	policy := usecase.NewLinkLimitPolicy(2)
+
+	decision, _ := policy.Check(entity.Content{Text: "See https://go.dev and www.example.com"})
+	assert.Equal(t, entity.PolicyAllow, decision.Action)
+
+	decision, _ = policy.Check(entity.Content{Text: "See https://a.io, HTTP://b.io and www.c.io"})
+	assert.Equal(t, entity.PolicyHold, decision.Action)
+	assert.Equal(t, "links", decision.Policy)
+}
+
+func TestRepeatedCharactersPolicy(t *testing.T) {
+	policy := usecase.NewRepeatedCharactersPolicy(5)
+
+	tests := []struct {
+		text   string
+		action string
+	}{
+		{"Why??????", entity.PolicyReject},
+		{"Heeeeeeelp", entity.PolicyReject},
+		{"Why?!", entity.PolicyAllow},
+		{"Title\n----------\nbody", entity.PolicyAllow},
+		{"Indented          text", entity.PolicyAllow},
+		{"Wait..........", entity.PolicyAllow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			decision, _ := policy.Check(entity.Content{Text: tt.text})
+			assert.Equal(t, tt.action, decision.Action)
+		})
+	}
+}
+
+func TestDuplicatePolicy(t *testing.T) {
+	recent := new(MockRecentContentRepo)
+	policy := usecase.NewDuplicatePolicy(recent, 10*time.Minute)
+	userID := uuid.New()
+
+	recent.On("HasRecent", entity.ContentAnswer, userID, "Same answer", mock.MatchedBy(func(since time.Time) bool {
+		return time.Since(since) > 9*time.Minute && time.Since(since) < 11*time.Minute
+	})).Return(true, nil)
+	recent.On("HasRecent", entity.ContentAnswer, userID, "New answer", mock.Anything).Return(false, nil)
+
+	decision, err := policy.Check(entity.Content{Kind: entity.ContentAnswer, UserID: userID, Text: "Same answer"})
+	assert.NoError(t, err)
+	assert.Equal(t, entity.PolicyReject, decision.Action)
+	assert.Equal(t, "duplicate", decision.Policy)
+
+	decision, _ = policy.Check(entity.Content{Kind: entity.ContentAnswer, UserID: userID, Text: "New answer"})
+	assert.Equal(t, entity.PolicyAllow, decision.Action)
+
+	decision, _ = policy.Check(entity.Content{Kind: entity.ContentAnswer, UserID: userID, Text: "Same answer", Edit: true})
+	assert.Equal(t, entity.PolicyAllow, decision.Action)
+}
+
+func TestContentModeration(t *testing.T) {
+	mockRepo := new(MockQuestionRepo)
+	mockUserRepo := new(MockUserRepo)
+	heldRepo := new(MockHeldContentRepo)
+	moderation := usecase.NewContentModeration(heldRepo, usecase.NewLinkLimitPolicy(0), usecase.NewBannedWordsPolicy([]string{"scam"}))
+	uc := usecase.NewQuestionUseCase(mockRepo, new(MockAnswerRepo), new(MockCommentRepo), mockUserRepo, usecase.DefaultTextLimits(), moderation)
+	userID := uuid.New()
+	mockUserRepo.On("GetByID", userID).Return(entity.User{ID: userID, DisplayName: "Alice"}, nil)
+
+	t.Run("reject wins over hold", func(t *testing.T) {
+		_, err := uc.Save(entity.QuestionDto{UserID: userID, Text: "Scam at https://example.com"})
+
+		assert.Equal(t, usecase.KindValidation, usecase.KindOf(err))
+		assert.Equal(t, []usecase.FieldError{{Field: "text", Rule: "policy banned_words"}}, usecase.FieldsOf(err))
+		heldRepo.AssertNotCalled(t, "Save", mock.Anything)
+	})
+
+	t.Run("hold", func(t *testing.T) {
+		heldRepo.On("Save", mock.MatchedBy(func(h entity.HeldContent) bool {
+			return h.Kind == entity.ContentQuestion && h.UserID == userID && h.Policy == "links" && !h.CreatedAt.IsZero()
+		})).Return(entity.HeldContent{ID: 7, Policy: "links"}, nil).Once()
+
+		_, err := uc.Save(entity.QuestionDto{UserID: userID, Text: "Read https://example.com"})
+
+		var held *usecase.HeldError
+		assert.True(t, errors.As(err, &held))
+		assert.Equal(t, 7, held.Content.ID)
+		mockRepo.AssertNotCalled(t, "Save", mock.Anything)
+	})
+
+	t.Run("moderator edits are not screened", func(t *testing.T) {
+		question := entity.Question{ID: 1, UserID: userID, Text: "Valid question", Version: 1}
+		mockRepo.On("GetByID", 1).Return(question, nil)
+		mockRepo.On("Update", mock.Anything).Return(question, nil).Once()
+		moderator := entity.User{ID: uuid.New(), Role: entity.RoleModerator}
+		text := "Read https://example.com"
+
+		_, err := uc.Update(moderator, 1, entity.QuestionUpdateDto{Text: &text})
+
+		assert.NoError(t, err)
+	})
+}
+
+func TestModerationUseCase_Approve(t *testing.T) {
+	mockRepo := new(MockQuestionRepo)
+	mockAnswerRepo := new(MockAnswerRepo)
+	mockUserRepo := new(MockUserRepo)
+	heldRepo := new(MockHeldContentRepo)
+	questions := usecase.NewQuestionUseCase(mockRepo, mockAnswerRepo, new(MockCommentRepo), mockUserRepo, usecase.DefaultTextLimits(), nil)
+	answers := usecase.NewAnswerUseCase(mockAnswerRepo, mockRepo, mockUserRepo, usecase.DefaultTextLimits(), nil)
+	uc := usecase.NewModerationUseCase(heldRepo, questions, answers)
+	userID := uuid.New()
+	moderator := entity.User{ID: uuid.New(), Role: entity.RoleModerator}
+	mockUserRepo.On("GetByID", userID).Return(entity.User{ID: userID, DisplayName: "Alice"}, nil)
+
+	t.Run("users may not approve", func(t *testing.T) {
+		_, err := uc.Approve(entity.User{ID: userID, Role: entity.RoleUser}, 1)
+		assert.ErrorIs(t, err, usecase.ErrForbidden)
+	})
+
+	t.Run("new question", func(t *testing.T) {
+		content := entity.Content{Kind: entity.ContentQuestion, UserID: userID, Text: "Read https://example.com", Tags: []string{"go"}}
+		heldRepo.On("Claim", 1).Return(entity.HeldContent{ID: 1, Content: content}, nil).Once()
+		mockRepo.On("Save", mock.MatchedBy(func(q entity.Question) bool {
+			return q.Text == content.Text && q.UserID == userID && len(q.Tags) == 1
+		})).Return(entity.Question{ID: 5}, nil).Once()
+
+		held, err := uc.Approve(moderator, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 5, *held.PostID)
+	})
+
+	t.Run("already approved", func(t *testing.T) {
+		heldRepo.On("Claim", 1).Return(entity.HeldContent{}, usecase.NotFound("Held content not found")).Once()
+
+		_, err := uc.Approve(moderator, 1)
+
+		assert.Equal(t, usecase.KindNotFound, usecase.KindOf(err))
+		mockRepo.AssertNumberOfCalls(t, "Save", 1)
+	})
+
+	t.Run("edit of a changed answer", func(t *testing.T) {
+		postID, version := 3, 1
+		content := entity.Content{Kind: entity.ContentAnswer, UserID: userID, Text: "Read https://example.com", Edit: true}
+		held := entity.HeldContent{ID: 2, Content: content, PostID: &postID, Version: &version}
+		heldRepo.On("Claim", 2).Return(held, nil).Once()
+		heldRepo.On("Save", held).Return(held, nil).Once()
+		mockAnswerRepo.On("GetByID", 3).Return(entity.Answer{ID: 3, UserID: userID, Text: "Old answer", Version: 2}, nil)
+
+		_, err := uc.Approve(moderator, 2)
+
+		assert.Equal(t, usecase.KindPrecondition, usecase.KindOf(err))
+		heldRepo.AssertCalled(t, "Save", held)
+	})
+}
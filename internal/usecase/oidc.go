@@ -0,0 +1,181 @@
+package usecase
+
+import (
+	"strings"
+	"testovoe/internal/entity"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// IdentityVerifier checks a token of an external identity provider.
+type IdentityVerifier interface {
+	VerifyIdentity(token string) (entity.ExternalIdentity, error)
+}
+
+type IdentityRepositoriy interface {
+	GetUserID(issuer, subject string) (uuid.UUID, error)
+	Save(entity.UserIdentity) error
+	// Register creates the user and its first identity at once.
+	Register(entity.User, entity.UserIdentity) error
+	// HasIssuer tells whether any subject of the issuer is linked to the user.
+	HasIssuer(userID uuid.UUID, issuer string) (bool, error)
+}
+
+var errEmailTaken = Unauthenticated("This email is already registered by another account")
+
+// OIDCUseCase accepts tokens of the company SSO and maps their subjects to users.
+type OIDCUseCase struct {
+	verifier   IdentityVerifier
+	identities IdentityRepositoriy
+	userRepo   UserRepositoriy
+}
+
+func NewOIDCUseCase(verifier IdentityVerifier, identities IdentityRepositoriy, users UserRepositoriy) *OIDCUseCase {
+	return &OIDCUseCase{
+		verifier:   verifier,
+		identities: identities,
+		userRepo:   users,
+	}
+}
+
+// Authenticate resolves an SSO token to a user, creating the user the first time the subject is seen.
+func (uc *OIDCUseCase) Authenticate(token string) (entity.User, error) {
+	identity, err := uc.verifier.VerifyIdentity(token)
+	if err != nil {
+		return entity.User{}, ErrUnauthenticated
+	}
+
+	user, err := uc.linked(identity)
+	if KindOf(err) == KindNotFound {
+		return uc.firstSight(identity)
+	}
+	return user, err
+}
+
+// firstSight links the identity to a user of the same provider with the same verified email
+// or registers a new user.
+func (uc *OIDCUseCase) firstSight(identity entity.ExternalIdentity) (entity.User, error) {
+	email, err := normalizeEmail(identity.Email)
+	if err != nil {
+		return entity.User{}, Unauthenticated("Token has no valid email")
+	}
+
+	user, err := uc.userRepo.GetByEmail(email)
+	if KindOf(err) == KindNotFound {
+		return uc.register(identity, email)
+	}
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	return uc.adopt(identity, user)
+}
+
+// register creates a user for the identity. Two first requests of a new subject race here,
+// the user and the identity of the winner are saved together, so the loser finds them by the identity.
+func (uc *OIDCUseCase) register(identity entity.ExternalIdentity, email string) (entity.User, error) {
+	now := time.Now()
+	user := entity.User{
+		ID:          uuid.New(),
+		DisplayName: displayNameOf(identity.Name, email),
+		Email:       email,
+		Role:        entity.RoleUser,
+		CreatedAt:   now,
+	}
+
+	err := uc.identities.Register(user, entity.UserIdentity{
+		Issuer:    identity.Issuer,
+		Subject:   identity.Subject,
+		UserID:    user.ID,
+		CreatedAt: now,
+	})
+	if KindOf(err) == KindConflict {
+		winner, err := uc.linked(identity)
+		if KindOf(err) != KindNotFound {
+			return winner, err
+		}
+
+		// another account took the email meanwhile
+		existing, err := uc.userRepo.GetByEmail(email)
+		if err != nil {
+			return entity.User{}, err
+		}
+		return uc.adopt(identity, existing)
+	}
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	return user, nil
+}
+
+// adopt links a new subject to an existing user with the same email. Local users choose their email
+// without proving it, so linking to them would let anybody register the email of a colleague first
+// and share the account with them later. Only users without a password that already sign in through
+// the same provider are linked.
+func (uc *OIDCUseCase) adopt(identity entity.ExternalIdentity, user entity.User) (entity.User, error) {
+	if !identity.EmailVerified || user.PasswordHash != "" {
+		return entity.User{}, errEmailTaken
+	}
+
+	known, err := uc.identities.HasIssuer(user.ID, identity.Issuer)
+	if err != nil {
+		return entity.User{}, err
+	}
+	if !known {
+		return entity.User{}, errEmailTaken
+	}
+
+	return uc.link(identity, user)
+}
+
+// link saves the identity of the user. If a concurrent request linked the subject first, its user wins.
+func (uc *OIDCUseCase) link(identity entity.ExternalIdentity, user entity.User) (entity.User, error) {
+	err := uc.identities.Save(entity.UserIdentity{
+		Issuer:    identity.Issuer,
+		Subject:   identity.Subject,
+		UserID:    user.ID,
+		CreatedAt: time.Now(),
+	})
+	if KindOf(err) == KindConflict {
+		return uc.linked(identity)
+	}
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	return user, nil
+}
+
+// linked is the user the identity is already linked to.
+func (uc *OIDCUseCase) linked(identity entity.ExternalIdentity) (entity.User, error) {
+	userID, err := uc.identities.GetUserID(identity.Issuer, identity.Subject)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	user, err := uc.userRepo.GetByID(userID)
+	if err != nil {
+		return entity.User{}, ErrUnauthenticated
+	}
+
+	return user, nil
+}
+
+// displayNameOf takes the name from the claims and falls back to the local part of the email.
+func displayNameOf(name, email string) string {
+	if normalized, err := normalizeDisplayName(name); err == nil {
+		return normalized
+	}
+
+	local, _, _ := strings.Cut(email, "@")
+	for utf8.RuneCountInString(local) < 2 {
+		local += "_"
+	}
+	if utf8.RuneCountInString(local) > 50 {
+		local = string([]rune(local)[:50])
+	}
+	return local
+}
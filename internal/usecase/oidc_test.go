@@ -0,0 +1,170 @@
+package usecase_test
+
+import (
+	"errors"
+	"testing"
+	"testovoe/internal/entity"
+	"testovoe/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockIdentityVerifier struct{ mock.Mock }
+
+func (m *MockIdentityVerifier) VerifyIdentity(token string) (entity.ExternalIdentity, error) {
+	args := m.Called(token)
+	return args.Get(0).(entity.ExternalIdentity), args.Error(1)
+}
+
+type MockIdentityRepo struct{ mock.Mock }
+
+func (m *MockIdentityRepo) GetUserID(issuer, subject string) (uuid.UUID, error) {
+	args := m.Called(issuer, subject)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockIdentityRepo) Save(identity entity.UserIdentity) error {
+	args := m.Called(identity)
+	return args.Error(0)
+}
+
+func (m *MockIdentityRepo) Register(user entity.User, identity entity.UserIdentity) error {
+	args := m.Called(user, identity)
+	return args.Error(0)
+}
+
+func (m *MockIdentityRepo) HasIssuer(userID uuid.UUID, issuer string) (bool, error) {
+	args := m.Called(userID, issuer)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestOIDCUseCase_Authenticate(t *testing.T) {
+	const issuer = "https://sso.example.com"
+
+	t.Run("known subject", func(t *testing.T) {
+		verifier, identities, users := new(MockIdentityVerifier), new(MockIdentityRepo), new(MockUserRepo)
+		uc := usecase.NewOIDCUseCase(verifier, identities, users)
+		user := entity.User{ID: uuid.New(), DisplayName: "Alice"}
+		verifier.On("VerifyIdentity", "token").Return(entity.ExternalIdentity{Issuer: issuer, Subject: "emp-1"}, nil)
+		identities.On("GetUserID", issuer, "emp-1").Return(user.ID, nil)
+		users.On("GetByID", user.ID).Return(user, nil)
+
+		result, err := uc.Authenticate("token")
+
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, result.ID)
+		identities.AssertNotCalled(t, "Save", mock.Anything)
+	})
+
+	t.Run("first sight creates user", func(t *testing.T) {
+		verifier, identities, users := new(MockIdentityVerifier), new(MockIdentityRepo), new(MockUserRepo)
+		uc := usecase.NewOIDCUseCase(verifier, identities, users)
+		verifier.On("VerifyIdentity", "token").Return(entity.ExternalIdentity{
+			Issuer: issuer, Subject: "emp-2", Email: " Carol@Corp.Example ",
+		}, nil)
+		identities.On("GetUserID", issuer, "emp-2").Return(uuid.Nil, usecase.NotFound("not found"))
+		users.On("GetByEmail", "carol@corp.example").Return(entity.User{}, usecase.NotFound("not found"))
+		identities.On("Register", mock.MatchedBy(func(u entity.User) bool {
+			return u.DisplayName == "carol" && u.Email == "carol@corp.example" && u.Role == entity.RoleUser
+		}), mock.MatchedBy(func(i entity.UserIdentity) bool {
+			return i.Issuer == issuer && i.Subject == "emp-2" && i.UserID != uuid.Nil
+		})).Return(nil)
+
+		result, err := uc.Authenticate("token")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "carol@corp.example", result.Email)
+		identities.AssertExpectations(t)
+	})
+
+	t.Run("unverified email of existing user", func(t *testing.T) {
+		verifier, identities, users := new(MockIdentityVerifier), new(MockIdentityRepo), new(MockUserRepo)
+		uc := usecase.NewOIDCUseCase(verifier, identities, users)
+		verifier.On("VerifyIdentity", "token").Return(entity.ExternalIdentity{
+			Issuer: issuer, Subject: "emp-3", Email: "bob@example.com",
+		}, nil)
+		identities.On("GetUserID", issuer, "emp-3").Return(uuid.Nil, usecase.NotFound("not found"))
+		users.On("GetByEmail", "bob@example.com").Return(entity.User{ID: uuid.New()}, nil)
+
+		_, err := uc.Authenticate("token")
+
+		assert.Equal(t, usecase.KindUnauthenticated, usecase.KindOf(err))
+		identities.AssertNotCalled(t, "Save", mock.Anything)
+	})
+
+	t.Run("verified email of a local user", func(t *testing.T) {
+		verifier, identities, users := new(MockIdentityVerifier), new(MockIdentityRepo), new(MockUserRepo)
+		uc := usecase.NewOIDCUseCase(verifier, identities, users)
+		local := entity.User{ID: uuid.New(), Email: "victim@corp.example"}
+		verifier.On("VerifyIdentity", "token").Return(entity.ExternalIdentity{
+			Issuer: issuer, Subject: "emp-4", Email: "victim@corp.example", EmailVerified: true,
+		}, nil)
+		identities.On("GetUserID", issuer, "emp-4").Return(uuid.Nil, usecase.NotFound("not found"))
+		users.On("GetByEmail", "victim@corp.example").Return(local, nil)
+		identities.On("HasIssuer", local.ID, issuer).Return(false, nil)
+
+		_, err := uc.Authenticate("token")
+		assert.Equal(t, usecase.KindUnauthenticated, usecase.KindOf(err))
+
+		local.PasswordHash = "$2a$10$hash"
+		users.ExpectedCalls = nil
+		users.On("GetByEmail", "victim@corp.example").Return(local, nil)
+
+		_, err = uc.Authenticate("token")
+		assert.Equal(t, usecase.KindUnauthenticated, usecase.KindOf(err))
+		identities.AssertNotCalled(t, "Save", mock.Anything)
+	})
+
+	t.Run("another subject of the same provider", func(t *testing.T) {
+		verifier, identities, users := new(MockIdentityVerifier), new(MockIdentityRepo), new(MockUserRepo)
+		uc := usecase.NewOIDCUseCase(verifier, identities, users)
+		existing := entity.User{ID: uuid.New(), Email: "dave@corp.example"}
+		verifier.On("VerifyIdentity", "token").Return(entity.ExternalIdentity{
+			Issuer: issuer, Subject: "emp-5", Email: "dave@corp.example", EmailVerified: true,
+		}, nil)
+		identities.On("GetUserID", issuer, "emp-5").Return(uuid.Nil, usecase.NotFound("not found"))
+		users.On("GetByEmail", "dave@corp.example").Return(existing, nil)
+		identities.On("HasIssuer", existing.ID, issuer).Return(true, nil)
+		identities.On("Save", mock.MatchedBy(func(i entity.UserIdentity) bool {
+			return i.Subject == "emp-5" && i.UserID == existing.ID
+		})).Return(nil)
+
+		result, err := uc.Authenticate("token")
+
+		assert.NoError(t, err)
+		assert.Equal(t, existing.ID, result.ID)
+		identities.AssertExpectations(t)
+	})
+
+	t.Run("concurrent first sight of the same subject", func(t *testing.T) {
+		verifier, identities, users := new(MockIdentityVerifier), new(MockIdentityRepo), new(MockUserRepo)
+		uc := usecase.NewOIDCUseCase(verifier, identities, users)
+		winner := entity.User{ID: uuid.New(), Email: "erin@corp.example"}
+		verifier.On("VerifyIdentity", "token").Return(entity.ExternalIdentity{
+			Issuer: issuer, Subject: "emp-6", Email: "erin@corp.example",
+		}, nil)
+		identities.On("GetUserID", issuer, "emp-6").Return(uuid.Nil, usecase.NotFound("not found")).Once()
+		users.On("GetByEmail", "erin@corp.example").Return(entity.User{}, usecase.NotFound("not found"))
+		identities.On("Register", mock.Anything, mock.Anything).Return(usecase.Conflict("This user already exists"))
+		identities.On("GetUserID", issuer, "emp-6").Return(winner.ID, nil)
+		users.On("GetByID", winner.ID).Return(winner, nil)
+
+		result, err := uc.Authenticate("token")
+
+		assert.NoError(t, err)
+		assert.Equal(t, winner.ID, result.ID)
+		identities.AssertNotCalled(t, "Save", mock.Anything)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		verifier := new(MockIdentityVerifier)
+		uc := usecase.NewOIDCUseCase(verifier, new(MockIdentityRepo), new(MockUserRepo))
+		verifier.On("VerifyIdentity", "forged").Return(entity.ExternalIdentity{}, errors.New("bad signature"))
+
+		_, err := uc.Authenticate("forged")
+
+		assert.ErrorIs(t, err, usecase.ErrUnauthenticated)
+	})
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"testovoe/internal/entity"
+	"time"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// ErrCursorSort is a cursor passed back with another sort than the one of the page it came from.
+var ErrCursorSort error = &Error{
+	Kind:    KindMalformed,
+	Message: "Cursor was made for another sort",
+	Fields:  []FieldError{{Field: "cursor", Rule: "sort"}},
+}
+
+// EncodeCursor makes an opaque cursor string that clients pass back as-is.
+func EncodeCursor(c entity.Cursor) string {
+	raw := c.Sort + ":" + strconv.FormatInt(c.Time.UnixNano(), 10) + ":" + strconv.Itoa(c.Count) + ":" + strconv.Itoa(c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(s string) (*entity.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, Invalid("cursor", "format", "Cursor is invalid")
+	}
+
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 4 {
+		return nil, Invalid("cursor", "format", "Cursor is invalid")
+	}
+
+	nanos, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, Invalid("cursor", "format", "Cursor is invalid")
+	}
+
+	count, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return nil, Invalid("cursor", "format", "Cursor is invalid")
+	}
+
+	id, err := strconv.Atoi(parts[3])
+	if err != nil {
+		return nil, Invalid("cursor", "format", "Cursor is invalid")
+	}
+
+	return &entity.Cursor{Sort: parts[0], Time: time.Unix(0, nanos), Count: count, ID: id}, nil
+}
+
+// NewPageRequest validates client input; zero limit means default and too big limit is cut to MaxPageLimit.
+func NewPageRequest(cursor string, limit int) (entity.PageRequest, error) {
+	if limit < 0 {
+		return entity.PageRequest{}, Invalid("limit", "min 0", "Limit must be positive")
+	}
+
+	if limit == 0 {
+		limit = DefaultPageLimit
+	}
+
+	if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+
+	page := entity.PageRequest{Limit: limit}
+
+	if cursor != "" {
+		after, err := DecodeCursor(cursor)
+		if err != nil {
+			return entity.PageRequest{}, err
+		}
+		page.After = after
+	}
+
+	return page, nil
+}
+
+// fetchPage asks the repository for one extra row to know if there is a next page.
+func fetchPage[T any](page entity.PageRequest, list func(entity.PageRequest) ([]T, error), cursorOf func(T) entity.Cursor) (entity.Page[T], error) {
+	return fetchSortedPage(page, "", list, cursorOf)
+}
+
+// fetchSortedPage is fetchPage of a list that can be sorted in several ways. The cursor remembers the sort,
+// because its key means nothing in another order and would silently skip or repeat rows.
+func fetchSortedPage[T any](page entity.PageRequest, sort string, list func(entity.PageRequest) ([]T, error), cursorOf func(T) entity.Cursor) (entity.Page[T], error) {
+	if page.After != nil && page.After.Sort != sort {
+		return entity.Page[T]{}, ErrCursorSort
+	}
+
+	items, err := list(entity.PageRequest{After: page.After, Limit: page.Limit + 1})
+	if err != nil {
+		return entity.Page[T]{}, err
+	}
+
+	result := entity.Page[T]{Items: items}
+	if len(items) > page.Limit {
+		result.Items = items[:page.Limit]
+		cursor := cursorOf(result.Items[page.Limit-1])
+		cursor.Sort = sort
+		result.NextCursor = EncodeCursor(cursor)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,216 @@
+package usecase
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testovoe/internal/entity"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// RecentContentRepositoriy finds content a user has already posted.
+type RecentContentRepositoriy interface {
+	// HasRecent tells whether the user posted or sent to moderation the same text of the kind since the time.
+	HasRecent(kind string, userID uuid.UUID, text string, since time.Time) (bool, error)
+}
+
+func allow() entity.PolicyDecision {
+	return entity.PolicyDecision{Action: entity.PolicyAllow}
+}
+
+// leet are the look-alikes people write instead of letters to get a word past a filter.
+var leet = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "8", "b",
+	"@", "a", "$", "s", "!", "i", "|", "l", "+", "t",
+)
+
+type bannedWordsPolicy struct {
+	// words are grouped by their squeezed form, a token is compared only with the words it may stretch.
+	words map[string][]string
+}
+
+// NewBannedWordsPolicy rejects texts and tags with any of the words. Words are matched whole, ignoring case,
+// look-alike digits and symbols ("b4d"), stretched letters ("baaad") and letters split with spaces or dots ("b.a.d").
+// Words are kept as written, so a banned "butt" does not take "but" with it.
+func NewBannedWordsPolicy(words []string) ContentPolicy {
+	p := &bannedWordsPolicy{words: map[string][]string{}}
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			p.words[squeeze(word)] = append(p.words[squeeze(word)], word)
+		}
+	}
+	return p
+}
+
+func (p *bannedWordsPolicy) Check(content entity.Content) (entity.PolicyDecision, error) {
+	for _, text := range append([]string{content.Text}, content.Tags...) {
+		if p.banned(text) {
+			return entity.PolicyDecision{Action: entity.PolicyReject, Policy: "banned_words", Reason: "Text has a banned word"}, nil
+		}
+	}
+	return allow(), nil
+}
+
+// banned compares every word of the text with the banned words. Runs of single letters are joined
+// and searched for banned words, as "a s c a m" starts with the article.
+func (p *bannedWordsPolicy) banned(text string) bool {
+	tokens := strings.FieldsFunc(leet.Replace(strings.ToLower(text)), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	var runs []string
+	var letters strings.Builder
+	for _, token := range tokens {
+		for _, word := range p.words[squeeze(token)] {
+			if stretches(token, word) {
+				return true
+			}
+		}
+		if len([]rune(token)) == 1 {
+			letters.WriteString(token)
+			continue
+		}
+		runs = append(runs, letters.String())
+		letters.Reset()
+	}
+	runs = append(runs, letters.String())
+
+	for _, run := range runs {
+		if len(run) < 2 {
+			continue
+		}
+		for squeezed, words := range p.words {
+			for _, word := range words {
+				// a squeezed run can only be searched for words that have no doubled letters themselves
+				if strings.Contains(run, word) || (squeezed == word && strings.Contains(squeeze(run), word)) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// stretches tells whether the token is the word with some of its letters repeated more, "baaad"
+// stretches "bad" and "buttt" stretches "butt", but "but" does not stretch "butt".
+func stretches(token, word string) bool {
+	tokenRuns, wordRuns := letterRuns(token), letterRuns(word)
+	if len(tokenRuns) != len(wordRuns) {
+		return false
+	}
+	for i, run := range tokenRuns {
+		if run.letter != wordRuns[i].letter || run.count < wordRuns[i].count {
+			return false
+		}
+	}
+	return true
+}
+
+type letterRun struct {
+	letter rune
+	count  int
+}
+
+func letterRuns(word string) []letterRun {
+	var runs []letterRun
+	for _, r := range word {
+		if n := len(runs); n > 0 && runs[n-1].letter == r {
+			runs[n-1].count++
+			continue
+		}
+		runs = append(runs, letterRun{letter: r, count: 1})
+	}
+	return runs
+}
+
+// squeeze leaves one of each run of the same letter, so "baaad" and "bad" are the same word.
+func squeeze(word string) string {
+	var b strings.Builder
+	var last rune
+	for i, r := range word {
+		if i > 0 && r == last {
+			continue
+		}
+		b.WriteRune(r)
+		last = r
+	}
+	return b.String()
+}
+
+// links are the starts of web addresses.
+var links = regexp.MustCompile(`(?i)\b(?:https?://|www\.)`)
+
+type linkLimitPolicy struct {
+	max int
+}
+
+// NewLinkLimitPolicy holds texts with more than max links for a moderator, many links are a sign of spam.
+func NewLinkLimitPolicy(max int) ContentPolicy {
+	return &linkLimitPolicy{max: max}
+}
+
+func (p *linkLimitPolicy) Check(content entity.Content) (entity.PolicyDecision, error) {
+	if len(links.FindAllStringIndex(content.Text, -1)) > p.max {
+		return entity.PolicyDecision{Action: entity.PolicyHold, Policy: "links", Reason: "Text has more than " + strconv.Itoa(p.max) + " links"}, nil
+	}
+	return allow(), nil
+}
+
+// separators are characters repeated to draw lines and markup, long runs of them are fine.
+const separators = "-=_*#~`."
+
+type repeatedCharactersPolicy struct {
+	max int
+}
+
+// NewRepeatedCharactersPolicy rejects texts where one character is repeated more than max times in a row,
+// like "!!!!!!!!!!!!" or "aaaaaaaaaaaa". Spaces and separators are not counted.
+func NewRepeatedCharactersPolicy(max int) ContentPolicy {
+	return &repeatedCharactersPolicy{max: max}
+}
+
+func (p *repeatedCharactersPolicy) Check(content entity.Content) (entity.PolicyDecision, error) {
+	run := 0
+	var last rune
+	for _, r := range content.Text {
+		if r == last {
+			run++
+		} else {
+			last, run = r, 1
+		}
+
+		if run > p.max && !unicode.IsSpace(r) && !strings.ContainsRune(separators, r) {
+			return entity.PolicyDecision{Action: entity.PolicyReject, Policy: "repeated_characters", Reason: "Text repeats a character more than " + strconv.Itoa(p.max) + " times"}, nil
+		}
+	}
+	return allow(), nil
+}
+
+type duplicatePolicy struct {
+	recent RecentContentRepositoriy
+	window time.Duration
+}
+
+// NewDuplicatePolicy rejects a new post with the text the same user posted within the window.
+// Edits are not checked, an edit does not add a post.
+func NewDuplicatePolicy(recent RecentContentRepositoriy, window time.Duration) ContentPolicy {
+	return &duplicatePolicy{recent: recent, window: window}
+}
+
+func (p *duplicatePolicy) Check(content entity.Content) (entity.PolicyDecision, error) {
+	if content.Edit {
+		return allow(), nil
+	}
+
+	found, err := p.recent.HasRecent(content.Kind, content.UserID, content.Text, time.Now().Add(-p.window))
+	if err != nil {
+		return entity.PolicyDecision{}, err
+	}
+	if found {
+		return entity.PolicyDecision{Action: entity.PolicyReject, Policy: "duplicate", Reason: "Same " + content.Kind + " was posted recently"}, nil
+	}
+	return allow(), nil
+}
@@ -1,52 +1,321 @@
 package usecase
 
 import (
-	"errors"
 	"testovoe/internal/entity"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type QuestionRepositoriy interface {
-	GetAll() ([]entity.Question, error)
+	Find(entity.QuestionFilter) ([]entity.Question, error)
 	GetByID(int) (entity.Question, error)
+	// Save stores the question together with its tags, creating tags that do not exist yet.
 	Save(entity.Question) (entity.Question, error)
-	Delete(int) error
+	// Update stores the text before the change as a new revision and saves the question
+	// if it still has the version it was read with, the version is then increased.
+	Update(entity.Question) (entity.Question, error)
+	ListRevisions(int) ([]entity.Revision, error)
+	// Delete moves the question and its answers to the trash if it still has the given version.
+	Delete(id int, version int) error
+	Restore(int) (entity.Question, error)
+	// GetDeleted returns a question from the trash.
+	GetDeleted(int) (entity.Question, error)
+	ListDeleted(entity.PageRequest) ([]entity.Question, error)
+	// Purge permanently removes questions deleted before the given time.
+	Purge(time.Time) (int64, error)
 }
 
 type QuestionUseCase struct {
-	repo QuestionRepositoriy
+	repo        QuestionRepositoriy
+	ansRepo     AnswerRepositoriy
+	commentRepo CommentRepositoriy
+	userRepo    UserRepositoriy
+	limit       LengthLimit
+	moderation  *ContentModeration
 }
 
-func NewQuestionUseCase(repo QuestionRepositoriy) *QuestionUseCase {
-	return &QuestionUseCase{repo: repo}
+// NewQuestionUseCase makes questions checked against the text limits and, unless moderation is nil, content policies.
+func NewQuestionUseCase(repo QuestionRepositoriy, ansRepo AnswerRepositoriy, commentRepo CommentRepositoriy, userRepo UserRepositoriy, limits TextLimits, moderation *ContentModeration) *QuestionUseCase {
+	return &QuestionUseCase{
+		repo:        repo,
+		ansRepo:     ansRepo,
+		commentRepo: commentRepo,
+		userRepo:    userRepo,
+		limit:       limits.Question,
+		moderation:  moderation,
+	}
 }
 
 func (uc *QuestionUseCase) Save(dto entity.QuestionDto) (entity.Question, error) {
-	if 5 > len(dto.Text) {
-		return entity.Question{}, errors.New("Text of question is short")
+	if dto.UserID == uuid.Nil {
+		return entity.Question{}, ErrUnauthenticated
+	}
+
+	text, textErr := cleanText("question", dto.Text, uc.limit)
+	tags, tagsErr := normalizeTags(dto.Tags)
+	if err := joinInvalid(textErr, tagsErr); err != nil {
+		return entity.Question{}, err
 	}
 
-	if len(dto.Text) > 200 {
-		return entity.Question{}, errors.New("Text of question is long")
+	content := entity.Content{Kind: entity.ContentQuestion, UserID: dto.UserID, Text: text, Tags: tags}
+	if err := uc.moderation.screen(entity.HeldContent{Content: content}); err != nil {
+		return entity.Question{}, err
 	}
 
+	return uc.publish(dto.UserID, text, tags)
+}
+
+// publish stores a question that passed validation and content policies.
+func (uc *QuestionUseCase) publish(userID uuid.UUID, text string, tags []string) (entity.Question, error) {
+	author, err := authorOf(uc.userRepo, userID)
+	if err != nil {
+		return entity.Question{}, err
+	}
+
+	now := time.Now()
 	question := entity.Question{
-		UserID:    dto.UserID,
-		Text:      dto.Text,
-		CreatedAt: time.Now(),
+		UserID:         userID,
+		Text:           text,
+		Tags:           tags,
+		LastActivityAt: now,
+		CreatedAt:      now,
 	}
 
-	return uc.repo.Save(question)
+	saved, err := uc.repo.Save(question)
+	if err != nil {
+		return entity.Question{}, err
+	}
+
+	saved.Author = author
+	return saved, nil
 }
 
-func (uc *QuestionUseCase) GetAll() ([]entity.Question, error) {
-	return uc.repo.GetAll()
+func (uc *QuestionUseCase) List(filter entity.QuestionFilter) (entity.Page[entity.Question], error) {
+	if filter.Sort == "" {
+		filter.Sort = entity.SortOldest
+	}
+
+	cursorOf, ok := questionCursors[filter.Sort]
+	if !ok {
+		return entity.Page[entity.Question]{}, Invalid("sort", "one_of", "Sort is unknown")
+	}
+
+	if filter.MinAnswers < 0 {
+		return entity.Page[entity.Question]{}, Invalid("min_answers", "min 0", "Min answers must be positive")
+	}
+
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && !filter.CreatedAfter.Before(*filter.CreatedBefore) {
+		return entity.Page[entity.Question]{}, Invalid("created_after", "before created_before", "Created after must be before created before")
+	}
+
+	tags, err := normalizeTags(filter.Tags)
+	if err != nil {
+		return entity.Page[entity.Question]{}, err
+	}
+	filter.Tags = tags
+
+	return fetchSortedPage(
+		filter.Page,
+		string(filter.Sort),
+		func(page entity.PageRequest) ([]entity.Question, error) {
+			filter.Page = page
+			return uc.repo.Find(filter)
+		},
+		cursorOf,
+	)
 }
 
 func (uc *QuestionUseCase) GetByID(ID int) (entity.Question, error) {
-	return uc.repo.GetByID(ID)
+	return visibleQuestion(uc.repo, ID)
+}
+
+func (uc *QuestionUseCase) GetWithAnswers(ID int) (entity.QuestionWithAnswers, error) {
+	question, err := visibleQuestion(uc.repo, ID)
+	if err != nil {
+		return entity.QuestionWithAnswers{}, err
+	}
+
+	answers, err := fetchSortedPage(
+		entity.PageRequest{Limit: DefaultPageLimit},
+		string(entity.AnswerSortOldest),
+		func(page entity.PageRequest) ([]entity.Answer, error) {
+			return uc.ansRepo.ListByQuestion(ID, entity.AnswerSortOldest, page)
+		},
+		answerCursors[entity.AnswerSortOldest],
+	)
+	if err != nil {
+		return entity.QuestionWithAnswers{}, err
+	}
+
+	questionComments, err := uc.commentRepo.ListByTargets(entity.CommentTargetQuestion, []int{ID})
+	if err != nil {
+		return entity.QuestionWithAnswers{}, err
+	}
+
+	answerIDs := make([]int, len(answers.Items))
+	for i, answer := range answers.Items {
+		answerIDs[i] = answer.ID
+	}
+
+	answerComments, err := uc.commentRepo.ListByTargets(entity.CommentTargetAnswer, answerIDs)
+	if err != nil {
+		return entity.QuestionWithAnswers{}, err
+	}
+
+	commentsOf := make(map[int][]entity.Comment, len(answerIDs))
+	for _, comment := range answerComments {
+		commentsOf[comment.TargetID] = append(commentsOf[comment.TargetID], comment)
+	}
+
+	withComments := make([]entity.AnswerWithComments, len(answers.Items))
+	for i, answer := range answers.Items {
+		withComments[i] = entity.AnswerWithComments{Answer: answer, Comments: commentsOf[answer.ID]}
+		if withComments[i].Comments == nil {
+			withComments[i].Comments = []entity.Comment{}
+		}
+	}
+
+	return entity.QuestionWithAnswers{
+		Question:          question,
+		Comments:          questionComments,
+		Answers:           withComments,
+		AnswersNextCursor: answers.NextCursor,
+	}, nil
+}
+
+// Update changes the text of the question, only its author or a moderator may do it.
+func (uc *QuestionUseCase) Update(actor entity.User, ID int, dto entity.QuestionUpdateDto) (entity.Question, error) {
+	if dto.Text == nil {
+		return entity.Question{}, Validation("Nothing to update")
+	}
+
+	text, err := cleanText("question", *dto.Text, uc.limit)
+	if err != nil {
+		return entity.Question{}, err
+	}
+
+	question, err := uc.repo.GetByID(ID)
+	if err != nil {
+		return entity.Question{}, err
+	}
+
+	if err := canModify(actor, question.UserID); err != nil {
+		return entity.Question{}, err
+	}
+
+	if question.LockedAt != nil && !actor.IsModerator() {
+		return entity.Question{}, ErrLocked
+	}
+
+	if err := checkVersion(question.Version, dto.Versions); err != nil {
+		return entity.Question{}, err
+	}
+
+	if question.Text == text {
+		return question, nil
+	}
+
+	if !actor.IsModerator() {
+		content := entity.Content{Kind: entity.ContentQuestion, UserID: actor.ID, Text: text, Tags: question.Tags, Edit: true}
+		held := entity.HeldContent{Content: content, PostID: &question.ID, Version: &question.Version}
+		if err := uc.moderation.screen(held); err != nil {
+			return entity.Question{}, err
+		}
+	}
+
+	return uc.change(question, text)
+}
+
+// rewrite applies an approved edit made to the given version of the question.
+func (uc *QuestionUseCase) rewrite(ID int, version int, text string) (entity.Question, error) {
+	question, err := uc.repo.GetByID(ID)
+	if err != nil {
+		return entity.Question{}, err
+	}
+
+	if err := checkVersion(question.Version, []int{version}); err != nil {
+		return entity.Question{}, err
+	}
+
+	return uc.change(question, text)
+}
+
+func (uc *QuestionUseCase) change(question entity.Question, text string) (entity.Question, error) {
+	now := time.Now()
+	question.Text = text
+	question.UpdatedAt = &now
+
+	return uc.repo.Update(question)
+}
+
+func (uc *QuestionUseCase) GetRevisions(ID int) ([]entity.Revision, error) {
+	question, err := visibleQuestion(uc.repo, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := uc.repo.ListRevisions(ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return withCurrent(previous, question.Text, question.CreatedAt, question.UpdatedAt), nil
+}
+
+func (uc *QuestionUseCase) DiffRevisions(ID, from, to int) (entity.RevisionDiff, error) {
+	revisions, err := uc.GetRevisions(ID)
+	if err != nil {
+		return entity.RevisionDiff{}, err
+	}
+
+	return diffRevisions(revisions, from, to)
+}
+
+// Delete moves the question to the trash, only its author or a moderator may do it.
+// The current version must be one of versions unless they are nil.
+func (uc *QuestionUseCase) Delete(actor entity.User, ID int, versions []int) error {
+	question, err := uc.repo.GetByID(ID)
+	if err != nil {
+		return err
+	}
+
+	if err := canModify(actor, question.UserID); err != nil {
+		return err
+	}
+
+	if err := checkVersion(question.Version, versions); err != nil {
+		return err
+	}
+
+	return uc.repo.Delete(ID, question.Version)
+}
+
+func (uc *QuestionUseCase) Restore(actor entity.User, ID int) (entity.Question, error) {
+	question, err := uc.repo.GetDeleted(ID)
+	if err != nil {
+		return entity.Question{}, err
+	}
+
+	if err := canModify(actor, question.UserID); err != nil {
+		return entity.Question{}, err
+	}
+
+	return uc.repo.Restore(ID)
 }
 
-func (uc *QuestionUseCase) Delete(ID int) error {
-	return uc.repo.Delete(ID)
+// questionCursors knows which field is the sort key for every supported order.
+var questionCursors = map[entity.QuestionSort]func(entity.Question) entity.Cursor{
+	entity.SortNewest: func(q entity.Question) entity.Cursor {
+		return entity.Cursor{Time: q.CreatedAt, ID: q.ID}
+	},
+	entity.SortOldest: func(q entity.Question) entity.Cursor {
+		return entity.Cursor{Time: q.CreatedAt, ID: q.ID}
+	},
+	entity.SortMostAnswered: func(q entity.Question) entity.Cursor {
+		return entity.Cursor{Count: q.AnswerCount, ID: q.ID}
+	},
+	entity.SortRecentActivity: func(q entity.Question) entity.Cursor {
+		return entity.Cursor{Time: q.LastActivityAt, ID: q.ID}
+	},
 }
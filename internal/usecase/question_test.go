@@ -1,9 +1,11 @@
 package usecase_test
 
 import (
+	"errors"
 	"testing"
 	"testovoe/internal/entity"
 	"testovoe/internal/usecase"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -12,8 +14,8 @@ import (
 
 type MockQuestionRepo struct{ mock.Mock }
 
-func (m *MockQuestionRepo) GetAll() ([]entity.Question, error) {
-	args := m.Called()
+func (m *MockQuestionRepo) Find(filter entity.QuestionFilter) ([]entity.Question, error) {
+	args := m.Called(filter)
 	return args.Get(0).([]entity.Question), args.Error(1)
 }
 
@@ -22,15 +24,47 @@ func (m *MockQuestionRepo) Save(question entity.Question) (entity.Question, erro
 	return args.Get(0).(entity.Question), args.Error(1)
 }
 
-func (m *MockQuestionRepo) Delete(id int) error {
+func (m *MockQuestionRepo) Update(question entity.Question) (entity.Question, error) {
+	args := m.Called(question)
+	return args.Get(0).(entity.Question), args.Error(1)
+}
+
+func (m *MockQuestionRepo) ListRevisions(id int) ([]entity.Revision, error) {
 	args := m.Called(id)
+	return args.Get(0).([]entity.Revision), args.Error(1)
+}
+
+func (m *MockQuestionRepo) Delete(id int, version int) error {
+	args := m.Called(id, version)
 	return args.Error(0)
 }
 
+func (m *MockQuestionRepo) Restore(id int) (entity.Question, error) {
+	args := m.Called(id)
+	return args.Get(0).(entity.Question), args.Error(1)
+}
+
+func (m *MockQuestionRepo) GetDeleted(id int) (entity.Question, error) {
+	args := m.Called(id)
+	return args.Get(0).(entity.Question), args.Error(1)
+}
+
+func (m *MockQuestionRepo) ListDeleted(page entity.PageRequest) ([]entity.Question, error) {
+	args := m.Called(page)
+	return args.Get(0).([]entity.Question), args.Error(1)
+}
+
+func (m *MockQuestionRepo) Purge(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func TestQuestionUseCase_Save(t *testing.T) {
 	mockRepo := new(MockQuestionRepo)
-	uc := usecase.NewQuestionUseCase(mockRepo)
+	mockUserRepo := new(MockUserRepo)
+	uc := usecase.NewQuestionUseCase(mockRepo, new(MockAnswerRepo), new(MockCommentRepo), mockUserRepo, usecase.DefaultTextLimits(), nil)
 	userID := uuid.New()
+	mockUserRepo.On("GetByID", userID).Return(entity.User{ID: userID, DisplayName: "Alice"}, nil)
 
 	t.Run("success", func(t *testing.T) {
 		dto := entity.QuestionDto{UserID: userID, Text: "Valid question"}
@@ -44,6 +78,15 @@ func TestQuestionUseCase_Save(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("unknown user", func(t *testing.T) {
+		stranger := uuid.New()
+		mockUserRepo.On("GetByID", stranger).Return(entity.User{}, errors.New("not found"))
+
+		_, err := uc.Save(entity.QuestionDto{UserID: stranger, Text: "Valid question"})
+
+		assert.Error(t, err)
+	})
+
 	t.Run("short text", func(t *testing.T) {
 		dto := entity.QuestionDto{UserID: userID, Text: "Hi"}
 		result, err := uc.Save(dto)
@@ -52,26 +95,260 @@ func TestQuestionUseCase_Save(t *testing.T) {
 	})
 }
 
-func TestQuestionUseCase_GetAll(t *testing.T) {
+func TestQuestionUseCase_List(t *testing.T) {
 	mockRepo := new(MockQuestionRepo)
-	uc := usecase.NewQuestionUseCase(mockRepo)
-	questions := []entity.Question{{ID: 1, Text: "Test"}}
-	mockRepo.On("GetAll").Return(questions, nil)
+	uc := usecase.NewQuestionUseCase(mockRepo, new(MockAnswerRepo), new(MockCommentRepo), new(MockUserRepo), usecase.DefaultTextLimits(), nil)
 
-	result, err := uc.GetAll()
+	t.Run("last page", func(t *testing.T) {
+		questions := []entity.Question{{ID: 1, Text: "Test"}}
+		filter := entity.QuestionFilter{Sort: entity.SortOldest, Page: entity.PageRequest{Limit: 3}}
+		mockRepo.On("Find", filter).Return(questions, nil).Once()
 
+		result, err := uc.List(entity.QuestionFilter{Page: entity.PageRequest{Limit: 2}})
+
+		assert.NoError(t, err)
+		assert.Len(t, result.Items, 1)
+		assert.Empty(t, result.NextCursor)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("has next page", func(t *testing.T) {
+		createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		questions := []entity.Question{
+			{ID: 1, CreatedAt: createdAt},
+			{ID: 2, CreatedAt: createdAt},
+			{ID: 3, CreatedAt: createdAt},
+		}
+		filter := entity.QuestionFilter{Sort: entity.SortOldest, Page: entity.PageRequest{Limit: 3}}
+		mockRepo.On("Find", filter).Return(questions, nil).Once()
+
+		result, err := uc.List(entity.QuestionFilter{Page: entity.PageRequest{Limit: 2}})
+
+		assert.NoError(t, err)
+		assert.Len(t, result.Items, 2)
+
+		cursor, err := usecase.DecodeCursor(result.NextCursor)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, cursor.ID)
+		assert.True(t, createdAt.Equal(cursor.Time))
+	})
+
+	t.Run("most answered cursor", func(t *testing.T) {
+		questions := []entity.Question{{ID: 7, AnswerCount: 5}, {ID: 3, AnswerCount: 1}}
+		filter := entity.QuestionFilter{Sort: entity.SortMostAnswered, Page: entity.PageRequest{Limit: 2}}
+		mockRepo.On("Find", filter).Return(questions, nil).Once()
+
+		result, err := uc.List(entity.QuestionFilter{Sort: entity.SortMostAnswered, Page: entity.PageRequest{Limit: 1}})
+
+		assert.NoError(t, err)
+		cursor, err := usecase.DecodeCursor(result.NextCursor)
+		assert.NoError(t, err)
+		assert.Equal(t, entity.Cursor{Sort: "most_answered", Time: cursor.Time, Count: 5, ID: 7}, *cursor)
+	})
+
+	t.Run("cursor of another sort", func(t *testing.T) {
+		page := entity.PageRequest{After: &entity.Cursor{Sort: "most_answered", Count: 5, ID: 7}, Limit: 1}
+
+		_, err := uc.List(entity.QuestionFilter{Sort: entity.SortNewest, Page: page})
+
+		assert.ErrorIs(t, err, usecase.ErrCursorSort)
+		assert.Equal(t, usecase.KindMalformed, usecase.KindOf(err))
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		_, err := uc.List(entity.QuestionFilter{Sort: "random"})
+		assert.Error(t, err)
+
+		_, err = uc.List(entity.QuestionFilter{MinAnswers: -1})
+		assert.Error(t, err)
+
+		after, before := time.Now(), time.Now().Add(-time.Hour)
+		_, err = uc.List(entity.QuestionFilter{CreatedAfter: &after, CreatedBefore: &before})
+		assert.Error(t, err)
+	})
+}
+
+func TestNewPageRequest(t *testing.T) {
+	page, err := usecase.NewPageRequest("", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, usecase.DefaultPageLimit, page.Limit)
+	assert.Nil(t, page.After)
+
+	page, err = usecase.NewPageRequest("", 1000)
 	assert.NoError(t, err)
-	assert.Len(t, result, 1)
-	mockRepo.AssertExpectations(t)
+	assert.Equal(t, usecase.MaxPageLimit, page.Limit)
+
+	_, err = usecase.NewPageRequest("", -1)
+	assert.Error(t, err)
+
+	_, err = usecase.NewPageRequest("not a cursor", 10)
+	assert.Error(t, err)
 }
 
 func TestQuestionUseCase_Delete(t *testing.T) {
 	mockRepo := new(MockQuestionRepo)
-	uc := usecase.NewQuestionUseCase(mockRepo)
-	mockRepo.On("Delete", 1).Return(nil)
+	uc := usecase.NewQuestionUseCase(mockRepo, new(MockAnswerRepo), new(MockCommentRepo), new(MockUserRepo), usecase.DefaultTextLimits(), nil)
+	author := entity.User{ID: uuid.New(), Role: entity.RoleUser}
+	mockRepo.On("GetByID", 1).Return(entity.Question{ID: 1, UserID: author.ID, Version: 3}, nil)
+
+	t.Run("somebody else", func(t *testing.T) {
+		err := uc.Delete(entity.User{ID: uuid.New(), Role: entity.RoleUser}, 1, nil)
+
+		assert.ErrorIs(t, err, usecase.ErrForbidden)
+		mockRepo.AssertNotCalled(t, "Delete", 1, 3)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		err := uc.Delete(entity.User{}, 1, nil)
+
+		assert.ErrorIs(t, err, usecase.ErrUnauthenticated)
+	})
+
+	t.Run("author", func(t *testing.T) {
+		mockRepo.On("Delete", 1, 3).Return(nil).Once()
+
+		err := uc.Delete(author, 1, nil)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("moderator", func(t *testing.T) {
+		mockRepo.On("Delete", 1, 3).Return(nil).Once()
+
+		err := uc.Delete(entity.User{ID: uuid.New(), Role: entity.RoleModerator}, 1, []int{2, 3})
+
+		assert.NoError(t, err)
+		mockRepo.AssertNumberOfCalls(t, "Delete", 2)
+	})
+
+	t.Run("stale version", func(t *testing.T) {
+		err := uc.Delete(author, 1, []int{2})
+
+		assert.ErrorIs(t, err, usecase.ErrVersionMismatch)
+		mockRepo.AssertNumberOfCalls(t, "Delete", 2)
+	})
+}
+
+func TestQuestionUseCase_GetWithAnswers(t *testing.T) {
+	mockRepo := new(MockQuestionRepo)
+	mockAnswerRepo := new(MockAnswerRepo)
+	mockCommentRepo := new(MockCommentRepo)
+	uc := usecase.NewQuestionUseCase(mockRepo, mockAnswerRepo, mockCommentRepo, new(MockUserRepo), usecase.DefaultTextLimits(), nil)
+
+	t.Run("success", func(t *testing.T) {
+		question := entity.Question{ID: 1, Text: "Test"}
+		answers := []entity.Answer{{ID: 1, QuestionID: 1}, {ID: 2, QuestionID: 1}}
+		mockRepo.On("GetByID", 1).Return(question, nil)
+		mockAnswerRepo.On("ListByQuestion", 1, entity.AnswerSortOldest, entity.PageRequest{Limit: usecase.DefaultPageLimit + 1}).Return(answers, nil)
+		mockCommentRepo.On("ListByTargets", entity.CommentTargetQuestion, []int{1}).
+			Return([]entity.Comment{{ID: 1, TargetType: entity.CommentTargetQuestion, TargetID: 1}}, nil)
+		mockCommentRepo.On("ListByTargets", entity.CommentTargetAnswer, []int{1, 2}).
+			Return([]entity.Comment{{ID: 2, TargetType: entity.CommentTargetAnswer, TargetID: 2}}, nil)
+
+		result, err := uc.GetWithAnswers(1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, result.ID)
+		assert.Len(t, result.Comments, 1)
+		assert.Len(t, result.Answers, 2)
+		assert.Empty(t, result.Answers[0].Comments)
+		assert.Equal(t, 2, result.Answers[1].Comments[0].ID)
+		mockRepo.AssertExpectations(t)
+		mockAnswerRepo.AssertExpectations(t)
+		mockCommentRepo.AssertExpectations(t)
+	})
+
+	t.Run("question not found", func(t *testing.T) {
+		mockRepo.On("GetByID", 999).Return(entity.Question{}, errors.New("not found"))
+
+		_, err := uc.GetWithAnswers(999)
 
-	err := uc.Delete(1)
+		assert.Error(t, err)
+		mockAnswerRepo.AssertNotCalled(t, "ListByQuestion", 999, mock.Anything, mock.Anything)
+	})
+}
 
+func TestQuestionUseCase_Update(t *testing.T) {
+	mockRepo := new(MockQuestionRepo)
+	uc := usecase.NewQuestionUseCase(mockRepo, new(MockAnswerRepo), new(MockCommentRepo), new(MockUserRepo), usecase.DefaultTextLimits(), nil)
+	author := entity.User{ID: uuid.New(), Role: entity.RoleUser}
+	question := entity.Question{ID: 1, UserID: author.ID, Text: "Old question"}
+	mockRepo.On("GetByID", 1).Return(question, nil)
+
+	t.Run("success", func(t *testing.T) {
+		text := "New question"
+		mockRepo.On("Update", mock.MatchedBy(func(q entity.Question) bool {
+			return q.ID == 1 && q.Text == text && q.UpdatedAt != nil
+		})).Return(entity.Question{ID: 1, Text: text}, nil).Once()
+
+		result, err := uc.Update(author, 1, entity.QuestionUpdateDto{Text: &text})
+
+		assert.NoError(t, err)
+		assert.Equal(t, text, result.Text)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("same text is not a revision", func(t *testing.T) {
+		text := "Old question"
+		result, err := uc.Update(author, 1, entity.QuestionUpdateDto{Text: &text})
+
+		assert.NoError(t, err)
+		assert.Equal(t, question, result)
+		mockRepo.AssertNumberOfCalls(t, "Update", 1)
+	})
+
+	t.Run("stale version", func(t *testing.T) {
+		text := "New question"
+		_, err := uc.Update(author, 1, entity.QuestionUpdateDto{Text: &text, Versions: []int{2}})
+
+		assert.ErrorIs(t, err, usecase.ErrVersionMismatch)
+		mockRepo.AssertNumberOfCalls(t, "Update", 1)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		short := "Hi"
+		_, err := uc.Update(author, 1, entity.QuestionUpdateDto{Text: &short})
+		assert.Error(t, err)
+
+		_, err = uc.Update(author, 1, entity.QuestionUpdateDto{})
+		assert.Error(t, err)
+	})
+
+	t.Run("somebody else", func(t *testing.T) {
+		text := "Hijacked question"
+		_, err := uc.Update(entity.User{ID: uuid.New(), Role: entity.RoleUser}, 1, entity.QuestionUpdateDto{Text: &text})
+
+		assert.ErrorIs(t, err, usecase.ErrForbidden)
+		mockRepo.AssertNumberOfCalls(t, "Update", 1)
+	})
+}
+
+func TestQuestionUseCase_DiffRevisions(t *testing.T) {
+	mockRepo := new(MockQuestionRepo)
+	uc := usecase.NewQuestionUseCase(mockRepo, new(MockAnswerRepo), new(MockCommentRepo), new(MockUserRepo), usecase.DefaultTextLimits(), nil)
+	updatedAt := time.Now()
+	mockRepo.On("GetByID", 1).Return(entity.Question{ID: 1, Text: "How to run fast tests?", UpdatedAt: &updatedAt}, nil)
+	mockRepo.On("ListRevisions", 1).Return([]entity.Revision{{Revision: 1, Text: "How to run tests?"}}, nil)
+
+	revisions, err := uc.GetRevisions(1)
+	assert.NoError(t, err)
+	assert.Len(t, revisions, 2)
+	assert.Equal(t, 2, revisions[1].Revision)
+	assert.Equal(t, updatedAt, revisions[1].CreatedAt)
+
+	diff, err := uc.DiffRevisions(1, 1, 2)
 	assert.NoError(t, err)
-	mockRepo.AssertExpectations(t)
+	assert.Equal(t, []entity.DiffChange{
+		{Op: entity.DiffEqual, Text: "How to run "},
+		{Op: entity.DiffInsert, Text: "fast "},
+		{Op: entity.DiffEqual, Text: "tests?"},
+	}, diff.Changes)
+
+	diff, err = uc.DiffRevisions(1, 2, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.DiffDelete, diff.Changes[1].Op)
+
+	_, err = uc.DiffRevisions(1, 1, 3)
+	assert.Error(t, err)
 }
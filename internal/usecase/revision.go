@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"regexp"
+	"testovoe/internal/entity"
+	"time"
+)
+
+// withCurrent appends the current text as the last revision to the stored previous ones.
+func withCurrent(previous []entity.Revision, text string, createdAt time.Time, updatedAt *time.Time) []entity.Revision {
+	current := entity.Revision{
+		Revision:  len(previous) + 1,
+		Text:      text,
+		CreatedAt: createdAt,
+	}
+	if updatedAt != nil {
+		current.CreatedAt = *updatedAt
+	}
+
+	return append(previous, current)
+}
+
+func diffRevisions(revisions []entity.Revision, from, to int) (entity.RevisionDiff, error) {
+	if from < 1 || from > len(revisions) || to < 1 || to > len(revisions) {
+		return entity.RevisionDiff{}, NotFound("This revision is not exist")
+	}
+
+	return entity.RevisionDiff{
+		From:    from,
+		To:      to,
+		Changes: diffWords(revisions[from-1].Text, revisions[to-1].Text),
+	}, nil
+}
+
+var wordsAndSpaces = regexp.MustCompile(`\s+|\S+`)
+
+// diffWords is a word-level diff based on the longest common subsequence; texts are short so O(n*m) is fine.
+func diffWords(a, b string) []entity.DiffChange {
+	x := wordsAndSpaces.FindAllString(a, -1)
+	y := wordsAndSpaces.FindAllString(b, -1)
+
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	changes := []entity.DiffChange{}
+	add := func(op, text string) {
+		if n := len(changes); n > 0 && changes[n-1].Op == op {
+			changes[n-1].Text += text
+			return
+		}
+		changes = append(changes, entity.DiffChange{Op: op, Text: text})
+	}
+
+	i, j := 0, 0
+	for i < len(x) && j < len(y) {
+		switch {
+		case x[i] == y[j]:
+			add(entity.DiffEqual, x[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			add(entity.DiffDelete, x[i])
+			i++
+		default:
+			add(entity.DiffInsert, y[j])
+			j++
+		}
+	}
+	for ; i < len(x); i++ {
+		add(entity.DiffDelete, x[i])
+	}
+	for ; j < len(y); j++ {
+		add(entity.DiffInsert, y[j])
+	}
+
+	return changes
+}
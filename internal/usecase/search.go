@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"strconv"
+	"strings"
+	"testovoe/internal/entity"
+	"unicode"
+)
+
+const maxSearchTerms = 10
+
+type SearchRepositoriy interface {
+	// Search returns hits containing all terms, best ranked first.
+	Search(terms []string, limit int) ([]entity.SearchResult, error)
+}
+
+type SearchUseCase struct {
+	repo SearchRepositoriy
+}
+
+func NewSearchUseCase(repo SearchRepositoriy) *SearchUseCase {
+	return &SearchUseCase{repo: repo}
+}
+
+func (uc *SearchUseCase) Search(query string, limit int) (entity.Page[entity.SearchResult], error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return entity.Page[entity.SearchResult]{}, Invalid("q", "required", "Search query is empty")
+	}
+
+	if len(terms) > maxSearchTerms {
+		return entity.Page[entity.SearchResult]{}, Invalid("q", "max_words "+strconv.Itoa(maxSearchTerms), "Search query has too many words")
+	}
+
+	page, err := NewPageRequest("", limit)
+	if err != nil {
+		return entity.Page[entity.SearchResult]{}, err
+	}
+
+	results, err := uc.repo.Search(terms, page.Limit)
+	if err != nil {
+		return entity.Page[entity.SearchResult]{}, err
+	}
+
+	return entity.Page[entity.SearchResult]{Items: results}, nil
+}
+
+// searchTerms splits the query into lower-cased words, so no search syntax of the database reaches it.
+func searchTerms(query string) []string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]bool, len(words))
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		if !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+
+	return terms
+}
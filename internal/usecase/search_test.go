@@ -0,0 +1,49 @@
+package usecase_test
+
+import (
+	"strings"
+	"testing"
+	"testovoe/internal/entity"
+	"testovoe/internal/usecase"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSearchRepo struct{ mock.Mock }
+
+func (m *MockSearchRepo) Search(terms []string, limit int) ([]entity.SearchResult, error) {
+	args := m.Called(terms, limit)
+	return args.Get(0).([]entity.SearchResult), args.Error(1)
+}
+
+func TestSearchUseCase_Search(t *testing.T) {
+	mockRepo := new(MockSearchRepo)
+	uc := usecase.NewSearchUseCase(mockRepo)
+
+	t.Run("query is split into words", func(t *testing.T) {
+		results := []entity.SearchResult{{Type: entity.SearchTypeQuestion, ID: 1}}
+		mockRepo.On("Search", []string{"goose", "миграции", "or", "v3"}, usecase.DefaultPageLimit).Return(results, nil)
+
+		page, err := uc.Search(`Goose: "миграции" OR goose* v3`, 0)
+
+		assert.NoError(t, err)
+		assert.Len(t, page.Items, 1)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("empty query", func(t *testing.T) {
+		_, err := uc.Search(" ?! ", 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("too many words", func(t *testing.T) {
+		mockRepo.On("Search", mock.Anything, usecase.DefaultPageLimit).Return([]entity.SearchResult{}, nil)
+
+		_, err := uc.Search(strings.Repeat("same ", 20)+"a b c d e f g h i", 0)
+		assert.NoError(t, err)
+
+		_, err = uc.Search("a b c d e f g h i j k", 0)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"strconv"
+	"strings"
+	"testovoe/internal/entity"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MaxQuestionTags = 5
+	MaxTagLength    = 32
+)
+
+type TagRepositoriy interface {
+	// List returns tags used by live questions, most used first.
+	List(entity.PageRequest) ([]entity.Tag, error)
+}
+
+type TagUseCase struct {
+	repo TagRepositoriy
+}
+
+func NewTagUseCase(repo TagRepositoriy) *TagUseCase {
+	return &TagUseCase{
+		repo: repo,
+	}
+}
+
+func (uc *TagUseCase) List(page entity.PageRequest) (entity.Page[entity.Tag], error) {
+	return fetchPage(page, uc.repo.List, func(t entity.Tag) entity.Cursor {
+		return entity.Cursor{Count: t.QuestionCount, ID: t.ID}
+	})
+}
+
+// normalizeTags lowercases tags, joins inner whitespace with dashes and drops duplicates,
+// so "Go", " go " and "GO" are the same tag.
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+
+	for _, tag := range tags {
+		tag, err := normalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > MaxQuestionTags {
+		return nil, Invalid("tags", "max_items "+strconv.Itoa(MaxQuestionTags), "Too many tags, maximum is "+strconv.Itoa(MaxQuestionTags))
+	}
+
+	return normalized, nil
+}
+
+func normalizeTag(tag string) (string, error) {
+	tag = strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+
+	if tag == "" {
+		return "", Invalid("tags", "required", "Tag is empty")
+	}
+
+	if utf8.RuneCountInString(tag) > MaxTagLength {
+		return "", Invalid("tags", "max_length "+strconv.Itoa(MaxTagLength), "Tag is long: "+tag)
+	}
+
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-.+#", r) {
+			return "", Invalid("tags", "pattern", "Tag has invalid characters: "+tag)
+		}
+	}
+
+	return tag, nil
+}
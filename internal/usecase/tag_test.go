@@ -0,0 +1,67 @@
+package usecase_test
+
+import (
+	"testing"
+	"testovoe/internal/entity"
+	"testovoe/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTagRepo struct{ mock.Mock }
+
+func (m *MockTagRepo) List(page entity.PageRequest) ([]entity.Tag, error) {
+	args := m.Called(page)
+	return args.Get(0).([]entity.Tag), args.Error(1)
+}
+
+func TestQuestionUseCase_SaveTags(t *testing.T) {
+	mockRepo := new(MockQuestionRepo)
+	mockUserRepo := new(MockUserRepo)
+	uc := usecase.NewQuestionUseCase(mockRepo, new(MockAnswerRepo), new(MockCommentRepo), mockUserRepo, usecase.DefaultTextLimits(), nil)
+	userID := uuid.New()
+	mockUserRepo.On("GetByID", userID).Return(entity.User{ID: userID}, nil)
+
+	t.Run("normalized", func(t *testing.T) {
+		mockRepo.On("Save", mock.MatchedBy(func(q entity.Question) bool {
+			return assert.ObjectsAreEqual([]string{"go", "c#", "unit-tests"}, q.Tags)
+		})).Return(entity.Question{ID: 1}, nil)
+
+		_, err := uc.Save(entity.QuestionDto{UserID: userID, Text: "Valid question", Tags: []string{" Go ", "C#", "go", "Unit  Tests"}})
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("too many", func(t *testing.T) {
+		_, err := uc.Save(entity.QuestionDto{UserID: userID, Text: "Valid question", Tags: []string{"a", "b", "c", "d", "e", "f"}})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, tag := range []string{"", "   ", "a/b", "<script>", "abcdefghijklmnopqrstuvwxyzabcdefg"} {
+			_, err := uc.Save(entity.QuestionDto{UserID: userID, Text: "Valid question", Tags: []string{tag}})
+			assert.Error(t, err, tag)
+		}
+	})
+}
+
+func TestTagUseCase_List(t *testing.T) {
+	mockRepo := new(MockTagRepo)
+	uc := usecase.NewTagUseCase(mockRepo)
+
+	tags := []entity.Tag{{ID: 3, Name: "go", QuestionCount: 7}, {ID: 1, Name: "sql", QuestionCount: 2}}
+	mockRepo.On("List", entity.PageRequest{Limit: 2}).Return(tags, nil)
+
+	page, err := uc.List(entity.PageRequest{Limit: 1})
+
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+
+	cursor, err := usecase.DecodeCursor(page.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, cursor.Count)
+	assert.Equal(t, 3, cursor.ID)
+}
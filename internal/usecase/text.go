@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// LengthLimit bounds the length of a text in characters as a reader counts them, see characters.
+type LengthLimit struct {
+	Min int
+	Max int
+}
+
+// ParseLengthLimit reads a limit written as "5-200".
+func ParseLengthLimit(value string) (LengthLimit, error) {
+	min, max, ok := strings.Cut(value, "-")
+	if !ok {
+		return LengthLimit{}, fmt.Errorf("length limit %q must look like 5-200", value)
+	}
+
+	limit := LengthLimit{}
+	var err error
+	if limit.Min, err = strconv.Atoi(strings.TrimSpace(min)); err != nil || limit.Min < 1 {
+		return LengthLimit{}, fmt.Errorf("length limit %q has an invalid minimum", value)
+	}
+	if limit.Max, err = strconv.Atoi(strings.TrimSpace(max)); err != nil || limit.Max < limit.Min {
+		return LengthLimit{}, fmt.Errorf("length limit %q has an invalid maximum", value)
+	}
+
+	return limit, nil
+}
+
+// TextLimits are the lengths allowed for texts of every kind of content.
+type TextLimits struct {
+	Question LengthLimit
+	Answer   LengthLimit
+	Comment  LengthLimit
+}
+
+// DefaultTextLimits are the limits used when the configuration does not set them.
+func DefaultTextLimits() TextLimits {
+	return TextLimits{
+		Question: LengthLimit{Min: 5, Max: 200},
+		Answer:   LengthLimit{Min: 5, Max: 200},
+		Comment:  LengthLimit{Min: 5, Max: 200},
+	}
+}
+
+var (
+	// blankLines are three and more line breaks, possibly with spaces between them.
+	blankLines = regexp.MustCompile(`\n[ \t]*\n(?:[ \t]*\n)+`)
+	// trailingSpaces are spaces at the end of a line.
+	trailingSpaces = regexp.MustCompile(`[ \t]+\n`)
+)
+
+// cleanText normalizes a text of the given kind and checks it against the limit. The normalized text
+// is what gets stored, so "é" typed as one or as two code points is the same text.
+func cleanText(kind, text string, limit LengthLimit) (string, error) {
+	if !utf8.ValidString(text) {
+		return "", Invalid("text", "utf8", "Text of "+kind+" is not valid UTF-8")
+	}
+
+	text = normalizeText(text)
+
+	if r, ok := forbiddenRune(text); ok {
+		return "", Invalid("text", "no_control_characters", fmt.Sprintf("Text of %s has a forbidden character %U", kind, r))
+	}
+
+	if characters(text) < limit.Min {
+		return "", Invalid("text", "min_length "+strconv.Itoa(limit.Min), "Text of "+kind+" is short")
+	}
+
+	if characters(text) > limit.Max {
+		return "", Invalid("text", "max_length "+strconv.Itoa(limit.Max), "Text of "+kind+" is long")
+	}
+
+	return text, nil
+}
+
+// normalizeText brings the text to NFC, turns every line break into "\n" and exotic spaces such as
+// no-break ones into plain spaces, drops spaces at line ends and squeezes runs of blank lines into one.
+// Indentation inside lines is kept, it matters for code.
+func normalizeText(text string) string {
+	text = norm.NFC.String(text)
+	text = strings.NewReplacer("\r\n", "\n", "\r", "\n", "\u2028", "\n", "\u2029", "\n").Replace(text)
+	text = strings.Map(func(r rune) rune {
+		if r != ' ' && unicode.Is(unicode.Zs, r) {
+			return ' '
+		}
+		return r
+	}, text)
+	text = trailingSpaces.ReplaceAllString(text, "\n")
+	text = blankLines.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// forbiddenRune finds control characters other than line breaks and tabs, and the bidirectional
+// overrides that make text read differently from how it is stored.
+func forbiddenRune(text string) (rune, bool) {
+	for _, r := range text {
+		switch {
+		case r == '\n' || r == '\t':
+		case unicode.IsControl(r):
+			return r, true
+		case r >= '\u202a' && r <= '\u202e', r >= '\u2066' && r <= '\u2069':
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+// characters counts what a reader sees as characters: combining marks, variation selectors and
+// emoji glued with a zero width joiner do not add to the count.
+func characters(text string) int {
+	count := 0
+	joined := false
+	for _, r := range text {
+		switch {
+		case r == '\u200d':
+			joined = true
+			continue
+		case unicode.Is(unicode.M, r), unicode.Is(unicode.Variation_Selector, r):
+			continue
+		case joined:
+			joined = false
+			continue
+		}
+		count++
+	}
+	return count
+}
@@ -0,0 +1,100 @@
+package usecase_test
+
+import (
+	"strings"
+	"testing"
+	"testovoe/internal/entity"
+	"testovoe/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestQuestionUseCase_SaveText(t *testing.T) {
+	mockRepo := new(MockQuestionRepo)
+	mockUserRepo := new(MockUserRepo)
+	uc := usecase.NewQuestionUseCase(mockRepo, new(MockAnswerRepo), new(MockCommentRepo), mockUserRepo, usecase.DefaultTextLimits(), nil)
+	userID := uuid.New()
+	mockUserRepo.On("GetByID", userID).Return(entity.User{ID: userID, DisplayName: "Alice"}, nil)
+
+	save := func(text string) (string, error) {
+		var saved string
+		mockRepo.ExpectedCalls = nil
+		mockRepo.On("Save", mock.Anything).Run(func(args mock.Arguments) {
+			saved = args.Get(0).(entity.Question).Text
+		}).Return(entity.Question{ID: 1}, nil).Once()
+
+		_, err := uc.Save(entity.QuestionDto{UserID: userID, Text: text})
+		return saved, err
+	}
+
+	t.Run("length is counted in characters", func(t *testing.T) {
+		_, err := save(strings.Repeat("ж", 150))
+		assert.NoError(t, err)
+
+		_, err = save(strings.Repeat("ж", 201))
+		assert.Equal(t, []usecase.FieldError{{Field: "text", Rule: "max_length 200"}}, usecase.FieldsOf(err))
+	})
+
+	t.Run("combining marks and joined emoji count once", func(t *testing.T) {
+		family := "\U0001F468\u200d\U0001F469\u200d\U0001F467"
+		_, err := save(strings.Repeat(family, 5))
+		assert.NoError(t, err)
+
+		_, err = save(strings.Repeat("e\u0301", 4))
+		assert.Equal(t, usecase.KindValidation, usecase.KindOf(err))
+	})
+
+	t.Run("text is normalized", func(t *testing.T) {
+		saved, err := save("  Cafe\u0301\u00a0au lait?  \r\n\r\n\r\n\r\nYes.\t \n")
+		assert.NoError(t, err)
+		assert.Equal(t, "Caf\u00e9 au lait?\n\nYes.", saved)
+	})
+
+	t.Run("whitespace only text is short", func(t *testing.T) {
+		_, err := save("   \n\n\t   ")
+		assert.Equal(t, []usecase.FieldError{{Field: "text", Rule: "min_length 5"}}, usecase.FieldsOf(err))
+	})
+
+	t.Run("control characters are rejected", func(t *testing.T) {
+		_, err := save("Hello\x00world")
+		assert.Equal(t, []usecase.FieldError{{Field: "text", Rule: "no_control_characters"}}, usecase.FieldsOf(err))
+
+		_, err = save("Hello \u202eworld")
+		assert.Equal(t, usecase.KindValidation, usecase.KindOf(err))
+	})
+
+	t.Run("anonymous author", func(t *testing.T) {
+		_, err := uc.Save(entity.QuestionDto{Text: "Valid question"})
+		assert.ErrorIs(t, err, usecase.ErrUnauthenticated)
+	})
+}
+
+func TestQuestionUseCase_TextLimits(t *testing.T) {
+	mockRepo := new(MockQuestionRepo)
+	mockUserRepo := new(MockUserRepo)
+	limits := usecase.DefaultTextLimits()
+	limits.Question = usecase.LengthLimit{Min: 2, Max: 10}
+	uc := usecase.NewQuestionUseCase(mockRepo, new(MockAnswerRepo), new(MockCommentRepo), mockUserRepo, limits, nil)
+	userID := uuid.New()
+	mockUserRepo.On("GetByID", userID).Return(entity.User{ID: userID}, nil)
+	mockRepo.On("Save", mock.Anything).Return(entity.Question{ID: 1}, nil)
+
+	_, err := uc.Save(entity.QuestionDto{UserID: userID, Text: "Hi"})
+	assert.NoError(t, err)
+
+	_, err = uc.Save(entity.QuestionDto{UserID: userID, Text: "Far too long"})
+	assert.Equal(t, []usecase.FieldError{{Field: "text", Rule: "max_length 10"}}, usecase.FieldsOf(err))
+}
+
+func TestParseLengthLimit(t *testing.T) {
+	limit, err := usecase.ParseLengthLimit("10-5000")
+	assert.NoError(t, err)
+	assert.Equal(t, usecase.LengthLimit{Min: 10, Max: 5000}, limit)
+
+	for _, value := range []string{"", "200", "0-10", "10-5", "a-b"} {
+		_, err := usecase.ParseLengthLimit(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"errors"
+	"testovoe/internal/entity"
+	"time"
+)
+
+type TrashUseCase struct {
+	questRepo QuestionRepositoriy
+	ansRepo   AnswerRepositoriy
+	retention time.Duration
+}
+
+// NewTrashUseCase makes a trash where deleted items live for the retention window before being purged.
+func NewTrashUseCase(quest QuestionRepositoriy, ansrepo AnswerRepositoriy, retention time.Duration) *TrashUseCase {
+	return &TrashUseCase{
+		questRepo: quest,
+		ansRepo:   ansrepo,
+		retention: retention,
+	}
+}
+
+// ListQuestions shows deleted questions of all users, so it is only for moderators.
+func (uc *TrashUseCase) ListQuestions(actor entity.User, page entity.PageRequest) (entity.Page[entity.Question], error) {
+	if err := canModerate(actor); err != nil {
+		return entity.Page[entity.Question]{}, err
+	}
+
+	return fetchPage(page, uc.questRepo.ListDeleted, deletedQuestionCursor)
+}
+
+func (uc *TrashUseCase) ListAnswers(actor entity.User, page entity.PageRequest) (entity.Page[entity.Answer], error) {
+	if err := canModerate(actor); err != nil {
+		return entity.Page[entity.Answer]{}, err
+	}
+
+	return fetchPage(page, uc.ansRepo.ListDeleted, deletedAnswerCursor)
+}
+
+// Purge permanently removes everything that has been in the trash longer than the retention window.
+func (uc *TrashUseCase) Purge() (entity.PurgeResult, error) {
+	if uc.retention <= 0 {
+		return entity.PurgeResult{}, errors.New("Trash retention must be positive")
+	}
+
+	before := time.Now().Add(-uc.retention)
+
+	answers, err := uc.ansRepo.Purge(before)
+	if err != nil {
+		return entity.PurgeResult{}, err
+	}
+
+	questions, err := uc.questRepo.Purge(before)
+	if err != nil {
+		return entity.PurgeResult{Answers: answers}, err
+	}
+
+	return entity.PurgeResult{Questions: questions, Answers: answers}, nil
+}
+
+func deletedQuestionCursor(q entity.Question) entity.Cursor {
+	return entity.Cursor{Time: *q.DeletedAt, ID: q.ID}
+}
+
+func deletedAnswerCursor(a entity.Answer) entity.Cursor {
+	return entity.Cursor{Time: *a.DeletedAt, ID: a.ID}
+}
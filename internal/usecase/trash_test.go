@@ -0,0 +1,54 @@
+package usecase_test
+
+import (
+	"testing"
+	"testovoe/internal/entity"
+	"testovoe/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTrashUseCase_Purge(t *testing.T) {
+	mockQuestionRepo := new(MockQuestionRepo)
+	mockAnswerRepo := new(MockAnswerRepo)
+	uc := usecase.NewTrashUseCase(mockQuestionRepo, mockAnswerRepo, 24*time.Hour)
+
+	beforeRetention := mock.MatchedBy(func(before time.Time) bool {
+		cutoff := time.Now().Add(-24 * time.Hour)
+		return before.Sub(cutoff).Abs() < time.Minute
+	})
+	mockAnswerRepo.On("Purge", beforeRetention).Return(int64(3), nil)
+	mockQuestionRepo.On("Purge", beforeRetention).Return(int64(1), nil)
+
+	result, err := uc.Purge()
+
+	assert.NoError(t, err)
+	assert.Equal(t, entity.PurgeResult{Questions: 1, Answers: 3}, result)
+	mockAnswerRepo.AssertExpectations(t)
+	mockQuestionRepo.AssertExpectations(t)
+}
+
+func TestTrashUseCase_ListQuestions(t *testing.T) {
+	mockQuestionRepo := new(MockQuestionRepo)
+	uc := usecase.NewTrashUseCase(mockQuestionRepo, new(MockAnswerRepo), time.Hour)
+
+	deletedAt := time.Now()
+	questions := []entity.Question{{ID: 2, DeletedAt: &deletedAt}, {ID: 1, DeletedAt: &deletedAt}}
+	mockQuestionRepo.On("ListDeleted", entity.PageRequest{Limit: 2}).Return(questions, nil)
+
+	_, err := uc.ListQuestions(entity.User{ID: uuid.New(), Role: entity.RoleUser}, entity.PageRequest{Limit: 1})
+	assert.ErrorIs(t, err, usecase.ErrForbidden)
+
+	page, err := uc.ListQuestions(entity.User{ID: uuid.New(), Role: entity.RoleModerator}, entity.PageRequest{Limit: 1})
+
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+
+	cursor, err := usecase.DecodeCursor(page.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cursor.ID)
+	assert.True(t, deletedAt.Equal(cursor.Time))
+}
@@ -0,0 +1,211 @@
+package usecase
+
+import (
+	"net/mail"
+	"strings"
+	"testovoe/internal/entity"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+type UserRepositoriy interface {
+	GetByID(uuid.UUID) (entity.User, error)
+	GetByEmail(string) (entity.User, error)
+	Save(entity.User) (entity.User, error)
+	Update(entity.User) (entity.User, error)
+	SetRole(uuid.UUID, string) (entity.User, error)
+}
+
+type UserUseCase struct {
+	repo UserRepositoriy
+}
+
+func NewUserUseCase(repo UserRepositoriy) *UserUseCase {
+	return &UserUseCase{
+		repo: repo,
+	}
+}
+
+func (uc *UserUseCase) Register(dto entity.UserDto) (entity.User, error) {
+	name, nameErr := normalizeDisplayName(dto.DisplayName)
+	email, emailErr := normalizeEmail(dto.Email)
+	if err := joinInvalid(nameErr, emailErr); err != nil {
+		return entity.User{}, err
+	}
+
+	if err := uc.checkEmailFree(email, uuid.Nil); err != nil {
+		return entity.User{}, err
+	}
+
+	user := entity.User{
+		ID:          uuid.New(),
+		DisplayName: name,
+		Email:       email,
+		Role:        entity.RoleUser,
+		CreatedAt:   time.Now(),
+	}
+
+	return uc.repo.Save(user)
+}
+
+// Profile shows the email and the role only to the user themselves and to admins,
+// anybody else gets the public summary.
+func (uc *UserUseCase) Profile(actor entity.User, ID uuid.UUID) (interface{}, error) {
+	user, err := uc.repo.GetByID(ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if actor.ID != ID && !actor.IsAdmin() {
+		return user.Summary(), nil
+	}
+	return user, nil
+}
+
+// Update changes the profile, users edit their own profile and admins edit anybody's.
+func (uc *UserUseCase) Update(actor entity.User, ID uuid.UUID, dto entity.UserUpdateDto) (entity.User, error) {
+	if actor.ID != ID {
+		if err := canManageRoles(actor); err != nil {
+			return entity.User{}, err
+		}
+	}
+
+	if dto.DisplayName == nil && dto.Email == nil {
+		return entity.User{}, Validation("Nothing to update")
+	}
+
+	user, err := uc.repo.GetByID(ID)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	if dto.DisplayName != nil {
+		name, err := normalizeDisplayName(*dto.DisplayName)
+		if err != nil {
+			return entity.User{}, err
+		}
+		user.DisplayName = name
+	}
+
+	if dto.Email != nil {
+		email, err := normalizeEmail(*dto.Email)
+		if err != nil {
+			return entity.User{}, err
+		}
+		if err := uc.checkEmailFree(email, ID); err != nil {
+			return entity.User{}, err
+		}
+		user.Email = email
+	}
+
+	now := time.Now()
+	user.UpdatedAt = &now
+
+	return uc.repo.Update(user)
+}
+
+// GrantRole makes the user a moderator or an admin, only admins manage roles.
+// Granting never lowers a role: an admin stays an admin until the admin role is revoked.
+func (uc *UserUseCase) GrantRole(actor entity.User, ID uuid.UUID, role string) (entity.User, error) {
+	if err := canManageRoles(actor); err != nil {
+		return entity.User{}, err
+	}
+
+	if role != entity.RoleModerator && role != entity.RoleAdmin {
+		return entity.User{}, Invalid("role", "one_of moderator admin", "Role must be moderator or admin")
+	}
+
+	user, err := uc.repo.GetByID(ID)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	if user.Role == role {
+		return user, nil
+	}
+
+	if user.IsAdmin() {
+		return entity.User{}, Conflict("This user is already an admin, revoke the admin role first")
+	}
+
+	return uc.repo.SetRole(ID, role)
+}
+
+// RevokeRole turns the user back into a regular user if they have the role.
+// Admins can not revoke their own role so there is always somebody to manage roles.
+func (uc *UserUseCase) RevokeRole(actor entity.User, ID uuid.UUID, role string) (entity.User, error) {
+	if err := canManageRoles(actor); err != nil {
+		return entity.User{}, err
+	}
+
+	if role != entity.RoleModerator && role != entity.RoleAdmin {
+		return entity.User{}, Invalid("role", "one_of moderator admin", "Role must be moderator or admin")
+	}
+
+	if actor.ID == ID {
+		return entity.User{}, Conflict("You can not revoke your own role")
+	}
+
+	user, err := uc.repo.GetByID(ID)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	if user.Role != role {
+		return user, nil
+	}
+
+	return uc.repo.SetRole(ID, entity.RoleUser)
+}
+
+// checkEmailFree fails when the email belongs to somebody other than the given user.
+func (uc *UserUseCase) checkEmailFree(email string, owner uuid.UUID) error {
+	user, err := uc.repo.GetByEmail(email)
+	if err == nil && user.ID != owner {
+		return Conflict("This email is already registered")
+	}
+	if err != nil && KindOf(err) != KindNotFound {
+		return err
+	}
+	return nil
+}
+
+// authorOf loads the author of new content, content from unknown users is rejected.
+func authorOf(repo UserRepositoriy, userID uuid.UUID) (*entity.UserSummary, error) {
+	user, err := repo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return user.Summary(), nil
+}
+
+func normalizeDisplayName(name string) (string, error) {
+	name = normalizeText(name)
+
+	if _, ok := forbiddenRune(name); ok || strings.ContainsAny(name, "\n\t") {
+		return "", Invalid("display_name", "no_control_characters", "Display name has forbidden characters")
+	}
+
+	if utf8.RuneCountInString(name) < 2 {
+		return "", Invalid("display_name", "min_length 2", "Display name is short")
+	}
+
+	if utf8.RuneCountInString(name) > 50 {
+		return "", Invalid("display_name", "max_length 50", "Display name is long")
+	}
+
+	return name, nil
+}
+
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", Invalid("email", "format email", "Email is invalid")
+	}
+
+	return email, nil
+}
@@ -0,0 +1,192 @@
+package usecase_test
+
+import (
+	"testing"
+	"testovoe/internal/entity"
+	"testovoe/internal/usecase"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockUserRepo struct{ mock.Mock }
+
+func (m *MockUserRepo) GetByID(id uuid.UUID) (entity.User, error) {
+	args := m.Called(id)
+	return args.Get(0).(entity.User), args.Error(1)
+}
+
+func (m *MockUserRepo) GetByEmail(email string) (entity.User, error) {
+	args := m.Called(email)
+	return args.Get(0).(entity.User), args.Error(1)
+}
+
+func (m *MockUserRepo) Save(user entity.User) (entity.User, error) {
+	args := m.Called(user)
+	return args.Get(0).(entity.User), args.Error(1)
+}
+
+func (m *MockUserRepo) Update(user entity.User) (entity.User, error) {
+	args := m.Called(user)
+	return args.Get(0).(entity.User), args.Error(1)
+}
+
+func (m *MockUserRepo) SetRole(id uuid.UUID, role string) (entity.User, error) {
+	args := m.Called(id, role)
+	return args.Get(0).(entity.User), args.Error(1)
+}
+
+func TestUserUseCase_Register(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	uc := usecase.NewUserUseCase(mockRepo)
+
+	t.Run("success", func(t *testing.T) {
+		mockRepo.On("GetByEmail", "alice@example.com").Return(entity.User{}, usecase.NotFound("not found")).Once()
+		mockRepo.On("Save", mock.MatchedBy(func(u entity.User) bool {
+			return u.ID != uuid.Nil && u.DisplayName == "Алиса" && u.Email == "alice@example.com"
+		})).Return(entity.User{DisplayName: "Алиса"}, nil)
+
+		_, err := uc.Register(entity.UserDto{DisplayName: "  Алиса ", Email: "Alice@Example.com"})
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("email taken", func(t *testing.T) {
+		mockRepo.On("GetByEmail", "bob@example.com").Return(entity.User{ID: uuid.New()}, nil)
+
+		_, err := uc.Register(entity.UserDto{DisplayName: "Bob", Email: "bob@example.com"})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, dto := range []entity.UserDto{
+			{DisplayName: "B", Email: "b@example.com"},
+			{DisplayName: "Bob", Email: "not an email"},
+			{DisplayName: "Bob", Email: "Bob <bob@example.com>"},
+		} {
+			_, err := uc.Register(dto)
+			assert.Error(t, err, dto)
+		}
+	})
+}
+
+func TestUserUseCase_Profile(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	uc := usecase.NewUserUseCase(mockRepo)
+	user := entity.User{ID: uuid.New(), DisplayName: "Alice", Email: "alice@example.com", Role: entity.RoleUser}
+	mockRepo.On("GetByID", user.ID).Return(user, nil)
+
+	for name, actor := range map[string]entity.User{
+		"self":  user,
+		"admin": {ID: uuid.New(), Role: entity.RoleAdmin},
+	} {
+		profile, err := uc.Profile(actor, user.ID)
+		assert.NoError(t, err, name)
+		assert.Equal(t, user, profile, name)
+	}
+
+	for name, actor := range map[string]entity.User{
+		"anonymous": {},
+		"other":     {ID: uuid.New(), Role: entity.RoleUser},
+		"moderator": {ID: uuid.New(), Role: entity.RoleModerator},
+	} {
+		profile, err := uc.Profile(actor, user.ID)
+		assert.NoError(t, err, name)
+		assert.Equal(t, user.Summary(), profile, name)
+	}
+}
+
+func TestUserUseCase_Update(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	uc := usecase.NewUserUseCase(mockRepo)
+	userID := uuid.New()
+	self := entity.User{ID: userID, Role: entity.RoleUser}
+	mockRepo.On("GetByID", userID).Return(entity.User{ID: userID, DisplayName: "Alice", Email: "alice@example.com"}, nil)
+
+	t.Run("own email is not taken", func(t *testing.T) {
+		email := "alice@example.com"
+		name := "Alice B."
+		mockRepo.On("GetByEmail", email).Return(entity.User{ID: userID}, nil)
+		mockRepo.On("Update", mock.MatchedBy(func(u entity.User) bool {
+			return u.DisplayName == name && u.UpdatedAt != nil
+		})).Return(entity.User{ID: userID, DisplayName: name}, nil)
+
+		result, err := uc.Update(self, userID, entity.UserUpdateDto{DisplayName: &name, Email: &email})
+
+		assert.NoError(t, err)
+		assert.Equal(t, name, result.DisplayName)
+	})
+
+	t.Run("nothing to update", func(t *testing.T) {
+		_, err := uc.Update(self, userID, entity.UserUpdateDto{})
+		assert.Error(t, err)
+	})
+
+	t.Run("somebody else", func(t *testing.T) {
+		name := "Mallory"
+		_, err := uc.Update(entity.User{ID: uuid.New(), Role: entity.RoleModerator}, userID, entity.UserUpdateDto{DisplayName: &name})
+		assert.ErrorIs(t, err, usecase.ErrForbidden)
+	})
+}
+
+func TestUserUseCase_Roles(t *testing.T) {
+	mockRepo := new(MockUserRepo)
+	uc := usecase.NewUserUseCase(mockRepo)
+	admin := entity.User{ID: uuid.New(), Role: entity.RoleAdmin}
+	userID := uuid.New()
+	mockRepo.On("GetByID", userID).Return(entity.User{ID: userID, Role: entity.RoleModerator}, nil)
+
+	t.Run("only admins manage roles", func(t *testing.T) {
+		_, err := uc.GrantRole(entity.User{ID: uuid.New(), Role: entity.RoleModerator}, userID, entity.RoleAdmin)
+		assert.ErrorIs(t, err, usecase.ErrForbidden)
+	})
+
+	t.Run("grant", func(t *testing.T) {
+		mockRepo.On("SetRole", userID, entity.RoleAdmin).Return(entity.User{ID: userID, Role: entity.RoleAdmin}, nil).Once()
+
+		result, err := uc.GrantRole(admin, userID, entity.RoleAdmin)
+
+		assert.NoError(t, err)
+		assert.Equal(t, entity.RoleAdmin, result.Role)
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		_, err := uc.GrantRole(admin, userID, "owner")
+		assert.Error(t, err)
+	})
+
+	t.Run("grant does not demote an admin", func(t *testing.T) {
+		adminID := uuid.New()
+		mockRepo.On("GetByID", adminID).Return(entity.User{ID: adminID, Role: entity.RoleAdmin}, nil).Once()
+
+		_, err := uc.GrantRole(admin, adminID, entity.RoleModerator)
+
+		assert.Equal(t, usecase.KindConflict, usecase.KindOf(err))
+		mockRepo.AssertNumberOfCalls(t, "SetRole", 1)
+	})
+
+	t.Run("revoke role the user does not have", func(t *testing.T) {
+		result, err := uc.RevokeRole(admin, userID, entity.RoleAdmin)
+
+		assert.NoError(t, err)
+		assert.Equal(t, entity.RoleModerator, result.Role)
+		mockRepo.AssertNumberOfCalls(t, "SetRole", 1)
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		mockRepo.On("SetRole", userID, entity.RoleUser).Return(entity.User{ID: userID, Role: entity.RoleUser}, nil).Once()
+
+		result, err := uc.RevokeRole(admin, userID, entity.RoleModerator)
+
+		assert.NoError(t, err)
+		assert.Equal(t, entity.RoleUser, result.Role)
+	})
+
+	t.Run("own role", func(t *testing.T) {
+		_, err := uc.RevokeRole(admin, admin.ID, entity.RoleAdmin)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,18 @@
+package usecase
+
+// ErrVersionMismatch is returned when the client changes something that was changed since it was read.
+var ErrVersionMismatch = Precondition("This was changed by somebody else, reload it and try again")
+
+// checkVersion tells whether the current version is one the client accepts, nil means the client
+// did not say and an empty list means none can match.
+func checkVersion(current int, accepted []int) error {
+	if accepted == nil {
+		return nil
+	}
+	for _, version := range accepted {
+		if version == current {
+			return nil
+		}
+	}
+	return ErrVersionMismatch
+}
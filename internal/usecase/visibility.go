@@ -0,0 +1,34 @@
+package usecase
+
+import "testovoe/internal/entity"
+
+// visibleQuestion gets a question readers may see, a hidden question is not found for them.
+func visibleQuestion(repo QuestionRepositoriy, ID int) (entity.Question, error) {
+	question, err := repo.GetByID(ID)
+	if err != nil {
+		return entity.Question{}, err
+	}
+	if question.HiddenAt != nil {
+		return entity.Question{}, NotFound("This question is not exist")
+	}
+	return question, nil
+}
+
+// visibleAnswer gets an answer readers may see, an answer that is hidden or belongs to a hidden
+// question is not found for them.
+func visibleAnswer(repo AnswerRepositoriy, questRepo QuestionRepositoriy, ID int) (entity.Answer, error) {
+	answer, err := repo.GetByID(ID)
+	if err != nil {
+		return entity.Answer{}, err
+	}
+	if answer.HiddenAt != nil {
+		return entity.Answer{}, NotFound("This answer is not exist")
+	}
+	if _, err := visibleQuestion(questRepo, answer.QuestionID); err != nil {
+		if KindOf(err) == KindNotFound {
+			return entity.Answer{}, NotFound("This answer is not exist")
+		}
+		return entity.Answer{}, err
+	}
+	return answer, nil
+}
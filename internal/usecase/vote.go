@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"testovoe/internal/entity"
+
+	"github.com/google/uuid"
+)
+
+type VoteRepositoriy interface {
+	// Save creates or changes the vote and moves the score of its target by the difference, returns the new score.
+	Save(entity.Vote) (int, error)
+	// Delete retracts the vote and returns the new score of its target.
+	Delete(userID uuid.UUID, targetType string, targetID int) (int, error)
+}
+
+type VoteUseCase struct {
+	voteRepo  VoteRepositoriy
+	questRepo QuestionRepositoriy
+	ansRepo   AnswerRepositoriy
+}
+
+func NewVoteUseCase(voteRepo VoteRepositoriy, quest QuestionRepositoriy, ansrepo AnswerRepositoriy) *VoteUseCase {
+	return &VoteUseCase{
+		voteRepo:  voteRepo,
+		questRepo: quest,
+		ansRepo:   ansrepo,
+	}
+}
+
+func (uc *VoteUseCase) VoteQuestion(questionID int, dto entity.VoteDto) (entity.VoteResult, error) {
+	if _, err := visibleQuestion(uc.questRepo, questionID); err != nil {
+		return entity.VoteResult{}, err
+	}
+
+	return uc.vote(entity.VoteTargetQuestion, questionID, dto)
+}
+
+func (uc *VoteUseCase) VoteAnswer(answerID int, dto entity.VoteDto) (entity.VoteResult, error) {
+	if _, err := visibleAnswer(uc.ansRepo, uc.questRepo, answerID); err != nil {
+		return entity.VoteResult{}, err
+	}
+
+	return uc.vote(entity.VoteTargetAnswer, answerID, dto)
+}
+
+func (uc *VoteUseCase) RetractQuestion(questionID int, userID uuid.UUID) (entity.VoteResult, error) {
+	if _, err := visibleQuestion(uc.questRepo, questionID); err != nil {
+		return entity.VoteResult{}, err
+	}
+
+	return uc.retract(entity.VoteTargetQuestion, questionID, userID)
+}
+
+func (uc *VoteUseCase) RetractAnswer(answerID int, userID uuid.UUID) (entity.VoteResult, error) {
+	if _, err := visibleAnswer(uc.ansRepo, uc.questRepo, answerID); err != nil {
+		return entity.VoteResult{}, err
+	}
+
+	return uc.retract(entity.VoteTargetAnswer, answerID, userID)
+}
+
+func (uc *VoteUseCase) vote(targetType string, targetID int, dto entity.VoteDto) (entity.VoteResult, error) {
+	if dto.Value != entity.VoteUp && dto.Value != entity.VoteDown {
+		return entity.VoteResult{}, Invalid("value", "one_of 1 -1", "Vote must be 1 or -1")
+	}
+
+	if dto.UserID == uuid.Nil {
+		return entity.VoteResult{}, ErrUnauthenticated
+	}
+
+	score, err := uc.voteRepo.Save(entity.Vote{
+		UserID:     dto.UserID,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Value:      dto.Value,
+	})
+	if err != nil {
+		return entity.VoteResult{}, err
+	}
+
+	return entity.VoteResult{Score: score, Vote: dto.Value}, nil
+}
+
+func (uc *VoteUseCase) retract(targetType string, targetID int, userID uuid.UUID) (entity.VoteResult, error) {
+	if userID == uuid.Nil {
+		return entity.VoteResult{}, ErrUnauthenticated
+	}
+
+	score, err := uc.voteRepo.Delete(userID, targetType, targetID)
+	if err != nil {
+		return entity.VoteResult{}, err
+	}
+
+	return entity.VoteResult{Score: score}, nil
+}
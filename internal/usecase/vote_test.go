@@ -0,0 +1,75 @@
+package usecase_test
+
+import (
+	"errors"
+	"testing"
+	"testovoe/internal/entity"
+	"testovoe/internal/usecase"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockVoteRepo struct{ mock.Mock }
+
+func (m *MockVoteRepo) Save(vote entity.Vote) (int, error) {
+	args := m.Called(vote)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockVoteRepo) Delete(userID uuid.UUID, targetType string, targetID int) (int, error) {
+	args := m.Called(userID, targetType, targetID)
+	return args.Int(0), args.Error(1)
+}
+
+func TestVoteUseCase_VoteAnswer(t *testing.T) {
+	mockVoteRepo := new(MockVoteRepo)
+	mockAnswerRepo := new(MockAnswerRepo)
+	mockQuestionRepo := new(MockQuestionRepo)
+	uc := usecase.NewVoteUseCase(mockVoteRepo, mockQuestionRepo, mockAnswerRepo)
+	userID := uuid.New()
+	hiddenAt := time.Now()
+
+	mockAnswerRepo.On("GetByID", 1).Return(entity.Answer{ID: 1, QuestionID: 1}, nil)
+	mockAnswerRepo.On("GetByID", 2).Return(entity.Answer{ID: 2, QuestionID: 2}, nil)
+	mockQuestionRepo.On("GetByID", 1).Return(entity.Question{ID: 1}, nil)
+	mockQuestionRepo.On("GetByID", 2).Return(entity.Question{ID: 2, HiddenAt: &hiddenAt}, nil)
+	mockAnswerRepo.On("GetByID", 999).Return(entity.Answer{}, errors.New("not found"))
+
+	t.Run("success", func(t *testing.T) {
+		vote := entity.Vote{UserID: userID, TargetType: entity.VoteTargetAnswer, TargetID: 1, Value: entity.VoteDown}
+		mockVoteRepo.On("Save", vote).Return(-1, nil)
+
+		result, err := uc.VoteAnswer(1, entity.VoteDto{UserID: userID, Value: entity.VoteDown})
+
+		assert.NoError(t, err)
+		assert.Equal(t, entity.VoteResult{Score: -1, Vote: entity.VoteDown}, result)
+		mockVoteRepo.AssertExpectations(t)
+	})
+
+	t.Run("retract", func(t *testing.T) {
+		mockVoteRepo.On("Delete", userID, entity.VoteTargetAnswer, 1).Return(0, nil)
+
+		result, err := uc.RetractAnswer(1, userID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, entity.VoteResult{}, result)
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		_, err := uc.VoteAnswer(1, entity.VoteDto{UserID: userID, Value: 5})
+		assert.Error(t, err)
+	})
+
+	t.Run("answer of a hidden question", func(t *testing.T) {
+		_, err := uc.VoteAnswer(2, entity.VoteDto{UserID: userID, Value: entity.VoteUp})
+		assert.Equal(t, usecase.KindNotFound, usecase.KindOf(err))
+	})
+
+	t.Run("answer not found", func(t *testing.T) {
+		_, err := uc.VoteAnswer(999, entity.VoteDto{UserID: userID, Value: entity.VoteUp})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE questions ADD COLUMN answer_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE questions ADD COLUMN last_activity_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE questions q SET
+    answer_count = (SELECT COUNT(*) FROM answers a WHERE a.question_id = q.id),
+    last_activity_at = GREATEST(q.created_at, (SELECT MAX(a.created_at) FROM answers a WHERE a.question_id = q.id));
+
+ALTER TABLE questions ALTER COLUMN last_activity_at SET NOT NULL;
+ALTER TABLE questions ALTER COLUMN last_activity_at SET DEFAULT NOW();
+
+CREATE INDEX idx_questions_user_id ON questions (user_id);
+CREATE INDEX idx_questions_answer_count_id ON questions (answer_count, id);
+CREATE INDEX idx_questions_last_activity_at_id ON questions (last_activity_at, id);
+
+-- +goose Down
+DROP INDEX idx_questions_last_activity_at_id;
+DROP INDEX idx_questions_answer_count_id;
+DROP INDEX idx_questions_user_id;
+ALTER TABLE questions DROP COLUMN last_activity_at;
+ALTER TABLE questions DROP COLUMN answer_count;
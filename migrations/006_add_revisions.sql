@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE questions ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE answers ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE question_revisions (
+    id SERIAL PRIMARY KEY,
+    question_id INTEGER NOT NULL REFERENCES questions(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT idx_question_revisions_question_id_revision UNIQUE (question_id, revision)
+);
+
+CREATE TABLE answer_revisions (
+    id SERIAL PRIMARY KEY,
+    answer_id INTEGER NOT NULL REFERENCES answers(id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    CONSTRAINT idx_answer_revisions_answer_id_revision UNIQUE (answer_id, revision)
+);
+
+-- +goose Down
+DROP TABLE answer_revisions;
+DROP TABLE question_revisions;
+ALTER TABLE answers DROP COLUMN updated_at;
+ALTER TABLE questions DROP COLUMN updated_at;
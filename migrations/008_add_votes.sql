@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE votes (
+    user_id UUID NOT NULL,
+    target_type VARCHAR(16) NOT NULL CHECK (target_type IN ('question', 'answer')),
+    target_id INTEGER NOT NULL,
+    value SMALLINT NOT NULL CHECK (value IN (-1, 1)),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, target_type, target_id)
+);
+
+CREATE INDEX idx_votes_target ON votes (target_type, target_id);
+
+ALTER TABLE questions ADD COLUMN score INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE answers ADD COLUMN score INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_answers_question_id_score_id ON answers (question_id, score, id);
+
+-- +goose Down
+DROP INDEX idx_answers_question_id_score_id;
+ALTER TABLE answers DROP COLUMN score;
+ALTER TABLE questions DROP COLUMN score;
+DROP TABLE votes;
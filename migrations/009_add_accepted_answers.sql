@@ -0,0 +1,10 @@
+-- +goose Up
+ALTER TABLE questions ADD COLUMN accepted_answer_id INTEGER REFERENCES answers(id) ON DELETE SET NULL;
+ALTER TABLE answers ADD COLUMN is_accepted BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_questions_accepted_answer_id ON questions (accepted_answer_id);
+
+-- +goose Down
+DROP INDEX idx_questions_accepted_answer_id;
+ALTER TABLE answers DROP COLUMN is_accepted;
+ALTER TABLE questions DROP COLUMN accepted_answer_id;
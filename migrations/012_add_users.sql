@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE users (
+    id UUID PRIMARY KEY,
+    display_name VARCHAR(50) NOT NULL,
+    email VARCHAR(254) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Questions and answers written before users existed keep their user_id without a profile,
+-- so there are no foreign keys; new content is checked against users in the application.
+CREATE INDEX idx_answers_user_id ON answers (user_id);
+
+-- +goose Down
+DROP INDEX idx_answers_user_id;
+DROP TABLE users;
@@ -0,0 +1,18 @@
+-- +goose Up
+-- Users registered through POST /users have no password and can not log in.
+ALTER TABLE users ADD COLUMN password_hash VARCHAR(72) NOT NULL DEFAULT '';
+
+CREATE TABLE refresh_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+
+-- +goose Down
+DROP TABLE refresh_tokens;
+ALTER TABLE users DROP COLUMN password_hash;
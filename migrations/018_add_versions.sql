@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE questions ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE answers ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+-- +goose Down
+ALTER TABLE answers DROP COLUMN version;
+ALTER TABLE questions DROP COLUMN version;
@@ -0,0 +1,21 @@
+-- +goose Up
+CREATE TABLE held_contents (
+    id SERIAL PRIMARY KEY,
+    kind VARCHAR(16) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    text TEXT NOT NULL,
+    tags TEXT NOT NULL DEFAULT '',
+    question_id INTEGER REFERENCES questions(id) ON DELETE CASCADE,
+    edit BOOLEAN NOT NULL DEFAULT FALSE,
+    post_id INTEGER,
+    version INTEGER,
+    policy VARCHAR(32) NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_held_contents_created_at ON held_contents (created_at, id);
+CREATE INDEX idx_held_contents_user_id ON held_contents (user_id);
+
+-- +goose Down
+DROP TABLE held_contents;
@@ -0,0 +1,40 @@
+-- +goose Up
+ALTER TABLE questions ADD COLUMN hidden_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE questions ADD COLUMN locked_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE answers ADD COLUMN hidden_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE answers ADD COLUMN locked_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE flags (
+    id SERIAL PRIMARY KEY,
+    target_type VARCHAR(16) NOT NULL,
+    target_id INTEGER NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason VARCHAR(16) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_flags_user_target ON flags (target_type, target_id, user_id);
+CREATE INDEX idx_flags_target ON flags (target_type, target_id);
+CREATE INDEX idx_flags_pending ON flags (created_at, id) WHERE status = 'pending';
+
+CREATE TABLE moderation_decisions (
+    id SERIAL PRIMARY KEY,
+    target_type VARCHAR(16) NOT NULL,
+    target_id INTEGER NOT NULL,
+    moderator_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action VARCHAR(16) NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_moderation_decisions_created_at ON moderation_decisions (created_at DESC, id DESC);
+
+-- +goose Down
+DROP TABLE moderation_decisions;
+DROP TABLE flags;
+ALTER TABLE answers DROP COLUMN locked_at;
+ALTER TABLE answers DROP COLUMN hidden_at;
+ALTER TABLE questions DROP COLUMN locked_at;
+ALTER TABLE questions DROP COLUMN hidden_at;
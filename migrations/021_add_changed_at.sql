@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE questions ADD COLUMN changed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE answers ADD COLUMN changed_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE answers SET changed_at = GREATEST(created_at, updated_at, hidden_at, locked_at, deleted_at);
+UPDATE questions q SET
+    changed_at = GREATEST(q.last_activity_at, q.updated_at, q.hidden_at, q.locked_at,
+        (SELECT MAX(a.changed_at) FROM answers a WHERE a.question_id = q.id));
+
+ALTER TABLE questions ALTER COLUMN changed_at SET NOT NULL;
+ALTER TABLE questions ALTER COLUMN changed_at SET DEFAULT NOW();
+ALTER TABLE answers ALTER COLUMN changed_at SET NOT NULL;
+ALTER TABLE answers ALTER COLUMN changed_at SET DEFAULT NOW();
+
+-- +goose Down
+ALTER TABLE answers DROP COLUMN changed_at;
+ALTER TABLE questions DROP COLUMN changed_at;